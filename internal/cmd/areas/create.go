@@ -4,7 +4,7 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"thingies/internal/things"
+	"thingies/internal/cmd/shared"
 )
 
 var createCmd = &cobra.Command{
@@ -17,7 +17,7 @@ var createCmd = &cobra.Command{
 func runCreate(cmd *cobra.Command, args []string) error {
 	name := args[0]
 
-	uuid, err := things.CreateArea(name)
+	uuid, err := shared.GetClient(cmd).CreateArea(name)
 	if err != nil {
 		return err
 	}
@@ -6,7 +6,6 @@ import (
 	"github.com/spf13/cobra"
 	"thingies/internal/cmd/shared"
 	"thingies/internal/db"
-	"thingies/internal/things"
 )
 
 var deleteCmd = &cobra.Command{
@@ -35,7 +34,7 @@ func runDelete(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if err := things.DeleteArea(fullUUID); err != nil {
+	if err := shared.GetClient(cmd).DeleteArea(fullUUID); err != nil {
 		return err
 	}
 
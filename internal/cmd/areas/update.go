@@ -6,7 +6,6 @@ import (
 	"github.com/spf13/cobra"
 	"thingies/internal/cmd/shared"
 	"thingies/internal/db"
-	"thingies/internal/things"
 )
 
 var (
@@ -43,7 +42,7 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if err := things.UpdateArea(fullUUID, updateName); err != nil {
+	if err := shared.GetClient(cmd).UpdateArea(fullUUID, updateName); err != nil {
 		return err
 	}
 
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"thingies/internal/cmd/shared"
+	"thingies/internal/db"
+	"thingies/internal/output"
+)
+
+var changesSince string
+
+var changesCmd = &cobra.Command{
+	Use:   "changes [--since <time|cursor>]",
+	Short: "Show what changed since a time or cursor",
+	Long: `Show the tasks, projects, and headings created, updated, or trashed after
+--since, which is an RFC3339 time (2026-01-02T15:04:05Z) or the cursor printed
+by an earlier run. The area and tag lists are included when they changed.
+Without --since, everything is listed as created.
+
+Pass each run's cursor to the next to sync incrementally:
+
+  thingies changes --json > full.json
+  thingies changes --json --since "$(jq -r .cursor full.json)"
+
+Changes come from modification dates, so an item edited several times shows
+up once. Items removed by emptying the trash are not reported.`,
+	Args: cobra.NoArgs,
+	RunE: runChanges,
+}
+
+func init() {
+	changesCmd.Flags().StringVar(&changesSince, "since", "", "RFC3339 time or cursor from an earlier run")
+
+	rootCmd.AddCommand(changesCmd)
+}
+
+func runChanges(cmd *cobra.Command, args []string) error {
+	thingsDB, err := db.Open(shared.GetDBPath(cmd))
+	if err != nil {
+		return err
+	}
+	defer thingsDB.Close()
+
+	changes, err := thingsDB.Changes(changesSince)
+	if err != nil {
+		return err
+	}
+
+	if shared.IsJSON(cmd) {
+		data, err := json.MarshalIndent(changes.ToJSON(), "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	formatter := output.NewTableFormatter(shared.IsNoColor(cmd))
+	return formatter.FormatChanges(changes)
+}
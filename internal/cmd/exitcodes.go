@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"errors"
+
+	"thingies/internal/db"
+	"thingies/internal/things"
+)
+
+// Exit codes returned by Execute, so scripts can branch on why a command failed
+const (
+	ExitError            = 1 // unclassified failure (including usage errors)
+	ExitNotFound         = 3 // no task/project/area/tag/heading matched
+	ExitAmbiguous        = 4 // a UUID prefix or name matched several items
+	ExitInvalidPrefix    = 5 // a UUID prefix was empty or not alphanumeric
+	ExitAppNotRunning    = 6 // Things could not be reached
+	ExitPermissionDenied = 7 // macOS blocked automation of Things
+	ExitCreateTimeout    = 8 // a created item did not appear in the database in time
+)
+
+// ExitCode maps an error returned by a command to a process exit code
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, db.ErrNotFound), errors.Is(err, things.ErrObjectMissing):
+		return ExitNotFound
+	case errors.Is(err, db.ErrAmbiguous):
+		return ExitAmbiguous
+	case errors.Is(err, db.ErrInvalidPrefix):
+		return ExitInvalidPrefix
+	case errors.Is(err, things.ErrAppNotRunning):
+		return ExitAppNotRunning
+	case errors.Is(err, things.ErrPermissionDenied):
+		return ExitPermissionDenied
+	case errors.Is(err, db.ErrCreateTimeout):
+		return ExitCreateTimeout
+	default:
+		return ExitError
+	}
+}
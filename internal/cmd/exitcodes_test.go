@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/things"
+)
+
+func TestExitCodeFromCommand(t *testing.T) {
+	b := dbtest.New(t)
+	b.Task("one").WithUUID("Dup1000000000000000000")
+	b.Task("two").WithUUID("Dup2000000000000000000")
+	b.Task("three").WithUUID("Run0000000000000000000")
+	path := b.Path()
+
+	rootCmd.SetErr(io.Discard)
+	defer rootCmd.SetErr(nil)
+
+	tests := []struct {
+		name    string
+		args    []string
+		backend things.Backend
+		want    int
+	}{
+		{"not found", []string{"tasks", "complete", "Nope"}, things.NewRecordingBackend(), ExitNotFound},
+		{"ambiguous prefix", []string{"tasks", "complete", "Dup"}, things.NewRecordingBackend(), ExitAmbiguous},
+		{"invalid prefix", []string{"tasks", "complete", "Du-p"}, things.NewRecordingBackend(), ExitInvalidPrefix},
+		{"app not running", []string{"tasks", "complete", "Run"},
+			&things.RecordingBackend{Err: errors.New("applescript error: Things3 got an error: Application isn't running. (-600)")}, ExitAppNotRunning},
+		{"permission denied", []string{"tasks", "complete", "Run"},
+			&things.RecordingBackend{Err: errors.New("applescript error: Not authorized to send Apple events to Things3. (-1743)")}, ExitPermissionDenied},
+		{"object missing", []string{"tasks", "complete", "Run"},
+			&things.RecordingBackend{Err: errors.New(`applescript error: Can't get to do id "Run0000000000000000000". (-1728)`)}, ExitNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootCmd.SetArgs(append([]string{"--db", path}, tt.args...))
+			defer rootCmd.SetArgs(nil)
+
+			err := ExecuteWith(tt.backend)
+			if got := ExitCode(err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitCodeUnclassified(t *testing.T) {
+	if got := ExitCode(nil); got != 0 {
+		t.Errorf("ExitCode(nil) = %d", got)
+	}
+	if got := ExitCode(fmt.Errorf("boom")); got != ExitError {
+		t.Errorf("ExitCode(boom) = %d, want %d", got, ExitError)
+	}
+}
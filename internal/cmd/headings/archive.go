@@ -0,0 +1,38 @@
+package headings
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"thingies/internal/cmd/shared"
+	"thingies/internal/db"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive <heading>",
+	Short: "Archive a heading",
+	Long: `Archive a heading using AppleScript. Things archives a heading by completing
+it; tasks under it are left as they are.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runArchive,
+}
+
+func runArchive(cmd *cobra.Command, args []string) error {
+	thingsDB, err := db.Open(shared.GetDBPath(cmd))
+	if err != nil {
+		return err
+	}
+	defer thingsDB.Close()
+
+	uuid, err := resolveHeading(thingsDB, args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := shared.GetClient(cmd).ArchiveHeading(uuid); err != nil {
+		return fmt.Errorf("failed to archive heading: %w", err)
+	}
+
+	fmt.Printf("Archived heading: %s\n", uuid)
+	return nil
+}
@@ -0,0 +1,42 @@
+package headings
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"thingies/internal/cmd/shared"
+	"thingies/internal/db"
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete <heading>",
+	Short: "Delete a heading",
+	Long:  `Move a heading to the trash. Its tasks go to the root of the project.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runDelete,
+}
+
+func runDelete(cmd *cobra.Command, args []string) error {
+	thingsDB, err := db.Open(shared.GetDBPath(cmd))
+	if err != nil {
+		return err
+	}
+	defer thingsDB.Close()
+
+	uuid, err := resolveHeading(thingsDB, args[0])
+	if err != nil {
+		return err
+	}
+
+	heading, err := thingsDB.GetHeading(uuid)
+	if err != nil {
+		return err
+	}
+
+	if err := shared.GetClient(cmd).DeleteHeading(uuid); err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleted heading: %s\n", heading.Title)
+	return nil
+}
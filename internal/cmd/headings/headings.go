@@ -0,0 +1,42 @@
+package headings
+
+import (
+	"github.com/spf13/cobra"
+	"thingies/internal/db"
+)
+
+var headingsProject string
+
+// HeadingsCmd is the parent command for heading operations
+var HeadingsCmd = &cobra.Command{
+	Use:     "headings",
+	Aliases: []string{"heading"},
+	Short:   "Manage project headings",
+	Long: `List, rename, delete, and archive the headings of a project.
+
+Headings are named by UUID or prefix, or by title together with --project.
+Things cannot add a heading to an existing project; create the project
+together with its headings using thingies import.`,
+}
+
+func init() {
+	HeadingsCmd.PersistentFlags().StringVar(&headingsProject, "project", "", "Project name or UUID")
+
+	HeadingsCmd.AddCommand(listCmd)
+	HeadingsCmd.AddCommand(renameCmd)
+	HeadingsCmd.AddCommand(deleteCmd)
+	HeadingsCmd.AddCommand(archiveCmd)
+}
+
+// resolveHeading resolves a heading argument, by title within --project if
+// that is given
+func resolveHeading(thingsDB *db.ThingsDB, ref string) (string, error) {
+	var project string
+	if headingsProject != "" {
+		var err error
+		if project, err = thingsDB.ResolveProjectID(headingsProject); err != nil {
+			return "", err
+		}
+	}
+	return thingsDB.ResolveHeadingID(project, ref)
+}
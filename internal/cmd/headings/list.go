@@ -0,0 +1,40 @@
+package headings
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"thingies/internal/cmd/shared"
+	"thingies/internal/db"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the headings of a project",
+	Long:  `List the headings of the --project in order, including archived ones.`,
+	Args:  cobra.NoArgs,
+	RunE:  runList,
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	if headingsProject == "" {
+		return fmt.Errorf("--project is required")
+	}
+
+	thingsDB, err := db.Open(shared.GetDBPath(cmd))
+	if err != nil {
+		return err
+	}
+	defer thingsDB.Close()
+
+	project, err := thingsDB.ResolveProjectID(headingsProject)
+	if err != nil {
+		return err
+	}
+
+	headings, err := thingsDB.GetProjectHeadings(project)
+	if err != nil {
+		return err
+	}
+	return shared.GetFormatter(cmd).FormatHeadings(headings)
+}
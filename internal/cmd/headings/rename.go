@@ -0,0 +1,36 @@
+package headings
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"thingies/internal/cmd/shared"
+	"thingies/internal/db"
+)
+
+var renameCmd = &cobra.Command{
+	Use:   "rename <heading> <title>",
+	Short: "Rename a heading",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runRename,
+}
+
+func runRename(cmd *cobra.Command, args []string) error {
+	thingsDB, err := db.Open(shared.GetDBPath(cmd))
+	if err != nil {
+		return err
+	}
+	defer thingsDB.Close()
+
+	uuid, err := resolveHeading(thingsDB, args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := shared.GetClient(cmd).RenameHeading(uuid, args[1]); err != nil {
+		return fmt.Errorf("failed to rename heading: %w", err)
+	}
+
+	fmt.Printf("Renamed heading: %s\n", uuid)
+	return nil
+}
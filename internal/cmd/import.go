@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"thingies/internal/cmd/shared"
+	"thingies/internal/db"
+	"thingies/internal/things"
+)
+
+var importFile string
+
+var importCmd = &cobra.Command{
+	Use:   "import -f <file>",
+	Short: "Create or update items from a Things JSON file",
+	Long: `Send a file in the Things JSON format (the data of a things:///json URL) to
+Things: an array of to-dos and projects, with a project's headings and to-dos
+in its "items" and a to-do's checklist in its "checklist-items". A whole
+project structure arrives in one round-trip.
+
+  [{"type": "project", "attributes": {"title": "Launch", "area": "Work", "items": [
+     {"type": "heading", "attributes": {"title": "Prep"}},
+     {"type": "to-do", "attributes": {"title": "Book venue", "when": "today"}}]}}]
+
+The file is checked before anything is sent. Large files are split into
+several URLs. Things accepts 250 items per 10 seconds, so a file with more
+pauses for 10 seconds every 250 items. Items with "operation": "update" need
+the URL scheme auth token, which is read from the database. Use -f - to read
+standard input.`,
+	Args: cobra.NoArgs,
+	RunE: runImport,
+}
+
+func init() {
+	importCmd.Flags().StringVarP(&importFile, "file", "f", "", "Things JSON file to import (- for standard input)")
+	importCmd.MarkFlagRequired("file")
+
+	rootCmd.AddCommand(importCmd)
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	var data []byte
+	var err error
+	if importFile == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(importFile)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", importFile, err)
+	}
+
+	var items []things.JSONItem
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&items); err != nil {
+		return fmt.Errorf("%w: %s: %v", things.ErrInvalidJSON, importFile, err)
+	}
+	if err := things.ValidateJSON(items); err != nil {
+		return err
+	}
+
+	var token string
+	if things.JSONNeedsAuth(items) {
+		thingsDB, err := db.Open(shared.GetDBPath(cmd))
+		if err != nil {
+			return err
+		}
+		defer thingsDB.Close()
+
+		if token, err = thingsDB.GetAuthToken(); err != nil {
+			return fmt.Errorf("failed to get auth token: %w", err)
+		}
+	}
+
+	urls, err := shared.GetClient(cmd).RunJSON(cmd.Context(), items, token)
+	if err != nil {
+		return fmt.Errorf("failed to import: %w", err)
+	}
+
+	fmt.Printf("Imported %d item(s) in %d URL(s)\n", things.CountJSONItems(items), urls)
+	return nil
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/things"
+)
+
+// TestImport checks that import sends a valid file as one things:///json
+// URL, adds the auth token only for updates, and rejects a bad file unsent
+func TestImport(t *testing.T) {
+	b := dbtest.New(t).AuthToken("secret")
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	create := write("create.json", `[{"type": "project", "attributes": {"title": "Launch", "items": [{"type": "heading", "attributes": {"title": "Prep"}}]}}]`)
+	update := write("update.json", `[{"type": "to-do", "operation": "update", "id": "abc", "attributes": {"when": "today"}}]`)
+	invalid := write("invalid.json", `[{"type": "heading", "attributes": {"title": "Loose"}}]`)
+	defer func() { importFile = "" }()
+	defer rootCmd.SetArgs(nil)
+
+	rec := things.NewRecordingBackend()
+	for _, path := range []string{create, update} {
+		rootCmd.SetArgs([]string{"--db", b.Path(), "import", "-f", path})
+		if err := ExecuteWith(rec); err != nil {
+			t.Fatalf("import %s: %v", path, err)
+		}
+	}
+	urls := rec.URLs()
+	if len(urls) != 2 || strings.Contains(urls[0], "auth-token") || !strings.Contains(urls[1], "auth-token=secret") {
+		t.Errorf("expected a create without and an update with the auth token, got %v", urls)
+	}
+
+	rootCmd.SetArgs([]string{"--db", b.Path(), "import", "-f", invalid})
+	if err := ExecuteWith(rec); !errors.Is(err, things.ErrInvalidJSON) {
+		t.Errorf("expected ErrInvalidJSON, got %v", err)
+	}
+	if len(rec.URLs()) != 2 {
+		t.Errorf("expected nothing sent for an invalid file, got %v", rec.URLs()[2:])
+	}
+}
@@ -0,0 +1,37 @@
+package projects
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"thingies/internal/cmd/shared"
+	"thingies/internal/db"
+)
+
+var cancelCmd = &cobra.Command{
+	Use:   "cancel <uuid>",
+	Short: "Mark a project as canceled",
+	Long:  `Mark a project as canceled using AppleScript.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCancel,
+}
+
+func runCancel(cmd *cobra.Command, args []string) error {
+	thingsDB, err := db.Open(shared.GetDBPath(cmd))
+	if err != nil {
+		return err
+	}
+	defer thingsDB.Close()
+
+	uuid, err := thingsDB.ResolveProjectUUID(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := shared.GetClient(cmd).CancelProject(uuid); err != nil {
+		return fmt.Errorf("failed to cancel project: %w", err)
+	}
+
+	fmt.Printf("Canceled project: %s\n", uuid)
+	return nil
+}
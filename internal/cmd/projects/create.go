@@ -3,8 +3,11 @@ package projects
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"thingies/internal/cmd/shared"
+	"thingies/internal/db"
 	"thingies/internal/things"
 )
 
@@ -15,14 +18,19 @@ var (
 	createTags     string
 	createArea     string
 	createToDos    string
+	createWait     time.Duration
 )
 
 var createCmd = &cobra.Command{
 	Use:   "create <title>",
 	Short: "Create a new project",
-	Long:  `Create a new project using the Things URL scheme.`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runCreate,
+	Long: `Create a new project using the Things URL scheme.
+
+The Things URL scheme does not report the new project's UUID, so the command
+then waits (up to --wait) for the project to appear in the database and
+prints it. Use --wait 0 to return immediately without the UUID.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCreate,
 }
 
 func init() {
@@ -32,6 +40,7 @@ func init() {
 	createCmd.Flags().StringVar(&createTags, "tags", "", "Comma-separated tags")
 	createCmd.Flags().StringVar(&createArea, "area", "", "Area name")
 	createCmd.Flags().StringVar(&createToDos, "todos", "", "Newline-separated task titles")
+	createCmd.Flags().DurationVar(&createWait, "wait", 5*time.Second, "How long to wait for the new project to appear in the database (0 to skip)")
 }
 
 func runCreate(cmd *cobra.Command, args []string) error {
@@ -50,12 +59,49 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		ToDos:    todos,
 	}
 
-	url := things.BuildAddProjectURL(params)
+	client := shared.GetClient(cmd)
+	if createWait == 0 || shared.IsDryRun(cmd) {
+		if err := client.OpenURL(things.BuildAddProjectURL(params)); err != nil {
+			return fmt.Errorf("failed to create project: %w", err)
+		}
+		return shared.PrintSent(cmd, "project", args[0])
+	}
 
-	if err := things.OpenURL(url); err != nil {
+	thingsDB, err := db.Open(shared.GetDBPath(cmd))
+	if err != nil {
+		return err
+	}
+	defer thingsDB.Close()
+
+	pending, err := thingsDB.ExpectCreated(db.CreatedMatch{Project: true, Title: args[0], Notes: createNotes})
+	if err != nil {
+		return err
+	}
+	// The marker in the notes is how the new row is found
+	params.Notes = pending.MarkedNotes()
+	if err := client.OpenURL(things.BuildAddProjectURL(params)); err != nil {
 		return fmt.Errorf("failed to create project: %w", err)
 	}
 
-	fmt.Printf("Created project: %s\n", args[0])
+	uuid, err := pending.Wait(cmd.Context(), createWait)
+	if err != nil {
+		return err
+	}
+	if err := pending.Strip(uuid, client.SetNotes); err != nil {
+		return err
+	}
+	project, err := thingsDB.GetProject(uuid)
+	if err != nil {
+		return err
+	}
+
+	if shared.IsJSON(cmd) {
+		tasks, err := thingsDB.GetProjectTasks(uuid, false)
+		if err != nil {
+			return err
+		}
+		return shared.GetFormatter(cmd).FormatProject(project, nil, tasks)
+	}
+	fmt.Printf("Created project: %s (%s)\n", project.Title, project.UUID)
 	return nil
 }
@@ -9,7 +9,7 @@ var ProjectsCmd = &cobra.Command{
 	Use:     "projects",
 	Aliases: []string{"project", "p"},
 	Short:   "Manage projects",
-	Long:    `List, show, create, update, complete, and delete projects.`,
+	Long:    `List, show, create, update, complete, cancel, and delete projects.`,
 }
 
 func init() {
@@ -18,5 +18,6 @@ func init() {
 	ProjectsCmd.AddCommand(createCmd)
 	ProjectsCmd.AddCommand(updateCmd)
 	ProjectsCmd.AddCommand(completeCmd)
+	ProjectsCmd.AddCommand(cancelCmd)
 	ProjectsCmd.AddCommand(deleteCmd)
 }
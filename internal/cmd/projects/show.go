@@ -11,7 +11,7 @@ var showIncludeCompleted bool
 var showCmd = &cobra.Command{
 	Use:   "show <name-or-uuid>",
 	Short: "Show project details",
-	Long:  `Show detailed information about a specific project including its tasks.`,
+	Long:  `Show detailed information about a specific project including its tasks, grouped under their headings.`,
 	Args:  cobra.ExactArgs(1),
 	RunE:  runShow,
 }
@@ -38,11 +38,16 @@ func runShow(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	headings, err := thingsDB.GetProjectHeadings(uuid)
+	if err != nil {
+		return err
+	}
+
 	tasks, err := thingsDB.GetProjectTasks(uuid, showIncludeCompleted)
 	if err != nil {
 		return err
 	}
 
 	formatter := shared.GetFormatter(cmd)
-	return formatter.FormatProject(project, tasks)
+	return formatter.FormatProject(project, headings, tasks)
 }
@@ -51,7 +51,7 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		TagNames: updateTags,
 	}
 
-	if err := things.UpdateProject(params); err != nil {
+	if err := shared.GetClient(cmd).UpdateProject(params); err != nil {
 		return fmt.Errorf("failed to update project: %w", err)
 	}
 
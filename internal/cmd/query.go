@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"thingies/internal/cmd/shared"
+	"thingies/internal/db"
+)
+
+var queryIncludeFuture bool
+
+var queryCmd = &cobra.Command{
+	Use:   "query <expression>...",
+	Short: "Find tasks with the query language",
+	Long: `Find tasks matching a query expression, for example:
+
+  thingies query tag:urgent -tag:waiting area:Work due<+7d
+  thingies query '(project:Launch OR project:Ops)' status:any created>2026-01-01
+  thingies query 'scheduled:today repeating:no "call back"'
+
+Terms:
+  tag:NAME  area:NAME  project:NAME  heading:NAME   exact, case-insensitive; none for unset
+  subtag:NAME                                        the tag or any tag nested below it
+  title:TEXT  notes:TEXT  "free text"                substring match
+  status:open|completed|canceled|any                 default: open
+  list:inbox|today|evening|anytime|someday|upcoming
+  repeating:yes|no
+  due  scheduled  created  modified  completed       with : < <= > >=
+
+Dates are YYYY-MM-DD, today, tomorrow, yesterday, or offsets like +7d, -2w,
++1m, +1y; due:none and due:any test for a missing or set date.
+Terms are ANDed. Use OR between alternatives, parentheses to group, and a
+leading - to negate. A single argument is the whole expression; several
+arguments are joined with spaces, re-quoting any that contain spaces.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runQuery,
+}
+
+func init() {
+	queryCmd.Flags().BoolVar(&queryIncludeFuture, "include-future", false, "Include future instances of repeating tasks")
+
+	rootCmd.AddCommand(queryCmd)
+}
+
+func runQuery(cmd *cobra.Command, args []string) error {
+	q, err := db.ParseQuery(joinQueryArgs(args))
+	if err != nil {
+		return err
+	}
+
+	thingsDB, err := db.Open(shared.GetDBPath(cmd))
+	if err != nil {
+		return err
+	}
+	defer thingsDB.Close()
+
+	tasks, err := thingsDB.ListTasks(db.TaskFilter{Query: q, IncludeFuture: queryIncludeFuture})
+	if err != nil {
+		return err
+	}
+
+	formatter := shared.GetFormatter(cmd)
+	return formatter.FormatTasks(tasks)
+}
+
+// joinQueryArgs joins command-line arguments into one query. A single
+// argument is taken as the whole expression. With several, the shell has
+// already removed quotes, so an argument with spaces such as `area:My Work`
+// (typed as area:"My Work") is quoted again.
+func joinQueryArgs(args []string) string {
+	if len(args) == 1 {
+		return args[0]
+	}
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = arg
+		if !strings.ContainsAny(arg, " \t") || strings.Contains(arg, `"`) {
+			continue
+		}
+		if field, value, ok := cutQueryField(arg); ok {
+			parts[i] = field + `"` + value + `"`
+		} else {
+			parts[i] = `"` + arg + `"`
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// cutQueryField splits `field:value` (or <, <=, >, >=, =) after the operator
+func cutQueryField(arg string) (string, string, bool) {
+	i := strings.IndexAny(arg, ":=<>")
+	if i <= 0 || strings.ContainsAny(arg[:i], " \t-(\"") {
+		return "", "", false
+	}
+	j := i + 1
+	if j < len(arg) && arg[j] == '=' {
+		j++
+	}
+	return arg[:j], arg[j:], true
+}
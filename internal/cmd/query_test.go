@@ -0,0 +1,21 @@
+package cmd
+
+import "testing"
+
+func TestJoinQueryArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{`tag:urgent -tag:waiting "free text"`}, `tag:urgent -tag:waiting "free text"`},
+		{[]string{"tag:urgent", "due<+7d"}, "tag:urgent due<+7d"},
+		{[]string{"area:My Work", "tag:x"}, `area:"My Work" tag:x`},
+		{[]string{"due<=next week", "x"}, `due<="next week" x`},
+		{[]string{"call back", "-tag:x"}, `"call back" -tag:x`},
+	}
+	for _, tt := range tests {
+		if got := joinQueryArgs(tt.args); got != tt.want {
+			t.Errorf("joinQueryArgs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
@@ -1,33 +1,99 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
 	"thingies/internal/cmd/areas"
+	"thingies/internal/cmd/headings"
 	"thingies/internal/cmd/projects"
+	"thingies/internal/cmd/shared"
 	"thingies/internal/cmd/tags"
 	"thingies/internal/cmd/tasks"
+	"thingies/internal/sandbox"
+	"thingies/internal/things"
 )
 
 var (
-	dbPath  string
-	jsonOut bool
-	noColor bool
-	verbose bool
+	dbPath   string
+	jsonOut  bool
+	noColor  bool
+	verbose  bool
+	simulate bool
+	dryRun   bool
 )
 
+// sandboxBackend is the simulated backend opened for --simulate, if any
+var sandboxBackend *sandbox.Backend
+
 // rootCmd represents the base command
 var rootCmd = &cobra.Command{
 	Use:   "thingies",
 	Short: "CLI for Things 3 task management",
 	Long:  `Thingies provides command-line access to Things 3 for listing, creating, updating, and deleting tasks, projects, and more.`,
+
+	PersistentPreRunE: setupBackend,
 }
 
-// Execute runs the root command
+// Execute runs the root command against the Things app, exiting with
+// ExitCode(err) on failure
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+	if err := ExecuteWith(things.SystemBackend{}); err != nil {
+		os.Exit(ExitCode(err))
+	}
+}
+
+// ExecuteWith runs the root command, routing all writes through backend
+func ExecuteWith(backend things.Backend) error {
+	defer closeSandbox()
+	ctx := shared.WithBackend(context.Background(), backend)
+	// Cobra only hands the context to a subcommand whose own context is
+	// unset, so a second run in the same process would keep the first backend
+	setContext(rootCmd, ctx)
+	return rootCmd.ExecuteContext(ctx)
+}
+
+// setContext sets ctx on c and all of its subcommands
+func setContext(c *cobra.Command, ctx context.Context) {
+	c.SetContext(ctx)
+	for _, sub := range c.Commands() {
+		setContext(sub, ctx)
+	}
+}
+
+// setupBackend swaps in the dry-run backend for --dry-run or the sandbox
+// backend for --simulate
+func setupBackend(cmd *cobra.Command, args []string) error {
+	if dryRun && simulate {
+		return fmt.Errorf("--dry-run and --simulate cannot be used together")
+	}
+	if dryRun {
+		cmd.SetContext(shared.WithBackend(cmd.Context(), things.NewDryRunBackend(cmd.OutOrStdout())))
+		return nil
+	}
+	if !simulate {
+		return nil
+	}
+	if dbPath == "" {
+		return fmt.Errorf("--simulate requires --db pointing at a sandbox copy of the Things database")
+	}
+
+	backend, err := sandbox.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	sandboxBackend = backend
+	cmd.SetContext(shared.WithBackend(cmd.Context(), backend))
+	return nil
+}
+
+// closeSandbox closes the sandbox backend opened by setupBackend
+func closeSandbox() {
+	if sandboxBackend != nil {
+		sandboxBackend.Close()
+		sandboxBackend = nil
 	}
 }
 
@@ -36,12 +102,15 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&jsonOut, "json", "j", false, "Output as JSON")
 	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
+	rootCmd.PersistentFlags().BoolVar(&simulate, "simulate", false, "Apply writes to the --db file instead of the Things app (sandbox databases only)")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print the AppleScript or things:/// URL each write would run instead of running it")
 
 	// Add subcommands
 	rootCmd.AddCommand(tasks.TasksCmd)
 	rootCmd.AddCommand(projects.ProjectsCmd)
 	rootCmd.AddCommand(areas.AreasCmd)
 	rootCmd.AddCommand(tags.TagsCmd)
+	rootCmd.AddCommand(headings.HeadingsCmd)
 	rootCmd.AddCommand(todayCmd)
 	rootCmd.AddCommand(inboxCmd)
 	rootCmd.AddCommand(upcomingCmd)
@@ -50,6 +119,7 @@ func init() {
 	rootCmd.AddCommand(snapshotCmd)
 	rootCmd.AddCommand(logbookCmd)
 	rootCmd.AddCommand(anytimeCmd)
+	rootCmd.AddCommand(trashCmd)
 }
 
 // GetDBPath returns the database path flag value
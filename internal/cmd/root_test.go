@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/things"
+)
+
+// TestExecuteWithRoutesWritesThroughBackend runs `tasks create` end-to-end and
+// checks that the add URL reaches the injected backend.
+func TestExecuteWithRoutesWritesThroughBackend(t *testing.T) {
+	rec := things.NewRecordingBackend()
+	rootCmd.SetArgs([]string{"tasks", "create", "Call mom", "--when", "tomorrow", "--list", "Family", "--wait", "0"})
+	defer rootCmd.SetArgs(nil)
+
+	if err := ExecuteWith(rec); err != nil {
+		t.Fatalf("ExecuteWith: %v", err)
+	}
+
+	urls := rec.URLs()
+	if len(urls) != 1 {
+		t.Fatalf("expected 1 URL, got %v", urls)
+	}
+	for _, part := range []string{"things:///add?", "title=Call%20mom", "when=tomorrow", "list=Family"} {
+		if !strings.Contains(urls[0], part) {
+			t.Errorf("expected URL to contain %q, got %s", part, urls[0])
+		}
+	}
+	if len(rec.Scripts()) != 0 {
+		t.Errorf("expected no AppleScript, got %v", rec.Scripts())
+	}
+}
+
+// TestDryRunPrintsInsteadOfRunning checks that --dry-run prints the script
+// and URL a command would run and never reaches the real backend
+func TestDryRunPrintsInsteadOfRunning(t *testing.T) {
+	b := dbtest.New(t)
+	b.Task("Water plants").WithUUID("Task000000000000000000")
+	path := b.Path()
+
+	rec := things.NewRecordingBackend()
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	defer rootCmd.SetOut(nil)
+	defer func() { dryRun = false }()
+
+	for _, args := range [][]string{
+		{"tasks", "complete", "Task"},
+		{"tasks", "create", "Call mom", "--when", "tomorrow"},
+	} {
+		rootCmd.SetArgs(append([]string{"--db", path, "--dry-run"}, args...))
+		if err := ExecuteWith(rec); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+	rootCmd.SetArgs(nil)
+
+	if calls := rec.Calls(); len(calls) != 0 {
+		t.Errorf("dry run reached the backend: %v", calls)
+	}
+	for _, want := range []string{
+		"[dry-run] osascript:\ntell application \"Things3\"",
+		`set status of to do id "Task000000000000000000" to completed`,
+		"[dry-run] open things:///add?",
+		"title=Call%20mom",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestDryRunAndSimulateConflict(t *testing.T) {
+	rootCmd.SetArgs([]string{"--db", "x.sqlite", "--dry-run", "--simulate", "today"})
+	defer rootCmd.SetArgs(nil)
+	defer func() { dryRun, simulate = false, false }()
+	rootCmd.SetErr(io.Discard)
+	rootCmd.SetOut(io.Discard)
+	defer rootCmd.SetErr(nil)
+	defer rootCmd.SetOut(nil)
+
+	err := ExecuteWith(things.NewRecordingBackend())
+	if err == nil || !strings.Contains(err.Error(), "cannot be used together") {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+}
+
+// TestCreatePrintsNewUUID creates a task against a sandbox database and
+// checks that the command waits for it and prints its UUID
+func TestCreatePrintsNewUUID(t *testing.T) {
+	b := dbtest.New(t)
+	path := b.Path()
+	defer func() { simulate = false }()
+
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	rootCmd.SetArgs([]string{"--db", path, "--simulate", "tasks", "create", "Sandboxed", "--list", "", "--wait", "2s"})
+	err = ExecuteWith(things.NewRecordingBackend())
+	rootCmd.SetArgs(nil)
+	w.Close()
+	os.Stdout = stdout
+	if err != nil {
+		t.Fatalf("ExecuteWith: %v", err)
+	}
+	out, _ := io.ReadAll(r)
+
+	var uuid string
+	if err := b.Open().Conn().QueryRow(`SELECT uuid FROM TMTask WHERE title = 'Sandboxed'`).Scan(&uuid); err != nil {
+		t.Fatalf("task not created: %v", err)
+	}
+	if want := "Created task: Sandboxed (" + uuid + ")\n"; string(out) != want {
+		t.Errorf("expected %q, got %q", want, out)
+	}
+}
+
+// TestCreateWithoutWaitJSON checks that --wait 0 --json prints JSON, without
+// the UUID it never learned
+func TestCreateWithoutWaitJSON(t *testing.T) {
+	defer func() { jsonOut = false }()
+
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	rootCmd.SetArgs([]string{"--json", "tasks", "create", "Call mom", "--wait", "0"})
+	err = ExecuteWith(things.NewRecordingBackend())
+	rootCmd.SetArgs(nil)
+	w.Close()
+	os.Stdout = stdout
+	if err != nil {
+		t.Fatalf("ExecuteWith: %v", err)
+	}
+	out, _ := io.ReadAll(r)
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("expected JSON, got %q", out)
+	}
+	if got["status"] != "sent" || got["title"] != "Call mom" || got["uuid"] != nil {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
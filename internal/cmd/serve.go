@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"errors"
+	"log"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,11 +14,17 @@ import (
 	"thingies/internal/cmd/shared"
 	"thingies/internal/db"
 	"thingies/internal/server"
+	"thingies/internal/webhook"
 )
 
 var (
-	servePort int
-	serveHost string
+	servePort       int
+	serveHost       string
+	serveAuthConfig string
+	serveWebhooks   string
+	serveReadOnly   bool
+	serveCreateWait time.Duration
+	serveWatch      time.Duration
 )
 
 var serveCmd = &cobra.Command{
@@ -30,6 +37,12 @@ var serveCmd = &cobra.Command{
 func init() {
 	serveCmd.Flags().IntVarP(&servePort, "port", "p", 8484, "Port to listen on")
 	serveCmd.Flags().StringVar(&serveHost, "host", "0.0.0.0", "Host to bind to")
+	serveCmd.Flags().StringVar(&serveAuthConfig, "auth-config", "", "JSON file of API keys; requests must send 'Authorization: Bearer <key>'")
+	serveCmd.Flags().StringVar(&serveWebhooks, "webhooks", "", "JSON file of webhooks to POST signed change events to")
+
+	serveCmd.Flags().DurationVar(&serveCreateWait, "create-timeout", 5*time.Second, "How long POST /tasks, /projects, /areas, and /tags wait for the new item to appear in the database (0 to skip)")
+	serveCmd.Flags().DurationVar(&serveWatch, "watch-interval", time.Second, "How often GET /events checks the database files for changes")
+	serveCmd.Flags().BoolVar(&serveReadOnly, "read-only", false, "Reject every POST, PATCH, and DELETE request with 403")
 
 	rootCmd.AddCommand(serveCmd)
 }
@@ -44,10 +57,38 @@ func runServe(cmd *cobra.Command, args []string) error {
 
 	// Create server
 	cfg := server.Config{
-		Host: serveHost,
-		Port: servePort,
+		Host:     serveHost,
+		Port:     servePort,
+		ReadOnly: serveReadOnly,
+
+		CreateTimeout: serveCreateWait,
+		WatchInterval: serveWatch,
+	}
+	if shared.IsDryRun(cmd) {
+		// Nothing reaches Things, so a create would only ever time out
+		cfg.CreateTimeout = 0
+	}
+	if serveReadOnly {
+		log.Printf("Read-only mode: write requests will be rejected")
+	}
+	if serveAuthConfig != "" {
+		auth, err := server.LoadAuthConfig(serveAuthConfig)
+		if err != nil {
+			return err
+		}
+		cfg.Auth = auth
+		log.Printf("API key authentication enabled (%d keys)", len(auth.Keys))
+	} else {
+		log.Printf("WARNING: no --auth-config given; anyone who can reach %s:%d can read and change your tasks", serveHost, servePort)
+	}
+	if serveWebhooks != "" {
+		hooks, err := webhook.LoadConfig(serveWebhooks)
+		if err != nil {
+			return err
+		}
+		cfg.Webhooks = hooks
 	}
-	srv := server.New(cfg, thingsDB)
+	srv := server.New(cfg, thingsDB, shared.GetBackend(cmd))
 
 	// Set up signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -1,10 +1,46 @@
 package shared
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+
 	"github.com/spf13/cobra"
 	"thingies/internal/output"
+	"thingies/internal/things"
 )
 
+// backendKey is the context key under which the write backend is stored
+type backendKey struct{}
+
+// WithBackend returns a context that carries the backend used for writes
+func WithBackend(ctx context.Context, backend things.Backend) context.Context {
+	return context.WithValue(ctx, backendKey{}, backend)
+}
+
+// GetBackend returns the write backend from the command context,
+// falling back to the real Things app when none was injected
+func GetBackend(cmd *cobra.Command) things.Backend {
+	if ctx := cmd.Context(); ctx != nil {
+		if backend, ok := ctx.Value(backendKey{}).(things.Backend); ok && backend != nil {
+			return backend
+		}
+	}
+	return things.SystemBackend{}
+}
+
+// IsDryRun reports whether writes are only printed (--dry-run), in which case
+// nothing will ever show up in the database
+func IsDryRun(cmd *cobra.Command) bool {
+	_, ok := GetBackend(cmd).(*things.DryRunBackend)
+	return ok
+}
+
+// GetClient returns a Things client that writes through the command's backend
+func GetClient(cmd *cobra.Command) *things.Client {
+	return things.NewClient(GetBackend(cmd))
+}
+
 // GetDBPath returns the database path from flags
 func GetDBPath(cmd *cobra.Command) string {
 	dbPath, _ := cmd.Flags().GetString("db")
@@ -59,3 +95,19 @@ func GetFormatter(cmd *cobra.Command) output.Formatter {
 	}
 	return output.NewTableFormatter(IsNoColor(cmd))
 }
+
+// PrintSent reports a create that was handed to Things without waiting for
+// the new row (--wait 0 or --dry-run), so its UUID is unknown. With --json
+// it prints {"status": "sent", "type": ..., "title": ...} and no uuid.
+func PrintSent(cmd *cobra.Command, kind, title string) error {
+	if !IsJSON(cmd) {
+		fmt.Printf("Created %s: %s\n", kind, title)
+		return nil
+	}
+	data, err := json.MarshalIndent(map[string]string{"status": "sent", "type": kind, "title": title}, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -6,7 +6,6 @@ import (
 	"github.com/spf13/cobra"
 	"thingies/internal/cmd/shared"
 	"thingies/internal/db"
-	"thingies/internal/things"
 )
 
 var parentTag string
@@ -40,7 +39,7 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		parentTag = resolved
 	}
 
-	uuid, err := things.CreateTag(name, parentTag)
+	uuid, err := shared.GetClient(cmd).CreateTag(name, parentTag)
 	if err != nil {
 		return err
 	}
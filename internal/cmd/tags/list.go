@@ -6,11 +6,19 @@ import (
 	"thingies/internal/db"
 )
 
+var listTree bool
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List tags",
-	Long:  `List all tags with usage counts.`,
-	RunE:  runList,
+	Long: `List all tags with usage counts.
+
+With --tree, nested tags are shown under their parents.`,
+	RunE: runList,
+}
+
+func init() {
+	listCmd.Flags().BoolVar(&listTree, "tree", false, "Show nested tags under their parents")
 }
 
 func runList(cmd *cobra.Command, args []string) error {
@@ -20,11 +28,18 @@ func runList(cmd *cobra.Command, args []string) error {
 	}
 	defer thingsDB.Close()
 
+	formatter := shared.GetFormatter(cmd)
+	if listTree {
+		roots, err := thingsDB.ListTagTree()
+		if err != nil {
+			return err
+		}
+		return formatter.FormatTagTree(roots)
+	}
+
 	tags, err := thingsDB.ListTags()
 	if err != nil {
 		return err
 	}
-
-	formatter := shared.GetFormatter(cmd)
 	return formatter.FormatTags(tags)
 }
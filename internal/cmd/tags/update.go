@@ -6,7 +6,6 @@ import (
 	"github.com/spf13/cobra"
 	"thingies/internal/cmd/shared"
 	"thingies/internal/db"
-	"thingies/internal/things"
 )
 
 var updateName string
@@ -41,7 +40,7 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if err := things.UpdateTag(fullUUID, updateName); err != nil {
+	if err := shared.GetClient(cmd).UpdateTag(fullUUID, updateName); err != nil {
 		return err
 	}
 
@@ -6,7 +6,6 @@ import (
 	"github.com/spf13/cobra"
 	"thingies/internal/cmd/shared"
 	"thingies/internal/db"
-	"thingies/internal/things"
 )
 
 var cancelCmd = &cobra.Command{
@@ -29,7 +28,7 @@ func runCancel(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if err := things.CancelTask(uuid); err != nil {
+	if err := shared.GetClient(cmd).CancelTask(uuid); err != nil {
 		return fmt.Errorf("failed to cancel task: %w", err)
 	}
 
@@ -0,0 +1,162 @@
+package tasks
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"thingies/internal/cmd/shared"
+	"thingies/internal/db"
+	"thingies/internal/models"
+)
+
+var checklistAddTop bool
+
+var checklistCmd = &cobra.Command{
+	Use:   "checklist <task>",
+	Short: "Show or edit a task's checklist",
+	Long: `Show a task's checklist, numbered, or edit it with the subcommands.
+
+Items are named by their position in this listing (1, 2, ...) or by title.
+AppleScript cannot reach checklists, so edits use the Things URL scheme and
+need its auth token. check, uncheck, remove, and reorder rewrite the whole
+checklist, which gives every item a new UUID.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runChecklist,
+}
+
+var checklistAddCmd = &cobra.Command{
+	Use:   "add <task> <item>...",
+	Short: "Add checklist items",
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runChecklistAdd,
+}
+
+var checklistCheckCmd = &cobra.Command{
+	Use:   "check <task> <item>...",
+	Short: "Mark checklist items completed",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editChecklist(cmd, args, "Checked", func(items []models.ChecklistItem, refs []string) ([]models.ChecklistItem, error) {
+			return db.CheckChecklistItems(items, refs, true)
+		})
+	},
+}
+
+var checklistUncheckCmd = &cobra.Command{
+	Use:   "uncheck <task> <item>...",
+	Short: "Reopen checklist items",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editChecklist(cmd, args, "Unchecked", func(items []models.ChecklistItem, refs []string) ([]models.ChecklistItem, error) {
+			return db.CheckChecklistItems(items, refs, false)
+		})
+	},
+}
+
+var checklistRemoveCmd = &cobra.Command{
+	Use:   "remove <task> <item>...",
+	Short: "Remove checklist items",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editChecklist(cmd, args, "Removed", db.RemoveChecklistItems)
+	},
+}
+
+var checklistReorderCmd = &cobra.Command{
+	Use:   "reorder <task> <item>...",
+	Short: "Move checklist items to the top, in the order given",
+	Long: `Move the named checklist items to the top of the checklist, in the order
+given; the rest keep their order below them. "reorder <task> 3" moves the
+third item first; listing every position sets the full order.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editChecklist(cmd, args, "Reordered", db.ReorderChecklist)
+	},
+}
+
+func init() {
+	checklistAddCmd.Flags().BoolVar(&checklistAddTop, "top", false, "Add the items at the top instead of the end")
+
+	checklistCmd.AddCommand(checklistAddCmd)
+	checklistCmd.AddCommand(checklistCheckCmd)
+	checklistCmd.AddCommand(checklistUncheckCmd)
+	checklistCmd.AddCommand(checklistRemoveCmd)
+	checklistCmd.AddCommand(checklistReorderCmd)
+}
+
+func runChecklist(cmd *cobra.Command, args []string) error {
+	thingsDB, err := db.Open(shared.GetDBPath(cmd))
+	if err != nil {
+		return err
+	}
+	defer thingsDB.Close()
+
+	uuid, err := thingsDB.ResolveTaskUUID(args[0])
+	if err != nil {
+		return err
+	}
+	items, err := thingsDB.GetTaskChecklistItems(uuid)
+	if err != nil {
+		return err
+	}
+
+	return shared.GetFormatter(cmd).FormatChecklist(items)
+}
+
+func runChecklistAdd(cmd *cobra.Command, args []string) error {
+	thingsDB, err := db.Open(shared.GetDBPath(cmd))
+	if err != nil {
+		return err
+	}
+	defer thingsDB.Close()
+
+	uuid, err := thingsDB.ResolveTaskUUID(args[0])
+	if err != nil {
+		return err
+	}
+	token, err := thingsDB.GetAuthToken()
+	if err != nil {
+		return fmt.Errorf("failed to get auth token: %w", err)
+	}
+
+	if err := shared.GetClient(cmd).AddChecklistItems(uuid, args[1:], checklistAddTop, token); err != nil {
+		return fmt.Errorf("failed to add checklist items: %w", err)
+	}
+
+	fmt.Printf("Added %d checklist item(s) to task: %s\n", len(args)-1, uuid)
+	return nil
+}
+
+// editChecklist loads the checklist of the task in args[0], applies edit
+// with the item references in args[1:], and writes the result back
+func editChecklist(cmd *cobra.Command, args []string, verb string, edit func([]models.ChecklistItem, []string) ([]models.ChecklistItem, error)) error {
+	thingsDB, err := db.Open(shared.GetDBPath(cmd))
+	if err != nil {
+		return err
+	}
+	defer thingsDB.Close()
+
+	uuid, err := thingsDB.ResolveTaskUUID(args[0])
+	if err != nil {
+		return err
+	}
+	items, err := thingsDB.GetTaskChecklistItems(uuid)
+	if err != nil {
+		return err
+	}
+	edited, err := edit(items, args[1:])
+	if err != nil {
+		return err
+	}
+	token, err := thingsDB.GetAuthToken()
+	if err != nil {
+		return fmt.Errorf("failed to get auth token: %w", err)
+	}
+
+	if err := shared.GetClient(cmd).SetChecklist(uuid, edited, token); err != nil {
+		return fmt.Errorf("failed to update checklist: %w", err)
+	}
+
+	fmt.Printf("%s %d checklist item(s) on task: %s\n", verb, len(args)-1, uuid)
+	return nil
+}
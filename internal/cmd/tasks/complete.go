@@ -6,7 +6,6 @@ import (
 	"github.com/spf13/cobra"
 	"thingies/internal/cmd/shared"
 	"thingies/internal/db"
-	"thingies/internal/things"
 )
 
 var completeCmd = &cobra.Command{
@@ -29,7 +28,7 @@ func runComplete(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if err := things.CompleteTask(uuid); err != nil {
+	if err := shared.GetClient(cmd).CompleteTask(uuid); err != nil {
 		return fmt.Errorf("failed to complete task: %w", err)
 	}
 
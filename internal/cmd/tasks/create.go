@@ -2,8 +2,11 @@ package tasks
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
+	"thingies/internal/cmd/shared"
+	"thingies/internal/db"
 	"thingies/internal/things"
 )
 
@@ -16,14 +19,19 @@ var (
 	createHeading   string
 	createCompleted bool
 	createCanceled  bool
+	createWait      time.Duration
 )
 
 var createCmd = &cobra.Command{
 	Use:   "create <title>",
 	Short: "Create a new task",
-	Long:  `Create a new task using the Things URL scheme.`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runCreate,
+	Long: `Create a new task using the Things URL scheme.
+
+The Things URL scheme does not report the new task's UUID, so the command
+then waits (up to --wait) for the task to appear in the database and prints
+it. Use --wait 0 to return immediately without the UUID.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCreate,
 }
 
 func init() {
@@ -35,6 +43,7 @@ func init() {
 	createCmd.Flags().StringVar(&createHeading, "heading", "", "Heading within project")
 	createCmd.Flags().BoolVar(&createCompleted, "completed", false, "Mark as completed")
 	createCmd.Flags().BoolVar(&createCanceled, "canceled", false, "Mark as canceled")
+	createCmd.Flags().DurationVar(&createWait, "wait", 5*time.Second, "How long to wait for the new task to appear in the database (0 to skip)")
 }
 
 func runCreate(cmd *cobra.Command, args []string) error {
@@ -50,12 +59,45 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		Canceled:  createCanceled,
 	}
 
-	url := things.BuildAddURL(params)
+	client := shared.GetClient(cmd)
+	if createWait == 0 || shared.IsDryRun(cmd) {
+		if err := client.OpenURL(things.BuildAddURL(params)); err != nil {
+			return fmt.Errorf("failed to create task: %w", err)
+		}
+		return shared.PrintSent(cmd, "task", args[0])
+	}
 
-	if err := things.OpenURL(url); err != nil {
+	thingsDB, err := db.Open(shared.GetDBPath(cmd))
+	if err != nil {
+		return err
+	}
+	defer thingsDB.Close()
+
+	pending, err := thingsDB.ExpectCreated(db.CreatedMatch{Title: args[0], Notes: createNotes})
+	if err != nil {
+		return err
+	}
+	// The marker in the notes is how the new row is found
+	params.Notes = pending.MarkedNotes()
+	if err := client.OpenURL(things.BuildAddURL(params)); err != nil {
 		return fmt.Errorf("failed to create task: %w", err)
 	}
 
-	fmt.Printf("Created task: %s\n", args[0])
+	uuid, err := pending.Wait(cmd.Context(), createWait)
+	if err != nil {
+		return err
+	}
+	if err := pending.Strip(uuid, client.SetNotes); err != nil {
+		return err
+	}
+	task, err := thingsDB.GetTask(uuid)
+	if err != nil {
+		return err
+	}
+
+	if shared.IsJSON(cmd) {
+		return shared.GetFormatter(cmd).FormatTask(task)
+	}
+	fmt.Printf("Created task: %s (%s)\n", task.Title, task.UUID)
 	return nil
 }
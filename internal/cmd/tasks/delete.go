@@ -6,7 +6,6 @@ import (
 	"github.com/spf13/cobra"
 	"thingies/internal/cmd/shared"
 	"thingies/internal/db"
-	"thingies/internal/things"
 )
 
 var deleteCmd = &cobra.Command{
@@ -29,7 +28,7 @@ func runDelete(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if err := things.DeleteTask(uuid); err != nil {
+	if err := shared.GetClient(cmd).DeleteTask(uuid); err != nil {
 		return fmt.Errorf("failed to delete task: %w", err)
 	}
 
@@ -11,7 +11,10 @@ var (
 	listStatus        string
 	listArea          string
 	listProject       string
-	listTag           string
+	listTags          []string
+	listAnyTags       []string
+	listNotTags       []string
+	listSubtags       bool
 	listToday         bool
 	listIncludeFuture bool
 )
@@ -19,15 +22,23 @@ var (
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List tasks",
-	Long:  `List tasks with optional filters for status, area, project, tag, and today view.`,
-	RunE:  runList,
+	Long: `List tasks with optional filters for status, area, project, tags, and today view.
+
+Tags match exactly (case-insensitive) and include tags inherited from the
+task's project or area. --tag may be repeated and all must match; --any-tag
+needs at least one; --not-tag excludes. With --subtags, a tag also matches
+every tag nested below it.`,
+	RunE: runList,
 }
 
 func init() {
 	listCmd.Flags().StringVar(&listStatus, "status", "incomplete", "Filter by status (all, incomplete, completed, canceled)")
 	listCmd.Flags().StringVar(&listArea, "area", "", "Filter by area name")
 	listCmd.Flags().StringVar(&listProject, "project", "", "Filter by project name")
-	listCmd.Flags().StringVar(&listTag, "tag", "", "Filter by tag name")
+	listCmd.Flags().StringArrayVar(&listTags, "tag", nil, "Only tasks with this tag (repeatable; all must match)")
+	listCmd.Flags().StringArrayVar(&listAnyTags, "any-tag", nil, "Only tasks with at least one of these tags (repeatable)")
+	listCmd.Flags().StringArrayVar(&listNotTags, "not-tag", nil, "Exclude tasks with this tag (repeatable)")
+	listCmd.Flags().BoolVar(&listSubtags, "subtags", false, "Let --tag, --any-tag, and --not-tag also match nested tags")
 	listCmd.Flags().BoolVar(&listToday, "today", false, "Show only Today items")
 	listCmd.Flags().BoolVar(&listIncludeFuture, "include-future", false, "Include future instances of repeating tasks")
 }
@@ -43,7 +54,10 @@ func runList(cmd *cobra.Command, args []string) error {
 		Status:        listStatus,
 		Area:          listArea,
 		Project:       listProject,
-		Tag:           listTag,
+		Tags:          listTags,
+		AnyTags:       listAnyTags,
+		NotTags:       listNotTags,
+		Subtags:       listSubtags,
 		Today:         listToday,
 		IncludeFuture: listIncludeFuture,
 	}
@@ -0,0 +1,76 @@
+package tasks
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"thingies/internal/cmd/shared"
+	"thingies/internal/db"
+)
+
+var (
+	moveProject string
+	moveArea    string
+	moveHeading string
+	moveInbox   bool
+)
+
+var moveCmd = &cobra.Command{
+	Use:   "move <uuid>",
+	Short: "Move a task to a project, heading, area, or the Inbox",
+	Long: `Move a task to another project, heading, area, or back to the Inbox.
+
+Projects and areas accept a name, UUID, or UUID prefix; headings a UUID or
+prefix. --heading alone moves the task to that heading's project; with
+--project, the heading must belong to that project. Moving under a heading
+uses the Things URL scheme, everything else uses AppleScript.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMove,
+}
+
+func init() {
+	moveCmd.Flags().StringVar(&moveProject, "project", "", "Target project (name, UUID, or prefix)")
+	moveCmd.Flags().StringVar(&moveArea, "area", "", "Target area (name, UUID, or prefix)")
+	moveCmd.Flags().StringVar(&moveHeading, "heading", "", "Target heading (UUID or prefix)")
+	moveCmd.Flags().BoolVar(&moveInbox, "inbox", false, "Move the task back to the Inbox")
+}
+
+func runMove(cmd *cobra.Command, args []string) error {
+	thingsDB, err := db.Open(shared.GetDBPath(cmd))
+	if err != nil {
+		return err
+	}
+	defer thingsDB.Close()
+
+	uuid, err := thingsDB.ResolveTaskUUID(args[0])
+	if err != nil {
+		return err
+	}
+
+	target, err := thingsDB.ResolveMoveTarget(moveInbox, moveProject, moveArea, moveHeading)
+	if err != nil {
+		return err
+	}
+
+	client := shared.GetClient(cmd)
+	switch {
+	case target.Inbox:
+		err = client.MoveTaskToInbox(uuid)
+	case target.Heading != "":
+		token, tokenErr := thingsDB.GetAuthToken()
+		if tokenErr != nil {
+			return fmt.Errorf("failed to get auth token: %w", tokenErr)
+		}
+		err = client.MoveTaskToHeading(uuid, target.Project, target.Heading, token)
+	case target.Project != "":
+		err = client.MoveTaskToProject(uuid, target.Project)
+	default:
+		err = client.MoveTaskToArea(uuid, target.Area)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to move task: %w", err)
+	}
+
+	fmt.Printf("Moved task: %s\n", uuid)
+	return nil
+}
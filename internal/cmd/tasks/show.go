@@ -1,6 +1,8 @@
 package tasks
 
 import (
+	"errors"
+
 	"github.com/spf13/cobra"
 	"thingies/internal/cmd/shared"
 	"thingies/internal/db"
@@ -32,6 +34,17 @@ func runShow(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// A rule we can't decode hides the schedule, not the task
+	task.Recurrence, err = thingsDB.GetTaskRecurrence(fullUUID)
+	if err != nil && !errors.Is(err, db.ErrInvalidRecurrence) {
+		return err
+	}
+
+	task.ChecklistItems, err = thingsDB.GetTaskChecklistItems(fullUUID)
+	if err != nil {
+		return err
+	}
+
 	formatter := shared.GetFormatter(cmd)
 	return formatter.FormatTask(task)
 }
@@ -9,7 +9,7 @@ var TasksCmd = &cobra.Command{
 	Use:     "tasks",
 	Aliases: []string{"task", "t"},
 	Short:   "Manage tasks",
-	Long:    `List, show, create, update, complete, and delete tasks.`,
+	Long:    `List, show, create, update, move, complete, and delete tasks; edit checklists.`,
 }
 
 func init() {
@@ -20,4 +20,6 @@ func init() {
 	TasksCmd.AddCommand(completeCmd)
 	TasksCmd.AddCommand(cancelCmd)
 	TasksCmd.AddCommand(deleteCmd)
+	TasksCmd.AddCommand(moveCmd)
+	TasksCmd.AddCommand(checklistCmd)
 }
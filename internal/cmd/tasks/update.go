@@ -54,8 +54,8 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		TagNames: updateTags,
 	}
 
-	// Specific dates need an auth token for the URL scheme
-	if things.IsSpecificDate(updateWhen) {
+	// Evening and specific dates need an auth token for the URL scheme
+	if things.NeedsAuthToken(updateWhen) {
 		token, err := thingsDB.GetAuthToken()
 		if err != nil {
 			return fmt.Errorf("failed to get auth token: %w", err)
@@ -63,7 +63,7 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		params.AuthToken = token
 	}
 
-	if err := things.UpdateTask(params); err != nil {
+	if err := shared.GetClient(cmd).UpdateTask(params); err != nil {
 		return fmt.Errorf("failed to update task: %w", err)
 	}
 
@@ -9,7 +9,7 @@ import (
 var todayCmd = &cobra.Command{
 	Use:   "today",
 	Short: "Show today's tasks",
-	Long:  `Show tasks scheduled for today.`,
+	Long:  `Show tasks scheduled for today, with This Evening listed separately.`,
 	RunE:  runToday,
 }
 
@@ -31,5 +31,5 @@ func runToday(cmd *cobra.Command, args []string) error {
 	}
 
 	formatter := shared.GetFormatter(cmd)
-	return formatter.FormatTasks(tasks)
+	return formatter.FormatToday(tasks)
 }
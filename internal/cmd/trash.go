@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"thingies/internal/cmd/shared"
+	"thingies/internal/db"
+	"thingies/internal/models"
+	"thingies/internal/output"
+	"thingies/internal/things"
+)
+
+var trashEmptyOlderThan string
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "Show trashed items",
+	Long: `Show trashed tasks, projects, and headings, most recently trashed first,
+with the area, project, heading, or list each one was trashed from.
+
+The trash date is the item's last modification, which trashing sets.`,
+	RunE: runTrash,
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <uuid>",
+	Short: "Restore a trashed item",
+	Long: `Take a task or project out of the trash using AppleScript.
+
+The item is moved to the list it was in (Upcoming items go to Anytime, since
+AppleScript cannot set a start date), then its project or area is set back.
+AppleScript has no property for a to-do's heading, so a task trashed from
+under a heading returns to the root of its project. Restore a trashed project
+before its tasks.
+
+Headings cannot be restored this way; put them back in Things itself.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTrashRestore,
+}
+
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Permanently delete everything in the trash",
+	Long: `Empty the trash using AppleScript. This cannot be undone.
+
+Things can only empty the whole trash. With --older-than (e.g. 30d, 2w, or
+YYYY-MM-DD), nothing is deleted unless every trashed item is older than that.`,
+	Args: cobra.NoArgs,
+	RunE: runTrashEmpty,
+}
+
+func init() {
+	trashEmptyCmd.Flags().StringVar(&trashEmptyOlderThan, "older-than", "", "Only empty if every item was trashed before this age (e.g. 30d, 2w, YYYY-MM-DD)")
+
+	trashCmd.AddCommand(trashRestoreCmd)
+	trashCmd.AddCommand(trashEmptyCmd)
+}
+
+func runTrash(cmd *cobra.Command, args []string) error {
+	thingsDB, err := db.Open(shared.GetDBPath(cmd))
+	if err != nil {
+		return err
+	}
+	defer thingsDB.Close()
+
+	items, err := thingsDB.ListTrash()
+	if err != nil {
+		return err
+	}
+
+	if shared.IsJSON(cmd) {
+		jsonItems := make([]models.TrashItemJSON, len(items))
+		for i, item := range items {
+			jsonItems[i] = item.ToJSON()
+		}
+		data, err := json.MarshalIndent(jsonItems, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	formatter := output.NewTableFormatter(shared.IsNoColor(cmd))
+	return formatter.FormatTrash(items)
+}
+
+func runTrashRestore(cmd *cobra.Command, args []string) error {
+	thingsDB, err := db.Open(shared.GetDBPath(cmd))
+	if err != nil {
+		return err
+	}
+	defer thingsDB.Close()
+
+	uuid, err := thingsDB.ResolveTrashUUID(args[0])
+	if err != nil {
+		return err
+	}
+	item, err := thingsDB.GetTrashItem(uuid)
+	if err != nil {
+		return err
+	}
+	if item.ProjectTrashed {
+		return fmt.Errorf("project %q is in the trash too; restore it first with: thingies trash restore %s",
+			item.ProjectName.String, item.ProjectUUID.String)
+	}
+
+	params := things.RestoreParams{
+		UUID:      uuid,
+		IsProject: item.Type == models.TypeProject,
+		IsHeading: item.Type == models.TypeHeading,
+		List:      item.List(),
+		Project:   item.ProjectUUID.String,
+		Area:      item.AreaUUID.String,
+	}
+	if err := shared.GetClient(cmd).RestoreItem(params); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", strings.ToLower(item.Type.String()), err)
+	}
+
+	fmt.Printf("Restored %s: %s\n", strings.ToLower(item.Type.String()), uuid)
+	return nil
+}
+
+func runTrashEmpty(cmd *cobra.Command, args []string) error {
+	thingsDB, err := db.Open(shared.GetDBPath(cmd))
+	if err != nil {
+		return err
+	}
+	defer thingsDB.Close()
+
+	items, err := thingsDB.ListTrash()
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		fmt.Println("Trash is already empty")
+		return nil
+	}
+
+	if trashEmptyOlderThan != "" {
+		cutoff, err := db.ParseAge(trashEmptyOlderThan)
+		if err != nil {
+			return err
+		}
+		recent := 0
+		for _, item := range items {
+			if !item.Trashed.Valid || !item.Trashed.Time.Before(cutoff) {
+				recent++
+			}
+		}
+		if recent > 0 {
+			return fmt.Errorf("%d of %d trashed item(s) are newer than %s and Things can only empty the whole trash; nothing was deleted",
+				recent, len(items), trashEmptyOlderThan)
+		}
+	}
+
+	if err := shared.GetClient(cmd).EmptyTrash(); err != nil {
+		return fmt.Errorf("failed to empty trash: %w", err)
+	}
+
+	fmt.Printf("Emptied trash: %d item(s)\n", len(items))
+	return nil
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/things"
+)
+
+// TestTrashEmptyOlderThan checks that --older-than refuses to empty a trash
+// holding recent items, since Things can only empty all of it
+func TestTrashEmptyOlderThan(t *testing.T) {
+	b := dbtest.New(t)
+	b.Task("Old").Trashed().Modified(time.Now().AddDate(0, 0, -40))
+	b.Task("Recent").Trashed().Modified(time.Now().AddDate(0, 0, -2))
+	old := dbtest.New(t)
+	old.Task("Old").Trashed().Modified(time.Now().AddDate(0, 0, -40))
+	defer func() { trashEmptyOlderThan = "" }()
+	defer rootCmd.SetArgs(nil)
+
+	rec := things.NewRecordingBackend()
+	rootCmd.SetArgs([]string{"--db", b.Path(), "trash", "empty", "--older-than", "30d"})
+	err := ExecuteWith(rec)
+	if err == nil || !strings.Contains(err.Error(), "1 of 2") {
+		t.Fatalf("expected a refusal naming 1 of 2 items, got %v", err)
+	}
+	if len(rec.Scripts()) != 0 {
+		t.Fatalf("expected no AppleScript, got %v", rec.Scripts())
+	}
+
+	rootCmd.SetArgs([]string{"--db", old.Path(), "trash", "empty", "--older-than", "30d"})
+	if err := ExecuteWith(rec); err != nil {
+		t.Fatalf("ExecuteWith: %v", err)
+	}
+	if scripts := rec.Scripts(); len(scripts) != 1 || !strings.Contains(scripts[0], "empty trash") {
+		t.Errorf("expected one empty trash script, got %v", scripts)
+	}
+}
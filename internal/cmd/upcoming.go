@@ -7,14 +7,25 @@ import (
 	"github.com/spf13/cobra"
 	"thingies/internal/cmd/shared"
 	"thingies/internal/db"
+	"thingies/internal/models"
 	"thingies/internal/output"
 )
 
+var upcomingHorizon string
+
 var upcomingCmd = &cobra.Command{
 	Use:   "upcoming",
 	Short: "Show upcoming tasks",
-	Long:  `Show tasks scheduled for the future.`,
-	RunE:  runUpcoming,
+	Long: `Show tasks scheduled for the future.
+
+With --horizon, repeating tasks also get projected occurrences that Things has
+not created yet, up to that far ahead (e.g. 30d, 2w, 3m, or YYYY-MM-DD).
+Projected rows carry the repeating template's UUID and are marked as such.`,
+	RunE: runUpcoming,
+}
+
+func init() {
+	upcomingCmd.Flags().StringVar(&upcomingHorizon, "horizon", "", "Also project repeating tasks this far ahead (e.g. 30d, 2w, 3m, YYYY-MM-DD)")
 }
 
 func runUpcoming(cmd *cobra.Command, args []string) error {
@@ -24,9 +35,21 @@ func runUpcoming(cmd *cobra.Command, args []string) error {
 	}
 	defer thingsDB.Close()
 
-	tasks, err := thingsDB.GetUpcomingTasks()
-	if err != nil {
-		return err
+	var tasks []models.Task
+	if upcomingHorizon != "" {
+		until, err := db.ParseHorizon(upcomingHorizon)
+		if err != nil {
+			return err
+		}
+		tasks, err = thingsDB.GetUpcomingWithProjections(until)
+		if err != nil {
+			return err
+		}
+	} else {
+		tasks, err = thingsDB.GetUpcomingTasks()
+		if err != nil {
+			return err
+		}
 	}
 
 	if shared.IsJSON(cmd) {
@@ -0,0 +1,324 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"thingies/internal/cmd/shared"
+	"thingies/internal/db"
+	"thingies/internal/models"
+	"thingies/internal/output"
+	"thingies/internal/watch"
+)
+
+var (
+	watchInterval  time.Duration
+	watchHighlight time.Duration
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [today|inbox|anytime|someday|query <expression>...]",
+	Short: "Show a task list that redraws as Things changes",
+	Long: `Show a task list and redraw it whenever the Things database changes,
+for example in a terminal pane kept open next to Things:
+
+  thingies watch                          # Today (the default)
+  thingies watch inbox
+  thingies watch query tag:urgent due<+7d # any query, see thingies query
+  thingies watch tag:urgent               # the query keyword is optional
+
+Tasks that appear are marked + and tasks that are completed or canceled are
+marked ✓ and stay in the list, for --highlight, before they drop out. The
+last line shows when the list was refreshed. Press Ctrl-C to stop.`,
+	RunE: runWatch,
+}
+
+func init() {
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", time.Second, "How often to check the database files for changes")
+	watchCmd.Flags().DurationVar(&watchHighlight, "highlight", 5*time.Second, "How long new and completed tasks stay marked")
+
+	rootCmd.AddCommand(watchCmd)
+}
+
+// watchView is a task list thingies watch can show
+type watchView struct {
+	title string
+	today bool // split off This Evening, like thingies today
+	load  func(*db.ThingsDB) ([]models.Task, error)
+}
+
+// parseWatchView resolves the arguments of thingies watch to a view. A
+// single view name picks that view; anything else is a query.
+func parseWatchView(args []string) (watchView, error) {
+	if len(args) == 0 {
+		args = []string{"today"}
+	}
+	if len(args) == 1 {
+		switch args[0] {
+		case "today":
+			return watchView{title: "Today", today: true, load: func(d *db.ThingsDB) ([]models.Task, error) {
+				return d.ListTasks(db.TaskFilter{Status: "incomplete", Today: true})
+			}}, nil
+		case "inbox":
+			return watchView{title: "Inbox", load: (*db.ThingsDB).GetInboxTasks}, nil
+		case "anytime":
+			return watchView{title: "Anytime", load: (*db.ThingsDB).GetAnytimeTasks}, nil
+		case "someday":
+			return watchView{title: "Someday", load: (*db.ThingsDB).GetSomedayTasks}, nil
+		}
+	}
+
+	if args[0] == "query" {
+		args = args[1:]
+		if len(args) == 0 {
+			return watchView{}, fmt.Errorf("watch query needs an expression, e.g. thingies watch query tag:urgent")
+		}
+	}
+	expr := joinQueryArgs(args)
+	if _, err := db.ParseQuery(expr); err != nil {
+		return watchView{}, err
+	}
+	// Parsed again on every load, so relative dates such as due<+7d move on
+	// with the midnight reload
+	return watchView{title: expr, load: func(d *db.ThingsDB) ([]models.Task, error) {
+		q, err := db.ParseQuery(expr)
+		if err != nil {
+			return nil, err
+		}
+		return d.ListTasks(db.TaskFilter{Query: q})
+	}}, nil
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	if shared.IsJSON(cmd) {
+		return fmt.Errorf("watch has no JSON output; use GET /events from thingies serve for a stream of changes")
+	}
+	if watchInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	view, err := parseWatchView(args)
+	if err != nil {
+		return err
+	}
+
+	thingsDB, err := db.Open(shared.GetDBPath(cmd))
+	if err != nil {
+		return err
+	}
+	defer thingsDB.Close()
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	changed := watch.Files(ctx, watchInterval, watch.DatabaseFiles(thingsDB.Path())...)
+
+	formatter := output.NewTableFormatter(shared.IsNoColor(cmd))
+	screen := isTerminal(os.Stdout)
+	if screen {
+		fmt.Print("\033[?25l")       // hide the cursor
+		defer fmt.Print("\033[?25h") // and bring it back
+	}
+
+	rows := &rowTracker{hold: watchHighlight}
+	var refreshed time.Time
+	var failure error
+	refresh := func() {
+		now := time.Now()
+		tasks, err := view.load(thingsDB)
+		if failure = err; err == nil {
+			rows.update(tasks, now, thingsDB.GetTask)
+			refreshed = now
+		}
+	}
+	drawn := false
+	draw := func() {
+		if screen {
+			fmt.Print("\033[H\033[2J")
+		} else if drawn {
+			fmt.Println()
+		}
+		drawn = true
+		tasks, highlights := rows.rows(time.Now())
+		formatter.SetHighlights(highlights)
+		if view.today {
+			formatter.FormatToday(tasks)
+		} else {
+			formatter.FormatTasks(tasks)
+		}
+		fmt.Println()
+		formatter.FormatStatus(watchStatus(view.title, refreshed, highlights, failure), failure != nil)
+	}
+
+	refresh()
+	if refreshed.IsZero() {
+		return failure
+	}
+	draw()
+
+	for {
+		// Redraw when a mark runs out, and reload at midnight for Today
+		var expired <-chan time.Time
+		var expiry *time.Timer
+		if next := rows.nextExpiry(); !next.IsZero() {
+			expiry = time.NewTimer(time.Until(next))
+			expired = expiry.C
+		}
+		midnight := time.NewTimer(time.Until(startOfTomorrow(time.Now())))
+
+		reload, quit := false, false
+		select {
+		case <-ctx.Done():
+			quit = true
+		case _, ok := <-changed:
+			reload, quit = true, !ok
+		case <-midnight.C:
+			reload = true
+		case <-expired:
+		}
+		midnight.Stop()
+		if expiry != nil {
+			expiry.Stop()
+		}
+		if quit {
+			return nil
+		}
+		if reload {
+			refresh()
+		}
+		draw()
+	}
+}
+
+// watchStatus renders the status line below a watched list
+func watchStatus(title string, refreshed time.Time, highlights map[string]output.Highlight, failure error) string {
+	parts := []string{title}
+	if failure != nil {
+		parts = append(parts, fmt.Sprintf("refresh failed at %s: %v", time.Now().Format("15:04:05"), failure))
+		parts = append(parts, "showing "+refreshed.Format("15:04:05"))
+	} else {
+		parts = append(parts, "refreshed "+refreshed.Format("15:04:05"))
+	}
+	var added, completed int
+	for _, h := range highlights {
+		switch h {
+		case output.HighlightAdded:
+			added++
+		case output.HighlightCompleted:
+			completed++
+		}
+	}
+	if added > 0 {
+		parts = append(parts, fmt.Sprintf("%d new", added))
+	}
+	if completed > 0 {
+		parts = append(parts, fmt.Sprintf("%d done", completed))
+	}
+	return strings.Join(append(parts, "Ctrl-C to stop"), " · ")
+}
+
+// rowTracker compares successive reads of a task list to mark tasks that
+// appeared or were completed. Completed tasks usually drop out of the list,
+// so they are kept in it at their old position until their mark runs out.
+type rowTracker struct {
+	hold   time.Duration // how long a mark lasts
+	loaded []models.Task // the latest read
+	primed bool          // a first read was recorded; it marks nothing
+	marks  map[string]rowMark
+}
+
+// rowMark is the mark on one task
+type rowMark struct {
+	highlight output.Highlight
+	until     time.Time
+	task      *models.Task // a completed task no longer in the list
+	index     int          // and its position when it was
+}
+
+// update records a new read of the list, marking the changes since the
+// previous one. lookup reads a task that left the list, to tell completed
+// tasks from ones that were moved or deleted.
+func (r *rowTracker) update(tasks []models.Task, now time.Time, lookup func(uuid string) (*models.Task, error)) {
+	if r.marks == nil {
+		r.marks = make(map[string]rowMark)
+	}
+	if r.primed {
+		until := now.Add(r.hold)
+		prev := make(map[string]models.Task, len(r.loaded))
+		for _, task := range r.loaded {
+			prev[task.UUID] = task
+		}
+		current := make(map[string]bool, len(tasks))
+		for _, task := range tasks {
+			current[task.UUID] = true
+			old, seen := prev[task.UUID]
+			switch {
+			case !seen:
+				r.marks[task.UUID] = rowMark{highlight: output.HighlightAdded, until: until}
+			case task.Status != models.StatusIncomplete && old.Status == models.StatusIncomplete:
+				r.marks[task.UUID] = rowMark{highlight: output.HighlightCompleted, until: until}
+			}
+		}
+		for i, old := range r.loaded {
+			if current[old.UUID] || old.Status != models.StatusIncomplete {
+				continue
+			}
+			if task, err := lookup(old.UUID); err == nil && task.Status != models.StatusIncomplete {
+				r.marks[old.UUID] = rowMark{highlight: output.HighlightCompleted, until: until, task: task, index: i}
+			}
+		}
+	}
+	r.loaded, r.primed = tasks, true
+}
+
+// rows returns the list to show at now, with the marks still in effect
+func (r *rowTracker) rows(now time.Time) ([]models.Task, map[string]output.Highlight) {
+	highlights := make(map[string]output.Highlight)
+	var kept []rowMark
+	for uuid, mark := range r.marks {
+		if !now.Before(mark.until) {
+			delete(r.marks, uuid)
+			continue
+		}
+		highlights[uuid] = mark.highlight
+		if mark.task != nil {
+			kept = append(kept, mark)
+		}
+	}
+
+	tasks := slices.Clone(r.loaded)
+	sort.Slice(kept, func(i, j int) bool { return kept[i].index < kept[j].index })
+	for _, mark := range kept {
+		tasks = slices.Insert(tasks, min(mark.index, len(tasks)), *mark.task)
+	}
+	return tasks, highlights
+}
+
+// nextExpiry returns when the next mark runs out, zero if there are none
+func (r *rowTracker) nextExpiry() time.Time {
+	var next time.Time
+	for _, mark := range r.marks {
+		if next.IsZero() || mark.until.Before(next) {
+			next = mark.until
+		}
+	}
+	return next
+}
+
+// startOfTomorrow returns local midnight after t
+func startOfTomorrow(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+}
+
+// isTerminal reports whether f is a terminal rather than a file or pipe,
+// in which case each redraw replaces the last
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
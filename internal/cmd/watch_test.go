@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"thingies/internal/db"
+	"thingies/internal/models"
+	"thingies/internal/output"
+)
+
+func TestRowTracker(t *testing.T) {
+	task := func(uuid string, status models.TaskStatus) models.Task {
+		return models.Task{UUID: uuid, Title: uuid, Status: status}
+	}
+	open := models.StatusIncomplete
+	// What the database says about tasks that left the list
+	elsewhere := map[string]*models.Task{
+		"b": {UUID: "b", Title: "b", Status: models.StatusCompleted},
+		"c": {UUID: "c", Title: "c", Status: open}, // moved to another list
+	}
+	lookup := func(uuid string) (*models.Task, error) {
+		if task, ok := elsewhere[uuid]; ok {
+			return task, nil
+		}
+		return nil, db.ErrNotFound
+	}
+
+	start := time.Now()
+	r := &rowTracker{hold: 5 * time.Second}
+	r.update([]models.Task{task("a", open), task("b", open), task("c", open), task("d", open)}, start, lookup)
+	if _, highlights := r.rows(start); len(highlights) != 0 {
+		t.Fatalf("the first read should mark nothing, got %v", highlights)
+	}
+
+	// b is completed and drops out, c moves away, d is completed in place,
+	// and e appears
+	now := start.Add(time.Second)
+	r.update([]models.Task{task("a", open), task("d", models.StatusCompleted), task("e", open)}, now, lookup)
+
+	tasks, highlights := r.rows(now)
+	var uuids []string
+	for _, task := range tasks {
+		uuids = append(uuids, task.UUID)
+	}
+	if want := []string{"a", "b", "d", "e"}; !reflect.DeepEqual(uuids, want) {
+		t.Errorf("expected the completed task kept in place, %v, got %v", want, uuids)
+	}
+	want := map[string]output.Highlight{
+		"b": output.HighlightCompleted,
+		"d": output.HighlightCompleted,
+		"e": output.HighlightAdded,
+	}
+	if !reflect.DeepEqual(highlights, want) {
+		t.Errorf("expected highlights %v, got %v", want, highlights)
+	}
+	if next := r.nextExpiry(); !next.Equal(now.Add(5 * time.Second)) {
+		t.Errorf("expected marks to run out at %v, got %v", now.Add(5*time.Second), next)
+	}
+
+	// Once the marks run out b drops out and nothing is marked
+	tasks, highlights = r.rows(now.Add(5 * time.Second))
+	if len(tasks) != 3 || len(highlights) != 0 || !r.nextExpiry().IsZero() {
+		t.Errorf("expected marks gone, got %d tasks and %v", len(tasks), highlights)
+	}
+}
+
+func TestParseWatchView(t *testing.T) {
+	tests := []struct {
+		args    []string
+		title   string
+		wantErr bool
+	}{
+		{nil, "Today", false},
+		{[]string{"inbox"}, "Inbox", false},
+		{[]string{"query", "tag:urgent", "due<+7d"}, "tag:urgent due<+7d", false},
+		{[]string{"tag:urgent"}, "tag:urgent", false},
+		{[]string{"query"}, "", true},
+		{[]string{"colour:red"}, "", true},
+	}
+	for _, tt := range tests {
+		view, err := parseWatchView(tt.args)
+		if (err != nil) != tt.wantErr || view.title != tt.title {
+			t.Errorf("parseWatchView(%q) = %q, %v", tt.args, view.title, err)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package db
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"thingies/internal/models"
+)
+
+// changeTaskSelect selects to-dos for the change feed with the columns
+// scanTasks expects. Like ListTasks it skips trashed to-dos and the to-dos
+// of trashed projects; unlike it, completed and canceled ones are included.
+const changeTaskSelect = `
+	SELECT
+		t.uuid,
+		t.title,
+		t.notes,
+		t.status,
+		t.type,
+		t.creationDate,
+		t.userModificationDate,
+		t.startDate,
+		t.deadline,
+		t.stopDate,
+		COALESCE(a.title, pa.title, hpa.title) as area_name,
+		COALESCE(p.uuid, hp.uuid) as project_uuid,
+		COALESCE(p.title, hp.title) as project_name,
+		h.uuid as heading_uuid,
+		h.title as heading_name,
+		GROUP_CONCAT(tag.title, ', ') as tags,
+		CASE WHEN t.rt1_repeatingTemplate IS NOT NULL THEN 1 ELSE 0 END as is_repeating,
+		t.todayIndex,
+		COALESCE(t.startBucket, 0) as start_bucket,
+		COALESCE(t.checklistItemsCount, 0) as checklist_total,
+		COALESCE(t.openChecklistItemsCount, 0) as checklist_open
+	FROM TMTask t
+	LEFT JOIN TMArea a ON t.area = a.uuid
+	LEFT JOIN TMTask p ON t.project = p.uuid AND p.type = 1
+	LEFT JOIN TMArea pa ON p.area = pa.uuid
+	LEFT JOIN TMTask h ON t.heading = h.uuid
+	LEFT JOIN TMTask hp ON h.project = hp.uuid AND hp.type = 1
+	LEFT JOIN TMArea hpa ON hp.area = hpa.uuid
+	LEFT JOIN TMTaskTag tt ON t.uuid = tt.tasks
+	LEFT JOIN TMTag tag ON tt.tags = tag.uuid
+	WHERE t.type = 0 AND t.trashed = 0
+		AND (p.trashed IS NULL OR p.trashed = 0)
+		AND (hp.trashed IS NULL OR hp.trashed = 0)`
+
+// changeCursor is the decoded form of the opaque cursor returned by Changes:
+// the newest creation or modification date seen and digests of the area and
+// tag tables, which have no modification dates of their own
+type changeCursor struct {
+	Version  int     `json:"v"`
+	Modified float64 `json:"m"`
+	Areas    string  `json:"a"`
+	Tags     string  `json:"t"`
+}
+
+// cursorVersion is bumped whenever the cursor format changes, so that old
+// cursors are rejected instead of misread
+const cursorVersion = 1
+
+// Changes returns the tasks, projects, and headings created, updated, or
+// trashed after since, and the area and tag lists if either changed. since
+// is "" for a full sync, an RFC3339 time, or the Cursor of an earlier call.
+// Errors for any other since wrap ErrInvalidCursor.
+//
+// Changes are found by TMTask.creationDate and userModificationDate, so an
+// item is reported once per change, not once per edit; clients should treat
+// created and updated alike as upserts. Emptying the trash removes rows
+// without a trace, so items deleted that way are never reported.
+func (db *ThingsDB) Changes(since string) (*models.Changes, error) {
+	from, err := parseSince(since)
+	if err != nil {
+		return nil, err
+	}
+
+	// Take the new cursor first: rows written while the queries below run
+	// may then be reported twice, but never missed
+	next, err := db.changeCursor(from.Modified)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &models.Changes{Cursor: next.encode()}
+	if from.Modified > 0 {
+		sec, frac := math.Modf(from.Modified)
+		changes.Since = time.Unix(int64(sec), int64(frac*1e9))
+	}
+
+	created := Cond("COALESCE(t.creationDate, 0) > ?", from.Modified)
+	updated := Cond(`COALESCE(t.creationDate, 0) <= ? AND (t.userModificationDate > ?
+		OR EXISTS (SELECT 1 FROM TMChecklistItem c WHERE c.task = t.uuid AND c.userModificationDate > ?))`,
+		from.Modified, from.Modified, from.Modified)
+
+	if changes.Created.Tasks, err = db.changedTasks(created); err != nil {
+		return nil, err
+	}
+	if changes.Updated.Tasks, err = db.changedTasks(updated); err != nil {
+		return nil, err
+	}
+
+	created = Cond("COALESCE(creationDate, 0) > ?", from.Modified)
+	updated = Cond("COALESCE(creationDate, 0) <= ? AND userModificationDate > ?", from.Modified, from.Modified)
+	if changes.Created.Projects, err = db.changedProjects(created); err != nil {
+		return nil, err
+	}
+	if changes.Updated.Projects, err = db.changedProjects(updated); err != nil {
+		return nil, err
+	}
+	if changes.Created.Headings, err = db.changedHeadings(created); err != nil {
+		return nil, err
+	}
+	if changes.Updated.Headings, err = db.changedHeadings(updated); err != nil {
+		return nil, err
+	}
+	if err := db.trashedSince(from.Modified, &changes.Deleted); err != nil {
+		return nil, err
+	}
+
+	if from.Areas != next.Areas {
+		if changes.Areas, err = db.ListAreas(); err != nil {
+			return nil, err
+		}
+		if changes.Areas == nil {
+			changes.Areas = []models.Area{}
+		}
+	}
+	if from.Tags != next.Tags {
+		if changes.Tags, err = db.ListTags(); err != nil {
+			return nil, err
+		}
+		if changes.Tags == nil {
+			changes.Tags = []models.Tag{}
+		}
+	}
+	return changes, nil
+}
+
+// parseSince decodes the since argument of Changes. An RFC3339 time carries
+// no area or tag digests, so both lists count as changed.
+func parseSince(since string) (changeCursor, error) {
+	if since == "" {
+		return changeCursor{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, since); err == nil {
+		return changeCursor{Modified: float64(t.UnixNano()) / 1e9}, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(since)
+	if err != nil {
+		return changeCursor{}, fmt.Errorf("%w: %q is neither an RFC3339 time nor a cursor", ErrInvalidCursor, since)
+	}
+	var c changeCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Version != cursorVersion {
+		return changeCursor{}, fmt.Errorf("%w: %q is neither an RFC3339 time nor a cursor", ErrInvalidCursor, since)
+	}
+	return c, nil
+}
+
+// encode returns the opaque string form of the cursor
+func (c changeCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// changeCursor returns a cursor for the current state of the database. Its
+// watermark never moves back past since.
+func (db *ThingsDB) changeCursor(since float64) (changeCursor, error) {
+	c := changeCursor{Version: cursorVersion, Modified: since}
+
+	var newest sql.NullFloat64
+	err := db.conn.QueryRow(`
+		SELECT MAX(m) FROM (
+			SELECT MAX(MAX(COALESCE(creationDate, 0), COALESCE(userModificationDate, 0))) as m
+			FROM TMTask WHERE type IN (0, 1, 2)
+			UNION ALL
+			SELECT MAX(COALESCE(userModificationDate, 0)) FROM TMChecklistItem
+		)
+	`).Scan(&newest)
+	if err != nil {
+		return c, fmt.Errorf("failed to query modification dates: %w", err)
+	}
+	if newest.Valid && newest.Float64 > c.Modified {
+		c.Modified = newest.Float64
+	}
+
+	if c.Areas, err = db.tableDigest(`SELECT uuid, title, COALESCE(visible, -1), "index" FROM TMArea ORDER BY uuid`); err != nil {
+		return c, err
+	}
+	if c.Tags, err = db.tableDigest(`SELECT uuid, title, COALESCE(shortcut, ''), COALESCE(parent, ''), "index" FROM TMTag ORDER BY uuid`); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// tableDigest hashes the rows of query, whose columns are all read as text
+func (db *ThingsDB) tableDigest(query string) (string, error) {
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return "", fmt.Errorf("failed to query digest: %w", err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", fmt.Errorf("failed to query digest: %w", err)
+	}
+	values := make([]sql.NullString, len(cols))
+	dest := make([]interface{}, len(cols))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	h := sha256.New()
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return "", fmt.Errorf("failed to scan digest row: %w", err)
+		}
+		for _, v := range values {
+			fmt.Fprintf(h, "%q\x00", v.String)
+		}
+		h.Write([]byte{'\n'})
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("error iterating rows: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8]), nil
+}
+
+// changedTasks returns the to-dos matching cond, oldest change first
+func (db *ThingsDB) changedTasks(cond Predicate) ([]models.Task, error) {
+	rows, err := db.conn.Query(changeTaskSelect+" AND "+cond.SQL+`
+		GROUP BY t.uuid
+		ORDER BY t.userModificationDate, t."index"
+	`, cond.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query changed tasks: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTasks(rows)
+}
+
+// changedProjects returns the projects matching cond, oldest change first
+func (db *ThingsDB) changedProjects(cond Predicate) ([]models.Project, error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			p.uuid,
+			p.title,
+			p.notes,
+			p.status,
+			a.title as area_name,
+			p.openUntrashedLeafActionsCount as open_tasks,
+			p.untrashedLeafActionsCount as total_tasks
+		FROM TMTask p
+		LEFT JOIN TMArea a ON p.area = a.uuid
+		WHERE p.type = 1 AND p.trashed = 0 AND `+cond.SQL+`
+		ORDER BY p.userModificationDate, p."index"
+	`, cond.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query changed projects: %w", err)
+	}
+	defer rows.Close()
+
+	return scanProjects(rows)
+}
+
+// changedHeadings returns the headings matching cond, skipping those of
+// trashed projects, oldest change first
+func (db *ThingsDB) changedHeadings(cond Predicate) ([]models.Heading, error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			uuid,
+			title,
+			"index",
+			status != 0,
+			COALESCE(project, '')
+		FROM TMTask
+		WHERE type = 2 AND trashed = 0 AND `+cond.SQL+`
+			AND NOT EXISTS (SELECT 1 FROM TMTask p WHERE p.uuid = TMTask.project AND p.trashed = 1)
+		ORDER BY userModificationDate, "index"
+	`, cond.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query changed headings: %w", err)
+	}
+	defer rows.Close()
+
+	var headings []models.Heading
+	for rows.Next() {
+		var h models.Heading
+		if err := rows.Scan(&h.UUID, &h.Title, &h.Index, &h.Archived, &h.ProjectUUID); err != nil {
+			return nil, fmt.Errorf("failed to scan heading: %w", err)
+		}
+		headings = append(headings, h)
+	}
+	return headings, rows.Err()
+}
+
+// trashedSince fills deleted with the items trashed after since. Items both
+// created and trashed after since were never reported, so they are skipped.
+func (db *ThingsDB) trashedSince(since float64, deleted *models.DeletedSet) error {
+	rows, err := db.conn.Query(`
+		SELECT uuid, type
+		FROM TMTask
+		WHERE trashed = 1 AND type IN (0, 1, 2)
+			AND userModificationDate > ? AND COALESCE(creationDate, 0) <= ?
+		ORDER BY userModificationDate, "index"
+	`, since, since)
+	if err != nil {
+		return fmt.Errorf("failed to query trashed items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var uuid string
+		var taskType models.TaskType
+		if err := rows.Scan(&uuid, &taskType); err != nil {
+			return fmt.Errorf("failed to scan trashed item: %w", err)
+		}
+		switch taskType {
+		case models.TypeTask:
+			deleted.Tasks = append(deleted.Tasks, uuid)
+		case models.TypeProject:
+			deleted.Projects = append(deleted.Projects, uuid)
+		case models.TypeHeading:
+			deleted.Headings = append(deleted.Headings, uuid)
+		}
+	}
+	return rows.Err()
+}
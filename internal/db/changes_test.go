@@ -0,0 +1,135 @@
+package db_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"thingies/internal/db"
+	"thingies/internal/db/dbtest"
+	"thingies/internal/models"
+)
+
+func TestChanges(t *testing.T) {
+	now := time.Now()
+	dayAgo, twoHoursAgo, hourAgo := now.Add(-24*time.Hour), now.Add(-2*time.Hour), now.Add(-time.Hour)
+	since := now.Add(-5 * time.Hour)
+
+	b := dbtest.New(t)
+	work := b.Area("Work")
+	launch := b.Project("Launch").InArea(work).WithUUID("Launch0000000000000000").Created(dayAgo).Modified(twoHoursAgo)
+	b.Heading(launch, "Prep").WithUUID("Prep000000000000000000").Created(twoHoursAgo).Modified(twoHoursAgo)
+	retired := b.Project("Retired").WithUUID("Retired000000000000000").Created(dayAgo).Modified(hourAgo).Trashed()
+	b.Task("Orphan").InProject(retired).Created(hourAgo).Modified(hourAgo)
+	b.Task("Old").WithUUID("Old0000000000000000000").Created(dayAgo).Modified(dayAgo)
+	b.Task("Edited").WithUUID("Edited0000000000000000").InProject(launch).Created(dayAgo).Modified(hourAgo)
+	b.Task("Done").WithUUID("Done000000000000000000").Created(dayAgo).Modified(hourAgo).Completed()
+	b.Task("New").WithUUID("New0000000000000000000").Created(hourAgo).Modified(hourAgo).Tags("urgent")
+	b.Task("Gone").WithUUID("Gone000000000000000000").Created(dayAgo).Modified(hourAgo).Trashed()
+	b.Task("Flash").Created(hourAgo).Modified(hourAgo).Trashed()
+	thingsDB := b.Open()
+
+	changes, err := thingsDB.Changes(since.Format(time.RFC3339))
+	if err != nil {
+		t.Fatalf("Changes: %v", err)
+	}
+
+	if got := taskUUIDs(changes.Created.Tasks); !equalStrings(got, []string{"New0000000000000000000"}) {
+		t.Errorf("expected New created, got %v", got)
+	}
+	if got := taskUUIDs(changes.Updated.Tasks); !equalStrings(got, []string{"Edited0000000000000000", "Done000000000000000000"}) {
+		t.Errorf("expected Edited and Done updated, got %v", got)
+	}
+	if len(changes.Created.Projects) != 0 || len(changes.Updated.Projects) != 1 || changes.Updated.Projects[0].UUID != launch.UUID {
+		t.Errorf("expected only Launch updated, got created %+v updated %+v", changes.Created.Projects, changes.Updated.Projects)
+	}
+	if len(changes.Created.Headings) != 1 || changes.Created.Headings[0].ProjectUUID != launch.UUID {
+		t.Errorf("expected heading Prep created in Launch, got %+v", changes.Created.Headings)
+	}
+	if !equalStrings(changes.Deleted.Tasks, []string{"Gone000000000000000000"}) || !equalStrings(changes.Deleted.Projects, []string{retired.UUID}) {
+		t.Errorf("expected Gone and Retired deleted (not Flash or Orphan), got %+v", changes.Deleted)
+	}
+	if changes.Areas == nil || changes.Tags == nil {
+		t.Errorf("expected area and tag lists for an RFC3339 since, got %v and %v", changes.Areas, changes.Tags)
+	}
+	if changes.Since.Unix() != since.Unix() {
+		t.Errorf("expected since %v, got %v", since, changes.Since)
+	}
+
+	// A full sync lists every live item as created
+	full, err := thingsDB.Changes("")
+	if err != nil {
+		t.Fatalf("Changes(\"\"): %v", err)
+	}
+	if len(full.Created.Tasks) != 4 || full.Updated.Len() != 0 || full.Deleted.Len() != 0 || len(full.Areas) != 1 {
+		t.Errorf("expected 4 created tasks, 1 area, and nothing else, got %+v", full)
+	}
+
+	// Nothing changed since the cursor
+	again, err := thingsDB.Changes(full.Cursor)
+	if err != nil {
+		t.Fatalf("Changes(cursor): %v", err)
+	}
+	if !again.Empty() {
+		t.Errorf("expected no changes since the cursor, got %+v", again)
+	}
+	if again.Cursor != full.Cursor {
+		t.Errorf("expected the cursor to stay put, got %q then %q", full.Cursor, again.Cursor)
+	}
+
+	// Renaming an area and editing a task show up after the cursor
+	conn, err := sql.Open("sqlite", b.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	later := float64(now.Add(time.Minute).UnixNano()) / 1e9
+	if _, err := conn.Exec(`UPDATE TMArea SET title = 'Office' WHERE uuid = ?`, work.UUID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(`UPDATE TMTask SET title = 'Old, revived', userModificationDate = ? WHERE uuid = 'Old0000000000000000000'`, later); err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := thingsDB.Changes(full.Cursor)
+	if err != nil {
+		t.Fatalf("Changes(cursor): %v", err)
+	}
+	if len(next.Updated.Tasks) != 1 || next.Updated.Tasks[0].Title != "Old, revived" || next.Created.Len() != 0 {
+		t.Errorf("expected only Old updated, got %+v", next)
+	}
+	if len(next.Areas) != 1 || next.Areas[0].Title != "Office" || next.Tags != nil {
+		t.Errorf("expected the renamed area list and no tags, got %+v and %+v", next.Areas, next.Tags)
+	}
+}
+
+func TestChangesInvalidCursor(t *testing.T) {
+	thingsDB := dbtest.New(t).Open()
+
+	for _, since := range []string{"yesterday", "eyJ2Ijo5fQ", "2026-13-01T00:00:00Z"} {
+		if _, err := thingsDB.Changes(since); !errors.Is(err, db.ErrInvalidCursor) {
+			t.Errorf("Changes(%q): expected ErrInvalidCursor, got %v", since, err)
+		}
+	}
+}
+
+func taskUUIDs(tasks []models.Task) []string {
+	var uuids []string
+	for _, task := range tasks {
+		uuids = append(uuids, task.UUID)
+	}
+	return uuids
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"thingies/internal/models"
+)
+
+// ResolveChecklistItem returns the index in items of the item ref names:
+// a 1-based position as `tasks checklist` lists it, or a title matched
+// case-insensitively. Checklist items have no stable UUID to go by, since
+// rewriting a checklist through the URL scheme replaces every row.
+func ResolveChecklistItem(items []models.ChecklistItem, ref string) (int, error) {
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 1 || n > len(items) {
+			return 0, notFound("checklist item", ref)
+		}
+		return n - 1, nil
+	}
+
+	var matches []string
+	match := -1
+	for i, item := range items {
+		if strings.EqualFold(item.Title, ref) {
+			match = i
+			matches = append(matches, strconv.Itoa(i+1))
+		}
+	}
+	if len(matches) == 0 {
+		return 0, notFound("checklist item", ref)
+	}
+	if len(matches) > 1 {
+		return 0, fmt.Errorf("%w: multiple checklist items match '%s', use a position (candidates: %s)",
+			ErrAmbiguous, ref, strings.Join(matches, ", "))
+	}
+	return match, nil
+}
+
+// ReorderChecklist returns items with the ones refs name moved to the top,
+// in the order given, followed by the rest in their current order
+func ReorderChecklist(items []models.ChecklistItem, refs []string) ([]models.ChecklistItem, error) {
+	picked := make(map[int]bool, len(refs))
+	var out []models.ChecklistItem
+	for _, ref := range refs {
+		i, err := ResolveChecklistItem(items, ref)
+		if err != nil {
+			return nil, err
+		}
+		if !picked[i] {
+			picked[i] = true
+			out = append(out, items[i])
+		}
+	}
+	for i, item := range items {
+		if !picked[i] {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+// CheckChecklistItems returns a copy of items with the ones refs name marked
+// completed, or reopened when completed is false
+func CheckChecklistItems(items []models.ChecklistItem, refs []string, completed bool) ([]models.ChecklistItem, error) {
+	out := append([]models.ChecklistItem(nil), items...)
+	for _, ref := range refs {
+		i, err := ResolveChecklistItem(items, ref)
+		if err != nil {
+			return nil, err
+		}
+		out[i].Completed = completed
+		out[i].Canceled = false
+	}
+	return out, nil
+}
+
+// RemoveChecklistItems returns items without the ones refs name
+func RemoveChecklistItems(items []models.ChecklistItem, refs []string) ([]models.ChecklistItem, error) {
+	removed := make(map[int]bool, len(refs))
+	for _, ref := range refs {
+		i, err := ResolveChecklistItem(items, ref)
+		if err != nil {
+			return nil, err
+		}
+		removed[i] = true
+	}
+	var out []models.ChecklistItem
+	for i, item := range items {
+		if !removed[i] {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
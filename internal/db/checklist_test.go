@@ -0,0 +1,105 @@
+package db_test
+
+import (
+	"errors"
+	"testing"
+
+	"thingies/internal/db"
+	"thingies/internal/db/dbtest"
+	"thingies/internal/models"
+)
+
+// checklistTitles returns the titles of items in order
+func checklistTitles(items []models.ChecklistItem) []string {
+	out := make([]string, len(items))
+	for i, item := range items {
+		out[i] = item.Title
+	}
+	return out
+}
+
+func TestChecklistProgressInTaskLists(t *testing.T) {
+	b := dbtest.New(t)
+	b.Task("Pack").Today().CheckedItems("Passport", "Charger").Checklist("Socks")
+	b.Task("Plain").Today()
+	thingsDB := b.Open()
+
+	tasks, err := thingsDB.ListTasks(db.TaskFilter{Today: true})
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	progress := map[string]string{}
+	for _, task := range tasks {
+		progress[task.Title] = task.ToJSON().Checklist
+	}
+	if progress["Pack"] != "2/3" || progress["Plain"] != "" {
+		t.Errorf("expected Pack 2/3 and no progress for Plain, got %v", progress)
+	}
+
+	items, err := thingsDB.GetTaskChecklistItems(tasks[0].UUID)
+	if err != nil {
+		t.Fatalf("GetTaskChecklistItems: %v", err)
+	}
+	if got := checklistTitles(items); len(got) != 3 || !items[0].Completed || items[2].Completed {
+		t.Errorf("unexpected checklist %v: %+v", got, items)
+	}
+}
+
+func TestChecklistEdits(t *testing.T) {
+	items := []models.ChecklistItem{
+		{Title: "Passport", Completed: true},
+		{Title: "Charger"},
+		{Title: "Socks", Canceled: true},
+		{Title: "socks"},
+	}
+
+	if i, err := db.ResolveChecklistItem(items, "2"); err != nil || i != 1 {
+		t.Errorf("ResolveChecklistItem(position) = %d, %v", i, err)
+	}
+	if i, err := db.ResolveChecklistItem(items, "charger"); err != nil || i != 1 {
+		t.Errorf("ResolveChecklistItem(title) = %d, %v", i, err)
+	}
+	if _, err := db.ResolveChecklistItem(items, "Socks"); !errors.Is(err, db.ErrAmbiguous) {
+		t.Errorf("expected ErrAmbiguous for a repeated title, got %v", err)
+	}
+	for _, ref := range []string{"0", "5", "Towel"} {
+		if _, err := db.ResolveChecklistItem(items, ref); !errors.Is(err, db.ErrNotFound) {
+			t.Errorf("ResolveChecklistItem(%q): expected ErrNotFound, got %v", ref, err)
+		}
+	}
+
+	checked, err := db.CheckChecklistItems(items, []string{"2", "3"}, true)
+	if err != nil {
+		t.Fatalf("CheckChecklistItems: %v", err)
+	}
+	if !checked[1].Completed || !checked[2].Completed || checked[2].Canceled || items[1].Completed {
+		t.Errorf("expected items 2 and 3 completed in a copy, got %+v (original %+v)", checked, items)
+	}
+	unchecked, _ := db.CheckChecklistItems(items, []string{"Passport"}, false)
+	if unchecked[0].Done() {
+		t.Errorf("expected Passport reopened, got %+v", unchecked[0])
+	}
+
+	removed, err := db.RemoveChecklistItems(items, []string{"1", "4"})
+	if err != nil {
+		t.Fatalf("RemoveChecklistItems: %v", err)
+	}
+	if got := checklistTitles(removed); len(got) != 2 || got[0] != "Charger" || got[1] != "Socks" {
+		t.Errorf("expected [Charger Socks], got %v", got)
+	}
+
+	reordered, err := db.ReorderChecklist(items, []string{"3", "charger", "3"})
+	if err != nil {
+		t.Fatalf("ReorderChecklist: %v", err)
+	}
+	want := []string{"Socks", "Charger", "Passport", "socks"}
+	got := checklistTitles(reordered)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+	if !reordered[0].Canceled {
+		t.Error("reordering must keep each item's state")
+	}
+}
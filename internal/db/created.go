@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// createPollInterval is how often PendingCreate.Wait re-queries the database
+const createPollInterval = 100 * time.Millisecond
+
+// CreatedMatch describes a task or project that is about to be handed to
+// Things through the URL scheme, which does not report the new UUID
+type CreatedMatch struct {
+	Project bool   // a project instead of a to-do
+	Title   string // title sent to Things, for error messages
+	Notes   string // notes the item should end up with
+}
+
+func (m CreatedMatch) kind() string {
+	if m.Project {
+		return "project"
+	}
+	return "task"
+}
+
+// PendingCreate is a create that has been issued but not yet seen in the
+// database. It is found by a random marker that the create carries in its
+// notes (MarkedNotes), so no other item can be mistaken for it: not a
+// concurrent create with the same title in this or another process, and not
+// an item added by hand. Strip then puts the requested notes back.
+type PendingCreate struct {
+	db     *ThingsDB
+	match  CreatedMatch
+	marker string
+}
+
+// ExpectCreated prepares to wait for the item described by m. Send the
+// create with MarkedNotes as its notes, then call Wait and Strip.
+func (db *ThingsDB) ExpectCreated(m CreatedMatch) (*PendingCreate, error) {
+	var nonce [8]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate create marker: %w", err)
+	}
+	return &PendingCreate{db: db, match: m, marker: "[thingies:" + hex.EncodeToString(nonce[:]) + "]"}, nil
+}
+
+// MarkedNotes returns the notes to send with the create: the requested
+// notes followed by the marker on its own line
+func (p *PendingCreate) MarkedNotes() string {
+	if p.match.Notes == "" {
+		return p.marker
+	}
+	return p.match.Notes + "\n\n" + p.marker
+}
+
+// Wait polls the database until the row carrying the marker appears and
+// returns its UUID. It returns an error wrapping ErrCreateTimeout if nothing
+// shows up within timeout.
+func (p *PendingCreate) Wait(ctx context.Context, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(createPollInterval)
+	defer ticker.Stop()
+
+	for {
+		uuid, err := p.find()
+		if err != nil || uuid != "" {
+			return uuid, err
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return "", fmt.Errorf("%s %q was sent to Things but did not appear in the database within %s: %w", p.match.kind(), p.match.Title, timeout, ErrCreateTimeout)
+			}
+			return "", ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Strip removes the marker from the item Wait found by setting its notes
+// back to the requested ones with setNotes, normally things.Client.SetNotes
+func (p *PendingCreate) Strip(uuid string, setNotes func(uuid string, isProject bool, notes string) error) error {
+	if err := setNotes(uuid, p.match.Project, p.match.Notes); err != nil {
+		return fmt.Errorf("%s %s was created but the marker %s could not be removed from its notes: %w", p.match.kind(), uuid, p.marker, err)
+	}
+	return nil
+}
+
+// find returns the UUID of the row whose notes hold the marker, or "" if
+// there is none yet
+func (p *PendingCreate) find() (string, error) {
+	itemType := 0
+	if p.match.Project {
+		itemType = 1
+	}
+
+	var uuid string
+	err := p.db.conn.QueryRow(
+		`SELECT uuid FROM TMTask WHERE type = ? AND trashed = 0 AND instr(notes, ?) > 0 ORDER BY creationDate LIMIT 1`,
+		itemType, p.marker,
+	).Scan(&uuid)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query created items: %w", err)
+	}
+	return uuid, nil
+}
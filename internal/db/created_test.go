@@ -0,0 +1,119 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"thingies/internal/db"
+	"thingies/internal/db/dbtest"
+	"thingies/internal/sandbox"
+	"thingies/internal/things"
+)
+
+func TestExpectCreatedFindsNewRow(t *testing.T) {
+	b := dbtest.New(t)
+	work := b.Area("Work")
+	launch := b.Project("Launch").InArea(work)
+	b.Task("Write spec").InProject(launch).WithUUID("Old0000000000000000000")
+	thingsDB := b.Open()
+
+	backend, err := sandbox.Open(b.Path())
+	if err != nil {
+		t.Fatalf("sandbox.Open: %v", err)
+	}
+	defer backend.Close()
+
+	pending, err := thingsDB.ExpectCreated(db.CreatedMatch{Title: "Write spec", Notes: "Draft"})
+	if err != nil {
+		t.Fatalf("ExpectCreated: %v", err)
+	}
+	params := things.AddParams{Title: "Write spec", Notes: pending.MarkedNotes(), List: "Launch"}
+	if err := backend.OpenURL(things.BuildAddURL(params)); err != nil {
+		t.Fatalf("OpenURL: %v", err)
+	}
+
+	uuid, err := pending.Wait(context.Background(), 2*time.Second)
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if uuid == "" || uuid == "Old0000000000000000000" {
+		t.Errorf("expected the new task, got %q", uuid)
+	}
+
+	if err := pending.Strip(uuid, things.NewClient(backend).SetNotes); err != nil {
+		t.Fatalf("Strip: %v", err)
+	}
+	task, err := thingsDB.GetTask(uuid)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if task.Notes.String != "Draft" {
+		t.Errorf("expected the marker to be removed from the notes, got %q", task.Notes.String)
+	}
+}
+
+func TestExpectCreatedConcurrentSameTitle(t *testing.T) {
+	b := dbtest.New(t)
+	thingsDB := b.Open()
+
+	backend, err := sandbox.Open(b.Path())
+	if err != nil {
+		t.Fatalf("sandbox.Open: %v", err)
+	}
+	defer backend.Close()
+
+	match := db.CreatedMatch{Title: "Standup"}
+	first, err := thingsDB.ExpectCreated(match)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := thingsDB.ExpectCreated(match)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sent in the opposite order, so matching by arrival would swap them
+	for _, p := range []*db.PendingCreate{second, first} {
+		if err := backend.OpenURL(things.BuildAddURL(things.AddParams{Title: "Standup", Notes: p.MarkedNotes()})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a, err := first.Wait(context.Background(), 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := second.Wait(context.Background(), 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == c {
+		t.Fatalf("both creates found %s", a)
+	}
+	task, err := thingsDB.GetTask(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Notes.String != first.MarkedNotes() {
+		t.Errorf("first create found the task with notes %q", task.Notes.String)
+	}
+}
+
+func TestExpectCreatedTimeout(t *testing.T) {
+	b := dbtest.New(t)
+	b.Task("Already here")
+	thingsDB := b.Open()
+
+	pending, err := thingsDB.ExpectCreated(db.CreatedMatch{Title: "Already here"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pending.Wait(context.Background(), 150*time.Millisecond)
+	if !errors.Is(err, db.ErrCreateTimeout) {
+		t.Fatalf("expected ErrCreateTimeout, got %v", err)
+	}
+	if want := `task "Already here" was sent to Things but did not appear in the database within 150ms`; err.Error()[:len(want)] != want {
+		t.Errorf("unexpected message: %q", err.Error())
+	}
+}
@@ -0,0 +1,543 @@
+// Package dbtest builds throwaway Things-schema databases for tests.
+//
+// A Builder collects areas, projects, headings, tasks, tags, and checklist
+// items through chained calls and writes them to a temp file on Path or Open:
+//
+//	b := dbtest.New(t)
+//	work := b.Area("Work")
+//	launch := b.Project("Launch").InArea(work)
+//	b.Task("Write copy").InProject(launch).Today().Tags("urgent")
+//	things := b.Open()
+//
+// UUIDs are deterministic (kind prefix plus a counter, e.g.
+// TASK000000000000000001) unless overridden with WithUUID.
+package dbtest
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+	"thingies/internal/db"
+)
+
+// Builder accumulates rows for a Things-schema database
+type Builder struct {
+	t        testing.TB
+	path     string
+	counter  int
+	areas    []*Area
+	tags     []*Tag
+	tasks    []*Task
+	token    string
+	now      time.Time
+	flushed  bool
+	database *db.ThingsDB
+}
+
+// New returns a builder writing to a fresh temp directory owned by t
+func New(t testing.TB) *Builder {
+	t.Helper()
+	return &Builder{
+		t:    t,
+		path: filepath.Join(t.TempDir(), "main.sqlite"),
+		now:  time.Now(),
+	}
+}
+
+// DaysFromToday returns local midnight n days from today (negative for past)
+func DaysFromToday(n int) time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day()+n, 0, 0, 0, 0, time.Local)
+}
+
+// nextUUID returns a deterministic 22-character UUID for the given 4-letter kind
+func (b *Builder) nextUUID(kind string) string {
+	b.counter++
+	return fmt.Sprintf("%s%018d", kind, b.counter)
+}
+
+// AuthToken sets TMSettings.uriSchemeAuthenticationToken
+func (b *Builder) AuthToken(token string) *Builder {
+	b.token = token
+	return b
+}
+
+// Area adds a visible area
+func (b *Builder) Area(title string) *Area {
+	a := &Area{UUID: b.nextUUID("AREA"), b: b, title: title, index: len(b.areas) + 1}
+	b.areas = append(b.areas, a)
+	return a
+}
+
+// Tag adds a tag, or returns the existing tag with the same title
+func (b *Builder) Tag(title string) *Tag {
+	for _, tag := range b.tags {
+		if tag.title == title {
+			return tag
+		}
+	}
+	tag := &Tag{UUID: b.nextUUID("TAGS"), title: title, index: len(b.tags) + 1}
+	b.tags = append(b.tags, tag)
+	return tag
+}
+
+// Task adds an open to-do in the Inbox
+func (b *Builder) Task(title string) *Task {
+	return b.addTask(0, "TASK", title)
+}
+
+// Project adds an open project available in Anytime
+func (b *Builder) Project(title string) *Task {
+	return b.addTask(1, "PROJ", title).Anytime()
+}
+
+// Heading adds a heading to project
+func (b *Builder) Heading(project *Task, title string) *Task {
+	h := b.addTask(2, "HEAD", title).Anytime()
+	h.project = project.UUID
+	return h
+}
+
+func (b *Builder) addTask(taskType int, kind, title string) *Task {
+	t := &Task{
+		UUID:     b.nextUUID(kind),
+		b:        b,
+		taskType: taskType,
+		title:    title,
+		index:    len(b.tasks) + 1,
+		created:  b.now,
+		modified: b.now,
+	}
+	b.tasks = append(b.tasks, t)
+	return t
+}
+
+// Path writes the database (once) and returns its file path. Rows must be
+// fully described before the first call; later changes are not written.
+func (b *Builder) Path() string {
+	b.t.Helper()
+	if !b.flushed {
+		if err := b.flush(); err != nil {
+			b.t.Fatalf("dbtest: %v", err)
+		}
+		b.flushed = true
+	}
+	return b.path
+}
+
+// Open writes the database and opens it with db.Open. The connection is
+// closed when the test finishes.
+func (b *Builder) Open() *db.ThingsDB {
+	b.t.Helper()
+	if b.database != nil {
+		return b.database
+	}
+	thingsDB, err := db.Open(b.Path())
+	if err != nil {
+		b.t.Fatalf("dbtest: db.Open: %v", err)
+	}
+	b.t.Cleanup(func() { thingsDB.Close() })
+	b.database = thingsDB
+	return thingsDB
+}
+
+// flush creates the schema and inserts every collected row
+func (b *Builder) flush() error {
+	conn, err := sql.Open("sqlite", b.path)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", b.path, err)
+	}
+	defer conn.Close()
+
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(db.Schema); err != nil {
+		return fmt.Errorf("create schema: %w", err)
+	}
+
+	for _, a := range b.areas {
+		if _, err := tx.Exec(`INSERT INTO TMArea (uuid, title, visible, "index") VALUES (?, ?, ?, ?)`,
+			a.UUID, a.title, a.visible, a.index); err != nil {
+			return fmt.Errorf("insert area %q: %w", a.title, err)
+		}
+		for _, tag := range a.tags {
+			if _, err := tx.Exec(`INSERT INTO TMAreaTag (areas, tags) VALUES (?, ?)`, a.UUID, tag.UUID); err != nil {
+				return fmt.Errorf("tag area %q: %w", a.title, err)
+			}
+		}
+	}
+
+	for _, tag := range b.tags {
+		if _, err := tx.Exec(`INSERT INTO TMTag (uuid, title, shortcut, parent, "index") VALUES (?, ?, ?, ?, ?)`,
+			tag.UUID, tag.title, nullString(tag.shortcut), nullString(tag.parent), tag.index); err != nil {
+			return fmt.Errorf("insert tag %q: %w", tag.title, err)
+		}
+	}
+
+	for _, t := range b.tasks {
+		if err := t.insert(tx); err != nil {
+			return fmt.Errorf("insert task %q: %w", t.title, err)
+		}
+	}
+
+	if b.token != "" {
+		if _, err := tx.Exec(`INSERT INTO TMSettings (uuid, uriSchemeAuthenticationToken) VALUES ('RhAzEf6qDxCD5PmnZVtBZR', ?)`, b.token); err != nil {
+			return fmt.Errorf("insert settings: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(db.Recount); err != nil {
+		return fmt.Errorf("recount: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Area is an area row under construction
+type Area struct {
+	UUID    string
+	b       *Builder
+	title   string
+	visible sql.NullInt64
+	index   int
+	tags    []*Tag
+}
+
+// WithUUID overrides the generated UUID
+func (a *Area) WithUUID(uuid string) *Area {
+	a.UUID = uuid
+	return a
+}
+
+// Hidden marks the area as not visible (TMArea.visible = 0)
+func (a *Area) Hidden() *Area {
+	a.visible = sql.NullInt64{Int64: 0, Valid: true}
+	return a
+}
+
+// Tags attaches tags to the area, creating any that don't exist yet
+func (a *Area) Tags(titles ...string) *Area {
+	for _, title := range titles {
+		a.tags = append(a.tags, a.b.Tag(title))
+	}
+	return a
+}
+
+// Tag is a tag row under construction
+type Tag struct {
+	UUID     string
+	title    string
+	shortcut string
+	parent   string
+	index    int
+}
+
+// WithUUID overrides the generated UUID
+func (tag *Tag) WithUUID(uuid string) *Tag {
+	tag.UUID = uuid
+	return tag
+}
+
+// Parent nests the tag under parent
+func (tag *Tag) Parent(parent *Tag) *Tag {
+	tag.parent = parent.UUID
+	return tag
+}
+
+// Shortcut sets the tag's keyboard shortcut
+func (tag *Tag) Shortcut(key string) *Tag {
+	tag.shortcut = key
+	return tag
+}
+
+// checklistItem is a TMChecklistItem row under construction
+type checklistItem struct {
+	title string
+	done  bool
+}
+
+// Task is a TMTask row (to-do, project, or heading) under construction
+type Task struct {
+	UUID string
+	b    *Builder
+
+	taskType   int
+	title      string
+	notes      string
+	status     int
+	stopDate   *time.Time
+	trashed    bool
+	start      int
+	startDate  *time.Time
+	bucket     int
+	deadline   *time.Time
+	suppressed bool
+	area       string
+	project    string
+	heading    string
+	index      int
+	todayIndex int
+	created    time.Time
+	modified   time.Time
+	template   string
+	rule       []byte
+	nextStart  *time.Time
+	tags       []*Tag
+	checklist  []checklistItem
+}
+
+// WithUUID overrides the generated UUID
+func (t *Task) WithUUID(uuid string) *Task {
+	t.UUID = uuid
+	return t
+}
+
+// Notes sets the notes
+func (t *Task) Notes(notes string) *Task {
+	t.notes = notes
+	return t
+}
+
+// InArea files the task or project directly under area
+func (t *Task) InArea(area *Area) *Task {
+	t.area = area.UUID
+	return t.leaveInbox()
+}
+
+// InProject files the task under project
+func (t *Task) InProject(project *Task) *Task {
+	t.project = project.UUID
+	return t.leaveInbox()
+}
+
+// UnderHeading files the task under a heading. As in Things, the task's
+// project column stays empty; the project is reached through the heading.
+func (t *Task) UnderHeading(heading *Task) *Task {
+	t.heading = heading.UUID
+	t.project = ""
+	return t.leaveInbox()
+}
+
+// leaveInbox makes a filed task available in Anytime unless already scheduled
+func (t *Task) leaveInbox() *Task {
+	if t.start == 0 {
+		t.start = 1
+	}
+	return t
+}
+
+// Inbox puts the task back in the Inbox (start = 0)
+func (t *Task) Inbox() *Task {
+	t.start, t.startDate = 0, nil
+	return t
+}
+
+// Anytime makes the task available with no date (start = 1)
+func (t *Task) Anytime() *Task {
+	t.start, t.startDate = 1, nil
+	return t
+}
+
+// Someday defers the task with no date (start = 2)
+func (t *Task) Someday() *Task {
+	t.start, t.startDate = 2, nil
+	return t
+}
+
+// Today schedules the task for today (start = 1, startDate = today)
+func (t *Task) Today() *Task {
+	today := DaysFromToday(0)
+	t.start, t.startDate = 1, &today
+	return t
+}
+
+// Evening schedules the task for This Evening (Today with startBucket = 1)
+func (t *Task) Evening() *Task {
+	t.bucket = 1
+	return t.Today()
+}
+
+// Scheduled sets a start date the way Things stores one picked from the
+// calendar (start = 2, startDate = date). Past dates land in Today.
+func (t *Task) Scheduled(date time.Time) *Task {
+	t.start, t.startDate = 2, &date
+	return t
+}
+
+// Deadline sets the deadline
+func (t *Task) Deadline(date time.Time) *Task {
+	t.deadline = &date
+	return t
+}
+
+// SuppressDeadline sets deadlineSuppressionDate, hiding an overdue deadline from Today
+func (t *Task) SuppressDeadline() *Task {
+	t.suppressed = true
+	return t
+}
+
+// Completed marks the task completed now
+func (t *Task) Completed() *Task {
+	return t.stopped(3, t.b.now)
+}
+
+// CompletedAt marks the task completed at the given time
+func (t *Task) CompletedAt(at time.Time) *Task {
+	return t.stopped(3, at)
+}
+
+// Canceled marks the task canceled now
+func (t *Task) Canceled() *Task {
+	return t.stopped(2, t.b.now)
+}
+
+func (t *Task) stopped(status int, at time.Time) *Task {
+	t.status, t.stopDate = status, &at
+	return t
+}
+
+// Trashed moves the row to the trash
+func (t *Task) Trashed() *Task {
+	t.trashed = true
+	return t
+}
+
+// Tags attaches tags, creating any that don't exist yet
+func (t *Task) Tags(titles ...string) *Task {
+	for _, title := range titles {
+		t.tags = append(t.tags, t.b.Tag(title))
+	}
+	return t
+}
+
+// Checklist appends open checklist items
+func (t *Task) Checklist(titles ...string) *Task {
+	for _, title := range titles {
+		t.checklist = append(t.checklist, checklistItem{title: title})
+	}
+	return t
+}
+
+// CheckedItems appends completed checklist items
+func (t *Task) CheckedItems(titles ...string) *Task {
+	for _, title := range titles {
+		t.checklist = append(t.checklist, checklistItem{title: title, done: true})
+	}
+	return t
+}
+
+// RepeatingTemplate turns the task into a repeating template: a Someday row
+// carrying the recurrence rule whose next instance starts on next.
+func (t *Task) RepeatingTemplate(rule []byte, next time.Time) *Task {
+	t.rule, t.nextStart = rule, &next
+	return t.Someday()
+}
+
+// InstanceOf makes the task an instance of a repeating template
+func (t *Task) InstanceOf(template *Task) *Task {
+	t.template = template.UUID
+	return t
+}
+
+// Created sets creationDate
+func (t *Task) Created(at time.Time) *Task {
+	t.created = at
+	return t
+}
+
+// Modified sets userModificationDate
+func (t *Task) Modified(at time.Time) *Task {
+	t.modified = at
+	return t
+}
+
+// Index sets the sort position within its list
+func (t *Task) Index(index int) *Task {
+	t.index = index
+	return t
+}
+
+// TodayIndex sets the sort position within Today
+func (t *Task) TodayIndex(index int) *Task {
+	t.todayIndex = index
+	return t
+}
+
+// insert writes the task, its tags, and its checklist
+func (t *Task) insert(tx *sql.Tx) error {
+	var deadlineSuppression interface{}
+	if t.suppressed {
+		deadlineSuppression = db.DateToPackedInt(t.b.now)
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO TMTask (
+			uuid, type, title, notes, status, stopDate, trashed,
+			start, startDate, startBucket, deadline, deadlineSuppressionDate,
+			area, project, heading, "index", todayIndex,
+			creationDate, userModificationDate,
+			rt1_repeatingTemplate, rt1_recurrenceRule, rt1_nextInstanceStartDate
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.UUID, t.taskType, t.title, t.notes, t.status, unixOrNil(t.stopDate), t.trashed,
+		t.start, packedOrNil(t.startDate), t.bucket, packedOrNil(t.deadline), deadlineSuppression,
+		nullString(t.area), nullString(t.project), nullString(t.heading), t.index, t.todayIndex,
+		unix(t.created), unix(t.modified),
+		nullString(t.template), t.rule, packedOrNil(t.nextStart),
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range t.tags {
+		if _, err := tx.Exec(`INSERT INTO TMTaskTag (tasks, tags) VALUES (?, ?)`, t.UUID, tag.UUID); err != nil {
+			return err
+		}
+	}
+
+	for i, item := range t.checklist {
+		status, stopDate := 0, interface{}(nil)
+		if item.done {
+			status, stopDate = 3, unix(t.b.now)
+		}
+		_, err := tx.Exec(`
+			INSERT INTO TMChecklistItem (uuid, title, status, stopDate, "index", task, creationDate, userModificationDate)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			t.b.nextUUID("LIST"), item.title, status, stopDate, i+1, t.UUID, unix(t.created), unix(t.modified))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unix(at time.Time) float64 {
+	return float64(at.UnixNano()) / 1e9
+}
+
+func unixOrNil(at *time.Time) interface{} {
+	if at == nil {
+		return nil
+	}
+	return unix(*at)
+}
+
+func packedOrNil(date *time.Time) interface{} {
+	if date == nil {
+		return nil
+	}
+	return db.DateToPackedInt(*date)
+}
+
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
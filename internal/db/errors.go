@@ -0,0 +1,75 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors returned by lookups and UUID resolution. Test for them with
+// errors.Is; messages keep the "<entity> not found: <input>" form.
+var (
+	// ErrNotFound means no row matched the UUID, prefix, or name
+	ErrNotFound = errors.New("not found")
+	// ErrAmbiguous means a prefix or name matched more than one row.
+	// The concrete error is an *AmbiguousError listing the candidates.
+	ErrAmbiguous = errors.New("ambiguous")
+	// ErrInvalidPrefix means a UUID prefix was empty or not alphanumeric
+	ErrInvalidPrefix = errors.New("invalid prefix")
+	// ErrCreateTimeout means an item handed to Things did not show up in the
+	// database before the wait timed out. Things may still create it later.
+	ErrCreateTimeout = errors.New("timed out waiting for created item")
+	// ErrInvalidQuery means a task query did not parse. The concrete error is
+	// a *QueryError carrying the position.
+	ErrInvalidQuery = errors.New("invalid query")
+	// ErrInvalidRecurrence means an rt1_recurrenceRule could not be decoded
+	ErrInvalidRecurrence = errors.New("invalid recurrence rule")
+	// ErrInvalidMove means a move named no destination, several, or a
+	// heading outside the given project
+	ErrInvalidMove = errors.New("invalid move")
+	// ErrInvalidCursor means a change feed "since" value was neither an
+	// RFC3339 time nor a cursor returned by Changes
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// maxCandidates caps how many UUIDs an AmbiguousError carries
+const maxCandidates = 10
+
+// AmbiguousError reports a prefix or name that matched several rows
+type AmbiguousError struct {
+	Entity     string   // "task", "project", "area", "tag", "heading"
+	Input      string   // the prefix or name as given
+	ByName     bool     // true when Input was matched as a name, not a prefix
+	Candidates []string // matching UUIDs, at most maxCandidates
+	Truncated  bool     // true when more rows matched than Candidates holds
+}
+
+func (e *AmbiguousError) Error() string {
+	count := fmt.Sprintf("%d", len(e.Candidates))
+	if e.Truncated {
+		count = "more than " + count
+	}
+	if e.ByName {
+		return fmt.Sprintf("multiple %ss match '%s', use UUID (candidates: %s)", e.Entity, e.Input, strings.Join(e.Candidates, ", "))
+	}
+	return fmt.Sprintf("ambiguous %s prefix '%s' matches %s %ss (candidates: %s)", e.Entity, e.Input, count, e.Entity, strings.Join(e.Candidates, ", "))
+}
+
+// Is makes errors.Is(err, ErrAmbiguous) match
+func (e *AmbiguousError) Is(target error) bool {
+	return target == ErrAmbiguous
+}
+
+// newAmbiguousError builds an AmbiguousError from up to maxCandidates+1 UUIDs
+func newAmbiguousError(entity, input string, byName bool, uuids []string) *AmbiguousError {
+	e := &AmbiguousError{Entity: entity, Input: input, ByName: byName, Candidates: uuids}
+	if len(uuids) > maxCandidates {
+		e.Candidates, e.Truncated = uuids[:maxCandidates], true
+	}
+	return e
+}
+
+// notFound returns an ErrNotFound-wrapping error such as "task not found: abc"
+func notFound(entity, input string) error {
+	return fmt.Errorf("%s %w: %s", entity, ErrNotFound, input)
+}
@@ -0,0 +1,61 @@
+package db_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"thingies/internal/db"
+	"thingies/internal/db/dbtest"
+)
+
+func TestTypedResolveErrors(t *testing.T) {
+	b := dbtest.New(t)
+	for i := 1; i <= 12; i++ {
+		b.Task(fmt.Sprintf("task %d", i)).WithUUID(fmt.Sprintf("Many%018d", i))
+	}
+	b.Task("pair a").WithUUID("Pair100000000000000000")
+	b.Task("pair b").WithUUID("Pair200000000000000000")
+	b.Area("Home")
+	b.Area("Home")
+	thingsDB := b.Open()
+
+	_, err := thingsDB.ResolveTaskUUID("Nope")
+	if !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err.Error() != "task not found: Nope" {
+		t.Errorf("unexpected message: %q", err.Error())
+	}
+
+	_, err = thingsDB.ResolveTaskUUID("No pe")
+	if !errors.Is(err, db.ErrInvalidPrefix) {
+		t.Errorf("expected ErrInvalidPrefix, got %v", err)
+	}
+
+	_, err = thingsDB.ResolveTaskUUID("Pair")
+	var amb *db.AmbiguousError
+	if !errors.Is(err, db.ErrAmbiguous) || !errors.As(err, &amb) {
+		t.Fatalf("expected *AmbiguousError, got %v", err)
+	}
+	want := []string{"Pair100000000000000000", "Pair200000000000000000"}
+	if !slices.Equal(amb.Candidates, want) || amb.Truncated || amb.Entity != "task" {
+		t.Errorf("unexpected ambiguous error: %+v", amb)
+	}
+
+	_, err = thingsDB.ResolveTaskUUID("Many")
+	if !errors.As(err, &amb) || len(amb.Candidates) != 10 || !amb.Truncated {
+		t.Errorf("expected 10 truncated candidates, got %v", err)
+	}
+
+	_, err = thingsDB.ResolveAreaID("Home")
+	if !errors.As(err, &amb) || !amb.ByName || len(amb.Candidates) != 2 {
+		t.Errorf("expected ambiguous name error, got %v", err)
+	}
+
+	_, err = thingsDB.GetTask("Missing000000000000000")
+	if !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("expected GetTask to return ErrNotFound, got %v", err)
+	}
+}
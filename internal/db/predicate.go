@@ -0,0 +1,119 @@
+package db
+
+import (
+	"strings"
+)
+
+// Predicate is a SQL boolean expression with its ? arguments. Predicates used
+// in a TaskFilter may refer to the aliases of the ListTasks query: t (the
+// task), a/pa/hpa (its area, directly, via its project, or via its heading's
+// project), p/hp (its project, directly or via its heading), and h (its
+// heading).
+type Predicate struct {
+	SQL  string
+	Args []interface{}
+}
+
+// Cond returns a predicate for a single SQL expression
+func Cond(sql string, args ...interface{}) Predicate {
+	return Predicate{SQL: sql, Args: args}
+}
+
+// And returns a predicate matching when all of ps match. With no
+// predicates it matches everything.
+func And(ps ...Predicate) Predicate {
+	return join(" AND ", "1 = 1", ps)
+}
+
+// Or returns a predicate matching when any of ps matches. With no
+// predicates it matches nothing.
+func Or(ps ...Predicate) Predicate {
+	return join(" OR ", "1 = 0", ps)
+}
+
+// Not negates p
+func Not(p Predicate) Predicate {
+	return Predicate{SQL: "NOT (" + p.SQL + ")", Args: p.Args}
+}
+
+func join(sep, empty string, ps []Predicate) Predicate {
+	switch len(ps) {
+	case 0:
+		return Predicate{SQL: empty}
+	case 1:
+		return ps[0]
+	}
+	parts := make([]string, len(ps))
+	var args []interface{}
+	for i, p := range ps {
+		parts[i] = "(" + p.SQL + ")"
+		args = append(args, p.Args...)
+	}
+	return Predicate{SQL: strings.Join(parts, sep), Args: args}
+}
+
+// effectiveTags selects the UUIDs of every tag that applies to task t: its
+// own, its project's (directly or through its heading), and its area's
+// (directly, through its project, or through its heading's project)
+const effectiveTags = `
+	SELECT tags FROM TMTaskTag WHERE tasks IN (
+		t.uuid, t.project, (SELECT project FROM TMTask WHERE uuid = t.heading))
+	UNION
+	SELECT tags FROM TMAreaTag WHERE areas IN (
+		t.area,
+		(SELECT area FROM TMTask WHERE uuid = t.project),
+		(SELECT area FROM TMTask WHERE uuid = (SELECT project FROM TMTask WHERE uuid = t.heading)))`
+
+// hasTag matches tasks carrying the tag named (case-insensitive) or with
+// UUID tag, directly or inherited. With subtags, a tag nested anywhere below
+// it matches too, the way Things' own tag filter works.
+func hasTag(tag string, subtags bool) Predicate {
+	if !subtags {
+		return Cond(`EXISTS (SELECT 1 FROM TMTag qtag
+			WHERE (LOWER(qtag.title) = LOWER(?) OR qtag.uuid = ?)
+			AND qtag.uuid IN (`+effectiveTags+`))`, tag, tag)
+	}
+	return Cond(`EXISTS (
+		WITH RECURSIVE qtag(uuid) AS (
+			SELECT uuid FROM TMTag WHERE LOWER(title) = LOWER(?) OR uuid = ?
+			UNION
+			SELECT child.uuid FROM TMTag child JOIN qtag ON child.parent = qtag.uuid)
+		SELECT 1 FROM qtag WHERE qtag.uuid IN (`+effectiveTags+`))`, tag, tag)
+}
+
+// untagged matches tasks with no tags, directly or inherited
+func untagged() Predicate {
+	return Cond(`NOT EXISTS (` + effectiveTags + `)`)
+}
+
+// tagFilter combines all-of, any-of, and none-of tag lists, or returns nil
+// when all are empty
+func tagFilter(all, any, none []string, subtags bool) *Predicate {
+	if len(all)+len(any)+len(none) == 0 {
+		return nil
+	}
+	var ps []Predicate
+	for _, tag := range all {
+		ps = append(ps, hasTag(tag, subtags))
+	}
+	if len(any) > 0 {
+		alternatives := make([]Predicate, len(any))
+		for i, tag := range any {
+			alternatives[i] = hasTag(tag, subtags)
+		}
+		ps = append(ps, Or(alternatives...))
+	}
+	for _, tag := range none {
+		ps = append(ps, Not(hasTag(tag, subtags)))
+	}
+	p := And(ps...)
+	return &p
+}
+
+// likeEscaper escapes LIKE wildcards; patterns built with it need ESCAPE '\'
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern returns a LIKE pattern matching s anywhere
+func containsPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
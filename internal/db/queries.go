@@ -18,9 +18,25 @@ type TaskFilter struct {
 	Status        string // "all", "incomplete", "completed", "canceled"
 	Area          string
 	Project       string
-	Tag           string
+	Tags          []string // task has every one of these tags
+	AnyTags       []string // task has at least one of these tags
+	NotTags       []string // task has none of these tags
+	Subtags       bool     // a tag in Tags, AnyTags, or NotTags also matches its descendants
 	Today         bool
 	IncludeFuture bool
+	Query         *TaskQuery // query language filter, see ParseQuery
+}
+
+// todayCondition matches the Today list, following things.py:
+// 1. Anytime tasks with start dates (start=1, startDate set)
+// 2. Someday tasks with past start dates (start=2, startDate <= today)
+// 3. Overdue tasks by deadline (no startDate, deadline <= today, not suppressed)
+func todayCondition(todayPacked int) Predicate {
+	return Cond(`(
+			(t.start = 1 AND t.startDate IS NOT NULL)
+			OR (t.start = 2 AND t.startDate IS NOT NULL AND t.startDate <= ?)
+			OR (t.startDate IS NULL AND t.deadline IS NOT NULL AND t.deadline <= ? AND t.deadlineSuppressionDate IS NULL)
+		)`, todayPacked, todayPacked)
 }
 
 // ListTasks returns tasks matching the filter
@@ -44,7 +60,10 @@ func (db *ThingsDB) ListTasks(filter TaskFilter) ([]models.Task, error) {
 			h.title as heading_name,
 			GROUP_CONCAT(tag.title, ', ') as tags,
 			CASE WHEN t.rt1_repeatingTemplate IS NOT NULL THEN 1 ELSE 0 END as is_repeating,
-			t.todayIndex
+			t.todayIndex,
+			COALESCE(t.startBucket, 0) as start_bucket,
+			COALESCE(t.checklistItemsCount, 0) as checklist_total,
+			COALESCE(t.openChecklistItemsCount, 0) as checklist_open
 		FROM TMTask t
 		LEFT JOIN TMArea a ON t.area = a.uuid
 		LEFT JOIN TMTask p ON t.project = p.uuid AND p.type = 1 AND p.trashed = 0
@@ -63,8 +82,12 @@ func (db *ThingsDB) ListTasks(filter TaskFilter) ([]models.Task, error) {
 	var conditions []string
 	var params []interface{}
 
-	// Status filter
-	switch filter.Status {
+	// Status filter. A query with its own status term replaces the default.
+	status := filter.Status
+	if status == "" && filter.Query != nil && filter.Query.HasStatus {
+		status = "all"
+	}
+	switch status {
 	case "incomplete", "":
 		conditions = append(conditions, "t.status = 0")
 	case "completed":
@@ -74,17 +97,11 @@ func (db *ThingsDB) ListTasks(filter TaskFilter) ([]models.Task, error) {
 		// "all" - no filter
 	}
 
-	// Today filter based on things.py logic:
-	// 1. Anytime tasks with start dates (start=1, startDate set)
-	// 2. Someday tasks with past start dates (start=2, startDate <= today)
-	// 3. Overdue tasks by deadline (no startDate, deadline <= today, not suppressed)
+	// Today filter
 	if filter.Today {
-		todayPacked := TodayPackedDate()
-		conditions = append(conditions, fmt.Sprintf(`(
-			(t.start = 1 AND t.startDate IS NOT NULL)
-			OR (t.start = 2 AND t.startDate IS NOT NULL AND t.startDate <= %d)
-			OR (t.startDate IS NULL AND t.deadline IS NOT NULL AND t.deadline <= %d AND t.deadlineSuppressionDate IS NULL)
-		)`, todayPacked, todayPacked))
+		today := todayCondition(TodayPackedDate())
+		conditions = append(conditions, today.SQL)
+		params = append(params, today.Args...)
 	}
 
 	// Area filter
@@ -99,6 +116,19 @@ func (db *ThingsDB) ListTasks(filter TaskFilter) ([]models.Task, error) {
 		params = append(params, "%"+filter.Project+"%")
 	}
 
+	// Tag filters. Tags are matched exactly (case-insensitive) by name or
+	// UUID and include tags inherited from the task's project and area.
+	if tags := tagFilter(filter.Tags, filter.AnyTags, filter.NotTags, filter.Subtags); tags != nil {
+		conditions = append(conditions, "("+tags.SQL+")")
+		params = append(params, tags.Args...)
+	}
+
+	// Query language filter
+	if filter.Query != nil {
+		conditions = append(conditions, "("+filter.Query.Where.SQL+")")
+		params = append(params, filter.Query.Where.Args...)
+	}
+
 	// Future repeating tasks filter (startDate is packed date format, not Unix timestamp)
 	if !filter.IncludeFuture {
 		todayPacked := TodayPackedDate()
@@ -111,14 +141,13 @@ func (db *ThingsDB) ListTasks(filter TaskFilter) ([]models.Task, error) {
 
 	query += " GROUP BY t.uuid"
 
-	// Tag filter (HAVING because of GROUP_CONCAT)
-	if filter.Tag != "" {
-		query += " HAVING LOWER(GROUP_CONCAT(tag.title, ', ')) LIKE LOWER(?)"
-		params = append(params, "%"+filter.Tag+"%")
+	if filter.Today {
+		// This Evening follows the rest of Today
+		query += ` ORDER BY COALESCE(t.startBucket, 0), COALESCE(t.todayIndex, 999999), t."index"`
+	} else {
+		query += ` ORDER BY COALESCE(t.todayIndex, 999999), t."index"`
 	}
 
-	query += ` ORDER BY COALESCE(t.todayIndex, 999999), t."index"`
-
 	rows, err := db.conn.Query(query, params...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query tasks: %w", err)
@@ -149,7 +178,10 @@ func (db *ThingsDB) GetTask(uuid string) (*models.Task, error) {
 			h.title as heading_name,
 			GROUP_CONCAT(tag.title, ', ') as tags,
 			CASE WHEN t.rt1_repeatingTemplate IS NOT NULL THEN 1 ELSE 0 END as is_repeating,
-			t.todayIndex
+			t.todayIndex,
+			COALESCE(t.startBucket, 0) as start_bucket,
+			COALESCE(t.checklistItemsCount, 0) as checklist_total,
+			COALESCE(t.openChecklistItemsCount, 0) as checklist_open
 		FROM TMTask t
 		LEFT JOIN TMArea a ON t.area = a.uuid
 		LEFT JOIN TMTask p ON t.project = p.uuid AND p.type = 1
@@ -175,7 +207,7 @@ func (db *ThingsDB) GetTask(uuid string) (*models.Task, error) {
 	}
 
 	if len(tasks) == 0 {
-		return nil, fmt.Errorf("task not found: %s", uuid)
+		return nil, notFound("task", uuid)
 	}
 
 	return &tasks[0], nil
@@ -242,7 +274,7 @@ func (db *ThingsDB) GetProject(uuid string) (*models.Project, error) {
 	}
 
 	if len(projects) == 0 {
-		return nil, fmt.Errorf("project not found: %s", uuid)
+		return nil, notFound("project", uuid)
 	}
 
 	return &projects[0], nil
@@ -269,7 +301,10 @@ func (db *ThingsDB) GetProjectTasks(projectUUID string, includeCompleted bool) (
 			h.title as heading_name,
 			GROUP_CONCAT(tag.title, ', ') as tags,
 			CASE WHEN t.rt1_repeatingTemplate IS NOT NULL THEN 1 ELSE 0 END as is_repeating,
-			t.todayIndex
+			t.todayIndex,
+			COALESCE(t.startBucket, 0) as start_bucket,
+			COALESCE(t.checklistItemsCount, 0) as checklist_total,
+			COALESCE(t.openChecklistItemsCount, 0) as checklist_open
 		FROM TMTask t
 		LEFT JOIN TMArea a ON t.area = a.uuid
 		LEFT JOIN TMTask p ON t.project = p.uuid AND p.type = 1
@@ -347,7 +382,7 @@ func (db *ThingsDB) GetArea(uuid string) (*models.Area, error) {
 	var area models.Area
 	err := db.conn.QueryRow(query, uuid).Scan(&area.UUID, &area.Title, &area.OpenTasks, &area.ActiveProjects)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("area not found: %s", uuid)
+		return nil, notFound("area", uuid)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query area: %w", err)
@@ -409,7 +444,10 @@ func (db *ThingsDB) GetAreaTasks(areaUUID string, includeCompleted bool) ([]mode
 			NULL as heading_name,
 			GROUP_CONCAT(tag.title, ', ') as tags,
 			CASE WHEN t.rt1_repeatingTemplate IS NOT NULL THEN 1 ELSE 0 END as is_repeating,
-			t.todayIndex
+			t.todayIndex,
+			COALESCE(t.startBucket, 0) as start_bucket,
+			COALESCE(t.checklistItemsCount, 0) as checklist_total,
+			COALESCE(t.openChecklistItemsCount, 0) as checklist_open
 		FROM TMTask t
 		LEFT JOIN TMArea a ON t.area = a.uuid
 		LEFT JOIN TMTaskTag tt ON t.uuid = tt.tasks
@@ -439,6 +477,7 @@ func (db *ThingsDB) ListTags() ([]models.Tag, error) {
 			t.uuid,
 			t.title,
 			t.shortcut,
+			t.parent,
 			COUNT(DISTINCT tt.tasks) as task_count
 		FROM TMTag t
 		LEFT JOIN TMTaskTag tt ON t.uuid = tt.tags
@@ -456,7 +495,7 @@ func (db *ThingsDB) ListTags() ([]models.Tag, error) {
 	var tags []models.Tag
 	for rows.Next() {
 		var tag models.Tag
-		if err := rows.Scan(&tag.UUID, &tag.Title, &tag.Shortcut, &tag.TaskCount); err != nil {
+		if err := rows.Scan(&tag.UUID, &tag.Title, &tag.Shortcut, &tag.ParentUUID, &tag.TaskCount); err != nil {
 			return nil, fmt.Errorf("failed to scan tag: %w", err)
 		}
 		tags = append(tags, tag)
@@ -465,6 +504,62 @@ func (db *ThingsDB) ListTags() ([]models.Tag, error) {
 	return tags, rows.Err()
 }
 
+// ListTagTree returns the top-level tags with their descendants nested in
+// Children. A tag whose parent no longer exists is listed at the top level.
+func (db *ThingsDB) ListTagTree() ([]models.Tag, error) {
+	tags, err := db.ListTags()
+	if err != nil {
+		return nil, err
+	}
+	return nestTags(tags), nil
+}
+
+// nestTags arranges a flat tag list into trees, keeping the list's order
+// among siblings
+func nestTags(tags []models.Tag) []models.Tag {
+	known := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		known[tag.UUID] = true
+	}
+
+	children := make(map[string][]int)
+	var roots []int
+	for i, tag := range tags {
+		parent := tag.ParentUUID.String
+		if tag.ParentUUID.Valid && known[parent] && parent != tag.UUID {
+			children[parent] = append(children[parent], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	placed := make(map[string]bool, len(tags))
+	var build func(i int) models.Tag
+	build = func(i int) models.Tag {
+		tag := tags[i]
+		placed[tag.UUID] = true
+		for _, c := range children[tag.UUID] {
+			if !placed[tags[c].UUID] {
+				tag.Children = append(tag.Children, build(c))
+			}
+		}
+		return tag
+	}
+
+	var out []models.Tag
+	for _, i := range roots {
+		out = append(out, build(i))
+	}
+	// Tags whose parents form a cycle never hang off a root; list them at the
+	// top level rather than dropping them
+	for i, tag := range tags {
+		if !placed[tag.UUID] {
+			out = append(out, build(i))
+		}
+	}
+	return out
+}
+
 // Search searches for tasks by title and optionally notes
 func (db *ThingsDB) Search(term string, includeNotes, includeFuture bool) ([]models.Task, error) {
 	query := `
@@ -486,7 +581,10 @@ func (db *ThingsDB) Search(term string, includeNotes, includeFuture bool) ([]mod
 			h.title as heading_name,
 			GROUP_CONCAT(tag.title, ', ') as tags,
 			CASE WHEN t.rt1_repeatingTemplate IS NOT NULL THEN 1 ELSE 0 END as is_repeating,
-			t.todayIndex
+			t.todayIndex,
+			COALESCE(t.startBucket, 0) as start_bucket,
+			COALESCE(t.checklistItemsCount, 0) as checklist_total,
+			COALESCE(t.openChecklistItemsCount, 0) as checklist_open
 		FROM TMTask t
 		LEFT JOIN TMArea a ON t.area = a.uuid
 		LEFT JOIN TMTask p ON t.project = p.uuid AND p.type = 1 AND p.trashed = 0
@@ -549,7 +647,10 @@ func (db *ThingsDB) GetInboxTasks() ([]models.Task, error) {
 			h.title as heading_name,
 			GROUP_CONCAT(tag.title, ', ') as tags,
 			CASE WHEN t.rt1_repeatingTemplate IS NOT NULL THEN 1 ELSE 0 END as is_repeating,
-			t.todayIndex
+			t.todayIndex,
+			COALESCE(t.startBucket, 0) as start_bucket,
+			COALESCE(t.checklistItemsCount, 0) as checklist_total,
+			COALESCE(t.openChecklistItemsCount, 0) as checklist_open
 		FROM TMTask t
 		LEFT JOIN TMArea a ON t.area = a.uuid
 		LEFT JOIN TMTask p ON t.project = p.uuid AND p.type = 1
@@ -600,7 +701,10 @@ func (db *ThingsDB) GetAnytimeTasks() ([]models.Task, error) {
 			h.title as heading_name,
 			GROUP_CONCAT(tag.title, ', ') as tags,
 			CASE WHEN t.rt1_repeatingTemplate IS NOT NULL THEN 1 ELSE 0 END as is_repeating,
-			t.todayIndex
+			t.todayIndex,
+			COALESCE(t.startBucket, 0) as start_bucket,
+			COALESCE(t.checklistItemsCount, 0) as checklist_total,
+			COALESCE(t.openChecklistItemsCount, 0) as checklist_open
 		FROM TMTask t
 		LEFT JOIN TMArea a ON t.area = a.uuid
 		LEFT JOIN TMTask p ON t.project = p.uuid AND p.type = 1
@@ -652,7 +756,10 @@ func (db *ThingsDB) GetDeadlines(daysAhead int) ([]models.Task, error) {
 			h.title as heading_name,
 			GROUP_CONCAT(tag.title, ', ') as tags,
 			CASE WHEN t.rt1_repeatingTemplate IS NOT NULL THEN 1 ELSE 0 END as is_repeating,
-			t.todayIndex
+			t.todayIndex,
+			COALESCE(t.startBucket, 0) as start_bucket,
+			COALESCE(t.checklistItemsCount, 0) as checklist_total,
+			COALESCE(t.openChecklistItemsCount, 0) as checklist_open
 		FROM TMTask t
 		LEFT JOIN TMArea a ON t.area = a.uuid
 		LEFT JOIN TMTask p ON t.project = p.uuid AND p.type = 1
@@ -700,7 +807,10 @@ func (db *ThingsDB) GetTasksByTag(tagName string) ([]models.Task, error) {
 			h.title as heading_name,
 			GROUP_CONCAT(tag.title, ', ') as tags,
 			CASE WHEN t.rt1_repeatingTemplate IS NOT NULL THEN 1 ELSE 0 END as is_repeating,
-			t.todayIndex
+			t.todayIndex,
+			COALESCE(t.startBucket, 0) as start_bucket,
+			COALESCE(t.checklistItemsCount, 0) as checklist_total,
+			COALESCE(t.openChecklistItemsCount, 0) as checklist_open
 		FROM TMTask t
 		LEFT JOIN TMArea a ON t.area = a.uuid
 		LEFT JOIN TMTask p ON t.project = p.uuid AND p.type = 1
@@ -752,7 +862,10 @@ func (db *ThingsDB) GetUpcomingTasks() ([]models.Task, error) {
 			h.title as heading_name,
 			GROUP_CONCAT(tag.title, ', ') as tags,
 			CASE WHEN t.rt1_repeatingTemplate IS NOT NULL OR EXISTS(SELECT 1 FROM TMTask i WHERE i.rt1_repeatingTemplate = t.uuid) THEN 1 ELSE 0 END as is_repeating,
-			t.todayIndex
+			t.todayIndex,
+			COALESCE(t.startBucket, 0) as start_bucket,
+			COALESCE(t.checklistItemsCount, 0) as checklist_total,
+			COALESCE(t.openChecklistItemsCount, 0) as checklist_open
 		FROM TMTask t
 		LEFT JOIN TMArea a ON t.area = a.uuid
 		LEFT JOIN TMTask p ON t.project = p.uuid AND p.type = 1
@@ -810,7 +923,10 @@ func (db *ThingsDB) GetSomedayTasks() ([]models.Task, error) {
 			h.title as heading_name,
 			GROUP_CONCAT(tag.title, ', ') as tags,
 			CASE WHEN t.rt1_repeatingTemplate IS NOT NULL THEN 1 ELSE 0 END as is_repeating,
-			t.todayIndex
+			t.todayIndex,
+			COALESCE(t.startBucket, 0) as start_bucket,
+			COALESCE(t.checklistItemsCount, 0) as checklist_total,
+			COALESCE(t.openChecklistItemsCount, 0) as checklist_open
 		FROM TMTask t
 		LEFT JOIN TMArea a ON t.area = a.uuid
 		LEFT JOIN TMTask p ON t.project = p.uuid AND p.type = 1
@@ -874,7 +990,10 @@ func (db *ThingsDB) GetLogbook(limit int) ([]models.Task, error) {
 			h.title as heading_name,
 			GROUP_CONCAT(tag.title, ', ') as tags,
 			CASE WHEN t.rt1_repeatingTemplate IS NOT NULL THEN 1 ELSE 0 END as is_repeating,
-			t.todayIndex
+			t.todayIndex,
+			COALESCE(t.startBucket, 0) as start_bucket,
+			COALESCE(t.checklistItemsCount, 0) as checklist_total,
+			COALESCE(t.openChecklistItemsCount, 0) as checklist_open
 		FROM TMTask t
 		LEFT JOIN TMArea a ON t.area = a.uuid
 		LEFT JOIN TMTask p ON t.project = p.uuid AND p.type = 1
@@ -909,7 +1028,7 @@ func (db *ThingsDB) ResolveTaskUUID(prefix string) (string, error) {
 		var exists int
 		err := db.conn.QueryRow(`SELECT 1 FROM TMTask WHERE uuid = ? AND type = 0`, prefix).Scan(&exists)
 		if err == sql.ErrNoRows {
-			return "", fmt.Errorf("task not found: %s", prefix)
+			return "", notFound("task", prefix)
 		}
 		if err != nil {
 			return "", fmt.Errorf("failed to query task: %w", err)
@@ -917,7 +1036,7 @@ func (db *ThingsDB) ResolveTaskUUID(prefix string) (string, error) {
 		return prefix, nil
 	}
 
-	query := `SELECT uuid FROM TMTask WHERE uuid LIKE ? || '%' AND type = 0 LIMIT 2`
+	query := `SELECT uuid FROM TMTask WHERE uuid LIKE ? || '%' AND type = 0`
 	return resolvePrefix(db, query, prefix, "task")
 }
 
@@ -928,7 +1047,7 @@ func (db *ThingsDB) ResolveProjectUUID(prefix string) (string, error) {
 		var exists int
 		err := db.conn.QueryRow(`SELECT 1 FROM TMTask WHERE uuid = ? AND type = 1 AND trashed = 0`, prefix).Scan(&exists)
 		if err == sql.ErrNoRows {
-			return "", fmt.Errorf("project not found: %s", prefix)
+			return "", notFound("project", prefix)
 		}
 		if err != nil {
 			return "", fmt.Errorf("failed to query project: %w", err)
@@ -936,7 +1055,7 @@ func (db *ThingsDB) ResolveProjectUUID(prefix string) (string, error) {
 		return prefix, nil
 	}
 
-	query := `SELECT uuid FROM TMTask WHERE uuid LIKE ? || '%' AND type = 1 AND trashed = 0 LIMIT 2`
+	query := `SELECT uuid FROM TMTask WHERE uuid LIKE ? || '%' AND type = 1 AND trashed = 0`
 	return resolvePrefix(db, query, prefix, "project")
 }
 
@@ -947,7 +1066,7 @@ func (db *ThingsDB) ResolveAreaUUID(prefix string) (string, error) {
 		var exists int
 		err := db.conn.QueryRow(`SELECT 1 FROM TMArea WHERE uuid = ?`, prefix).Scan(&exists)
 		if err == sql.ErrNoRows {
-			return "", fmt.Errorf("area not found: %s", prefix)
+			return "", notFound("area", prefix)
 		}
 		if err != nil {
 			return "", fmt.Errorf("failed to query area: %w", err)
@@ -955,7 +1074,7 @@ func (db *ThingsDB) ResolveAreaUUID(prefix string) (string, error) {
 		return prefix, nil
 	}
 
-	query := `SELECT uuid FROM TMArea WHERE uuid LIKE ? || '%' LIMIT 2`
+	query := `SELECT uuid FROM TMArea WHERE uuid LIKE ? || '%'`
 	return resolvePrefix(db, query, prefix, "area")
 }
 
@@ -966,7 +1085,7 @@ func (db *ThingsDB) ResolveTagUUID(prefix string) (string, error) {
 		var exists int
 		err := db.conn.QueryRow(`SELECT 1 FROM TMTag WHERE uuid = ?`, prefix).Scan(&exists)
 		if err == sql.ErrNoRows {
-			return "", fmt.Errorf("tag not found: %s", prefix)
+			return "", notFound("tag", prefix)
 		}
 		if err != nil {
 			return "", fmt.Errorf("failed to query tag: %w", err)
@@ -974,7 +1093,7 @@ func (db *ThingsDB) ResolveTagUUID(prefix string) (string, error) {
 		return prefix, nil
 	}
 
-	query := `SELECT uuid FROM TMTag WHERE uuid LIKE ? || '%' LIMIT 2`
+	query := `SELECT uuid FROM TMTag WHERE uuid LIKE ? || '%'`
 	return resolvePrefix(db, query, prefix, "tag")
 }
 
@@ -985,7 +1104,7 @@ func (db *ThingsDB) ResolveHeadingUUID(prefix string) (string, error) {
 		var exists int
 		err := db.conn.QueryRow(`SELECT 1 FROM TMTask WHERE uuid = ? AND type = 2`, prefix).Scan(&exists)
 		if err == sql.ErrNoRows {
-			return "", fmt.Errorf("heading not found: %s", prefix)
+			return "", notFound("heading", prefix)
 		}
 		if err != nil {
 			return "", fmt.Errorf("failed to query heading: %w", err)
@@ -993,20 +1112,22 @@ func (db *ThingsDB) ResolveHeadingUUID(prefix string) (string, error) {
 		return prefix, nil
 	}
 
-	query := `SELECT uuid FROM TMTask WHERE uuid LIKE ? || '%' AND type = 2 LIMIT 2`
+	query := `SELECT uuid FROM TMTask WHERE uuid LIKE ? || '%' AND type = 2`
 	return resolvePrefix(db, query, prefix, "heading")
 }
 
 // resolvePrefix is a shared helper for resolving a short UUID prefix to a full UUID.
-// The query must select UUIDs with a single ? placeholder for the prefix.
+// The query must select UUIDs with a single ? placeholder for the prefix; a LIMIT
+// is appended so an ambiguous prefix reports at most maxCandidates matches.
 func resolvePrefix(db *ThingsDB, query, prefix, entityType string) (string, error) {
 	if prefix == "" {
-		return "", fmt.Errorf("%s prefix cannot be empty", entityType)
+		return "", fmt.Errorf("%w: %s prefix cannot be empty", ErrInvalidPrefix, entityType)
 	}
 	if !prefixPattern.MatchString(prefix) {
-		return "", fmt.Errorf("invalid %s prefix: %s (must be alphanumeric)", entityType, prefix)
+		return "", fmt.Errorf("%w for %s: %s (must be alphanumeric)", ErrInvalidPrefix, entityType, prefix)
 	}
 
+	query += fmt.Sprintf(" LIMIT %d", maxCandidates+1)
 	rows, err := db.conn.Query(query, prefix)
 	if err != nil {
 		return "", fmt.Errorf("failed to query %s: %w", entityType, err)
@@ -1026,21 +1147,24 @@ func resolvePrefix(db *ThingsDB, query, prefix, entityType string) (string, erro
 	}
 
 	if len(uuids) == 0 {
-		return "", fmt.Errorf("%s not found: %s", entityType, prefix)
+		return "", notFound(entityType, prefix)
 	}
 	if len(uuids) > 1 {
-		return "", fmt.Errorf("ambiguous %s prefix '%s' matches %d %ss", entityType, prefix, len(uuids), entityType)
+		return "", newAmbiguousError(entityType, prefix, false, uuids)
 	}
 	return uuids[0], nil
 }
 
-// GetProjectHeadings returns headings belonging to a project
+// GetProjectHeadings returns headings belonging to a project, including
+// archived ones
 func (db *ThingsDB) GetProjectHeadings(projectUUID string) ([]models.Heading, error) {
 	query := `
 		SELECT
 			uuid,
 			title,
-			"index"
+			"index",
+			status != 0,
+			project
 		FROM TMTask
 		WHERE type = 2 AND project = ? AND trashed = 0
 		ORDER BY "index"
@@ -1055,7 +1179,7 @@ func (db *ThingsDB) GetProjectHeadings(projectUUID string) ([]models.Heading, er
 	var headings []models.Heading
 	for rows.Next() {
 		var h models.Heading
-		if err := rows.Scan(&h.UUID, &h.Title, &h.Index); err != nil {
+		if err := rows.Scan(&h.UUID, &h.Title, &h.Index, &h.Archived, &h.ProjectUUID); err != nil {
 			return nil, fmt.Errorf("failed to scan heading: %w", err)
 		}
 		headings = append(headings, h)
@@ -1064,6 +1188,30 @@ func (db *ThingsDB) GetProjectHeadings(projectUUID string) ([]models.Heading, er
 	return headings, rows.Err()
 }
 
+// GetHeading returns a single heading by UUID
+func (db *ThingsDB) GetHeading(uuid string) (*models.Heading, error) {
+	query := `
+		SELECT
+			uuid,
+			title,
+			"index",
+			status != 0,
+			COALESCE(project, '')
+		FROM TMTask
+		WHERE uuid = ? AND type = 2 AND trashed = 0
+	`
+
+	var h models.Heading
+	err := db.conn.QueryRow(query, uuid).Scan(&h.UUID, &h.Title, &h.Index, &h.Archived, &h.ProjectUUID)
+	if err == sql.ErrNoRows {
+		return nil, notFound("heading", uuid)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query heading: %w", err)
+	}
+	return &h, nil
+}
+
 // GetTag returns a single tag by UUID
 func (db *ThingsDB) GetTag(uuid string) (*models.Tag, error) {
 	query := `
@@ -1071,6 +1219,7 @@ func (db *ThingsDB) GetTag(uuid string) (*models.Tag, error) {
 			t.uuid,
 			t.title,
 			t.shortcut,
+			t.parent,
 			COUNT(DISTINCT tt.tasks) as task_count
 		FROM TMTag t
 		LEFT JOIN TMTaskTag tt ON t.uuid = tt.tags
@@ -1080,9 +1229,9 @@ func (db *ThingsDB) GetTag(uuid string) (*models.Tag, error) {
 	`
 
 	var tag models.Tag
-	err := db.conn.QueryRow(query, uuid).Scan(&tag.UUID, &tag.Title, &tag.Shortcut, &tag.TaskCount)
+	err := db.conn.QueryRow(query, uuid).Scan(&tag.UUID, &tag.Title, &tag.Shortcut, &tag.ParentUUID, &tag.TaskCount)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("tag not found: %s", uuid)
+		return nil, notFound("tag", uuid)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query tag: %w", err)
@@ -1117,10 +1266,35 @@ func (db *ThingsDB) GetTaskChecklistItems(taskUUID string) ([]models.ChecklistIt
 		if err := rows.Scan(&item.UUID, &item.Title, &status, &item.Index); err != nil {
 			return nil, fmt.Errorf("failed to scan checklist item: %w", err)
 		}
-		// status: 0=incomplete, 3=completed (same as task status)
+		// status: 0=incomplete, 2=canceled, 3=completed (same as task status)
 		item.Completed = status == 3
+		item.Canceled = status == 2
 		items = append(items, item)
 	}
 
 	return items, rows.Err()
 }
+
+// GetItemArea returns the UUID of the area a task, project, or heading lives
+// in, following project and heading links. It returns "" for items outside
+// any area (e.g. the Inbox) and ErrNotFound if the UUID is unknown.
+func (db *ThingsDB) GetItemArea(uuid string) (string, error) {
+	query := `
+		SELECT COALESCE(t.area, p.area, hp.area, '')
+		FROM TMTask t
+		LEFT JOIN TMTask p ON t.project = p.uuid
+		LEFT JOIN TMTask h ON t.heading = h.uuid
+		LEFT JOIN TMTask hp ON h.project = hp.uuid
+		WHERE t.uuid = ?
+	`
+
+	var area string
+	err := db.conn.QueryRow(query, uuid).Scan(&area)
+	if err == sql.ErrNoRows {
+		return "", notFound("item", uuid)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query item area: %w", err)
+	}
+	return area, nil
+}
@@ -0,0 +1,607 @@
+package db_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"thingies/internal/db"
+	"thingies/internal/db/dbtest"
+	"thingies/internal/models"
+)
+
+// titles returns the sorted titles of tasks
+func titles(tasks []models.Task) []string {
+	out := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		out = append(out, t.Title)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func assertTitles(t *testing.T, got []models.Task, want ...string) {
+	t.Helper()
+	sort.Strings(want)
+	if g := titles(got); !slices.Equal(g, want) {
+		t.Errorf("got %q, want %q", g, want)
+	}
+}
+
+func TestListTasksToday(t *testing.T) {
+	b := dbtest.New(t)
+	yesterday, tomorrow := dbtest.DaysFromToday(-1), dbtest.DaysFromToday(1)
+
+	b.Task("today").Today()
+	b.Task("scheduled yesterday").Scheduled(yesterday)
+	b.Task("scheduled tomorrow").Scheduled(tomorrow)
+	b.Task("anytime").Anytime()
+	b.Task("someday").Someday()
+	b.Task("overdue").Deadline(yesterday)
+	b.Task("overdue suppressed").Deadline(yesterday).SuppressDeadline()
+	b.Task("due tomorrow").Deadline(tomorrow)
+	b.Task("completed today").Today().Completed()
+	b.Task("trashed today").Today().Trashed()
+
+	dead := b.Project("Dead project").Trashed()
+	b.Task("in trashed project").InProject(dead).Today()
+	deadHeading := b.Heading(dead, "Old heading")
+	b.Task("under heading of trashed project").UnderHeading(deadHeading).Today()
+
+	live := b.Project("Live project")
+	b.Task("in live project").InProject(live).Today()
+	b.Task("under live heading").UnderHeading(b.Heading(live, "Now")).Today()
+
+	tasks, err := b.Open().ListTasks(db.TaskFilter{Today: true})
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	assertTitles(t, tasks,
+		"today", "scheduled yesterday", "overdue", "in live project", "under live heading")
+}
+
+func TestListTasksTodayOrder(t *testing.T) {
+	b := dbtest.New(t)
+	b.Task("second").Today().TodayIndex(2)
+	b.Task("first").Today().TodayIndex(1)
+
+	tasks, err := b.Open().ListTasks(db.TaskFilter{Today: true})
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].Title != "first" || tasks[1].Title != "second" {
+		t.Errorf("expected todayIndex order, got %q", titles(tasks))
+	}
+}
+
+func TestListTasksEvening(t *testing.T) {
+	b := dbtest.New(t)
+	b.Task("dinner").Evening().TodayIndex(1)
+	b.Task("standup").Today().TodayIndex(2)
+	b.Task("read").Evening().TodayIndex(3)
+	thingsDB := b.Open()
+
+	tasks, err := thingsDB.ListTasks(db.TaskFilter{Today: true})
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	var got []string
+	for _, task := range tasks {
+		got = append(got, fmt.Sprintf("%s:%v", task.Title, task.Evening))
+	}
+	if want := []string{"standup:false", "dinner:true", "read:true"}; !slices.Equal(got, want) {
+		t.Errorf("expected This Evening after Today, got %q, want %q", got, want)
+	}
+
+	q, err := db.ParseQuery("list:evening")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks, err = thingsDB.ListTasks(db.TaskFilter{Query: q})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertTitles(t, tasks, "dinner", "read")
+}
+
+func TestListTasksRepeatingAndTags(t *testing.T) {
+	b := dbtest.New(t)
+	template := b.Task("Water plants").RepeatingTemplate(nil, dbtest.DaysFromToday(7))
+	b.Task("Water plants").InstanceOf(template).Scheduled(dbtest.DaysFromToday(0))
+	b.Task("Water plants").InstanceOf(template).Scheduled(dbtest.DaysFromToday(7))
+	b.Task("Tagged").Anytime().Tags("errands", "home")
+
+	thingsDB := b.Open()
+
+	tasks, err := thingsDB.ListTasks(db.TaskFilter{})
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	// The template itself has no startDate, so only the future instance is hidden
+	assertTitles(t, tasks, "Tagged", "Water plants", "Water plants")
+
+	tasks, err = thingsDB.ListTasks(db.TaskFilter{IncludeFuture: true})
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	if len(tasks) != 4 {
+		t.Errorf("expected future instance with IncludeFuture, got %q", titles(tasks))
+	}
+
+	tasks, err = thingsDB.ListTasks(db.TaskFilter{Tags: []string{"ERRANDS"}})
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	assertTitles(t, tasks, "Tagged")
+	if tasks[0].Tags.String != "errands, home" {
+		t.Errorf("expected both tags, got %q", tasks[0].Tags.String)
+	}
+}
+
+func TestGetUpcomingTasks(t *testing.T) {
+	b := dbtest.New(t)
+	b.Task("next week").Scheduled(dbtest.DaysFromToday(7))
+	b.Task("tomorrow").Scheduled(dbtest.DaysFromToday(1))
+	b.Task("yesterday").Scheduled(dbtest.DaysFromToday(-1))
+	b.Task("someday").Someday()
+	b.Task("done tomorrow").Scheduled(dbtest.DaysFromToday(1)).Completed()
+	b.Task("monthly review").RepeatingTemplate([]byte("rule"), dbtest.DaysFromToday(3))
+
+	stalled := b.Task("stalled template").RepeatingTemplate(nil, dbtest.DaysFromToday(-3))
+	b.Task("stalled template").InstanceOf(stalled).Today()
+
+	b.Task("old template").RepeatingTemplate(nil, dbtest.DaysFromToday(-3))
+
+	tasks, err := b.Open().GetUpcomingTasks()
+	if err != nil {
+		t.Fatalf("GetUpcomingTasks: %v", err)
+	}
+
+	var got []string
+	for _, task := range tasks {
+		got = append(got, task.Title)
+	}
+	// Dated rows sort by date; the template kept alive by an open instance has none
+	want := []string{"stalled template", "tomorrow", "monthly review", "next week"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	if !tasks[0].IsRepeating {
+		t.Error("expected template with an open instance to be marked repeating")
+	}
+	review := tasks[2]
+	if want := dbtest.DaysFromToday(3).Format("2006-01-02"); review.Scheduled.Time.Format("2006-01-02") != want {
+		t.Errorf("expected template scheduled for next instance %s, got %s", want, review.Scheduled.Time.Format("2006-01-02"))
+	}
+}
+
+func TestGetUpcomingTasksSkipsTrashedProjects(t *testing.T) {
+	b := dbtest.New(t)
+	dead := b.Project("Dead").Trashed()
+	b.Task("in dead project").InProject(dead).Scheduled(dbtest.DaysFromToday(2))
+	b.Task("under dead heading").UnderHeading(b.Heading(dead, "H")).Scheduled(dbtest.DaysFromToday(2))
+
+	tasks, err := b.Open().GetUpcomingTasks()
+	if err != nil {
+		t.Fatalf("GetUpcomingTasks: %v", err)
+	}
+	assertTitles(t, tasks)
+}
+
+func TestSearch(t *testing.T) {
+	b := dbtest.New(t)
+	work := b.Area("Work")
+	launch := b.Project("Launch plan").InArea(work)
+	b.Task("Plan offsite").UnderHeading(b.Heading(launch, "Prep")).Tags("work")
+	b.Task("Groceries").Notes("remember the PLAN b snacks")
+	b.Task("Trashed plan").Trashed()
+	b.Task("Old plan").Completed()
+	b.Task("Plan in dead project").InProject(b.Project("Dead").Trashed())
+	template := b.Task("Plan week").RepeatingTemplate(nil, dbtest.DaysFromToday(7))
+	b.Task("Plan week").InstanceOf(template).Scheduled(dbtest.DaysFromToday(7))
+
+	thingsDB := b.Open()
+
+	tasks, err := thingsDB.Search("plan", false, false)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	assertTitles(t, tasks, "Launch plan", "Old plan", "Plan offsite", "Plan week")
+
+	for _, task := range tasks {
+		if task.Title == "Plan offsite" {
+			if task.AreaName.String != "Work" || task.ProjectName.String != "Launch plan" || task.HeadingName.String != "Prep" {
+				t.Errorf("expected Work > Launch plan > Prep, got %q > %q > %q",
+					task.AreaName.String, task.ProjectName.String, task.HeadingName.String)
+			}
+		}
+	}
+	if tasks[len(tasks)-1].Type != models.TypeProject {
+		t.Errorf("expected projects to sort after tasks, got %q last", tasks[len(tasks)-1].Title)
+	}
+
+	tasks, err = thingsDB.Search("plan", true, true)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	assertTitles(t, tasks, "Groceries", "Launch plan", "Old plan", "Plan offsite", "Plan week", "Plan week")
+}
+
+func TestResolveTaskUUID(t *testing.T) {
+	b := dbtest.New(t)
+	b.Task("alpha").WithUUID("Abc1000000000000000000")
+	b.Task("beta").WithUUID("Abc2000000000000000000")
+	b.Task("gamma").WithUUID("Xyz0000000000000000000")
+	b.Project("not a task").WithUUID("Prj0000000000000000000")
+	thingsDB := b.Open()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{"full UUID", "Xyz0000000000000000000", "Xyz0000000000000000000", ""},
+		{"unique prefix", "Abc1", "Abc1000000000000000000", ""},
+		{"prefix ignores case (SQLite LIKE)", "xyz", "Xyz0000000000000000000", ""},
+		{"ambiguous prefix", "Abc", "", "ambiguous task prefix 'Abc'"},
+		{"unknown prefix", "Nope", "", "task not found: Nope"},
+		{"wrong type", "Prj0", "", "not found"},
+		{"unknown full UUID", "Zzz0000000000000000000", "", "task not found"},
+		{"invalid characters", "Abc%", "", "must be alphanumeric"},
+		{"empty prefix", "", "", "cannot be empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := thingsDB.ResolveTaskUUID(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v (%q)", tt.wantErr, err, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveProjectID(t *testing.T) {
+	b := dbtest.New(t)
+	b.Project("Launch").WithUUID("Lau1000000000000000000")
+	b.Project("Twin").WithUUID("Twn1000000000000000000")
+	b.Project("Twin").WithUUID("Twn2000000000000000000")
+	b.Project("Gone").WithUUID("Gon1000000000000000000").Trashed()
+	thingsDB := b.Open()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{"full UUID", "Lau1000000000000000000", "Lau1000000000000000000", ""},
+		{"prefix", "Lau", "Lau1000000000000000000", ""},
+		{"name", "Launch", "Lau1000000000000000000", ""},
+		{"ambiguous name", "Twin", "", "multiple projects match 'Twin'"},
+		{"ambiguous prefix is not a name lookup", "Twn", "", "ambiguous project prefix"},
+		{"trashed UUID", "Gon1000000000000000000", "", "project not found"},
+		{"trashed name", "Gone", "", "project not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := thingsDB.ResolveProjectID(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v (%q)", tt.wantErr, err, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveTagID(t *testing.T) {
+	b := dbtest.New(t)
+	errands := b.Tag("Errands")
+	b.Tag("Home")
+	urgent := b.Tag("High priority")
+	thingsDB := b.Open()
+
+	for _, input := range []string{errands.UUID, "Errands"} {
+		if got, err := thingsDB.ResolveTagID(input); err != nil || got != errands.UUID {
+			t.Errorf("ResolveTagID(%q) = %q, %v; want %q", input, got, err, errands.UUID)
+		}
+	}
+	if got, err := thingsDB.ResolveTagID("High priority"); err != nil || got != urgent.UUID {
+		t.Errorf("ResolveTagID(name with a space) = %q, %v; want %q", got, err, urgent.UUID)
+	}
+	if _, err := thingsDB.ResolveTagID("TAGS"); err == nil || !strings.Contains(err.Error(), "ambiguous tag prefix") {
+		t.Errorf("expected an ambiguous prefix error, got %v", err)
+	}
+	if _, err := thingsDB.ResolveTagID("Nope"); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestResolveMoveTarget(t *testing.T) {
+	b := dbtest.New(t)
+	b.Area("Work").WithUUID("Work000000000000000000")
+	b.Area("Client Work").WithUUID("Client0000000000000000")
+	launch := b.Project("Launch").WithUUID("Lau1000000000000000000")
+	other := b.Project("Other").WithUUID("Oth1000000000000000000")
+	b.Project("Q1 Launch").WithUUID("Q1La000000000000000000")
+	b.Heading(launch, "Prep").WithUUID("Prep000000000000000000")
+	b.Heading(other, "Misc").WithUUID("Misc000000000000000000")
+	thingsDB := b.Open()
+
+	tests := []struct {
+		name                   string
+		inbox                  bool
+		project, area, heading string
+		want                   db.MoveTarget
+		wantErr                error
+	}{
+		{name: "inbox", inbox: true, want: db.MoveTarget{Inbox: true}},
+		{name: "area by name", area: "Work", want: db.MoveTarget{Area: "Work000000000000000000"}},
+		{name: "area name with a space", area: "Client Work", want: db.MoveTarget{Area: "Client0000000000000000"}},
+		{name: "project by name", project: "Launch", want: db.MoveTarget{Project: "Lau1000000000000000000"}},
+		{name: "project name with a space", project: "Q1 Launch", want: db.MoveTarget{Project: "Q1La000000000000000000"}},
+		{name: "heading implies project", heading: "Prep", want: db.MoveTarget{Project: "Lau1000000000000000000", Heading: "Prep000000000000000000"}},
+		{name: "heading in project", project: "Lau", heading: "Prep", want: db.MoveTarget{Project: "Lau1000000000000000000", Heading: "Prep000000000000000000"}},
+		{name: "heading in other project", project: "Launch", heading: "Misc", wantErr: db.ErrInvalidMove},
+		{name: "no destination", wantErr: db.ErrInvalidMove},
+		{name: "two destinations", inbox: true, area: "Work", wantErr: db.ErrInvalidMove},
+		{name: "unknown area", area: "Nowhere", wantErr: db.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := thingsDB.ResolveMoveTarget(tt.inbox, tt.project, tt.area, tt.heading)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveHeadingID(t *testing.T) {
+	b := dbtest.New(t)
+	launch := b.Project("Launch").WithUUID("Lau1000000000000000000")
+	other := b.Project("Other").WithUUID("Oth1000000000000000000")
+	b.Heading(launch, "Prep").WithUUID("Prep000000000000000000")
+	b.Heading(launch, "Wrap up").WithUUID("Wrap000000000000000000").Completed()
+	b.Heading(other, "Prep").WithUUID("Oprp000000000000000000")
+	thingsDB := b.Open()
+
+	tests := []struct {
+		name, project, ref string
+		want               string
+		wantErr            error
+	}{
+		{name: "prefix", ref: "Prep", want: "Prep000000000000000000"},
+		{name: "prefix in project", project: launch.UUID, ref: "Wrap", want: "Wrap000000000000000000"},
+		{name: "name in project", project: other.UUID, ref: "Prep", want: "Oprp000000000000000000"},
+		{name: "name with a space", project: launch.UUID, ref: "Wrap up", want: "Wrap000000000000000000"},
+		{name: "name without project", ref: "Wrap up", wantErr: db.ErrInvalidPrefix},
+		{name: "heading of another project", project: other.UUID, ref: "Wrap", wantErr: db.ErrNotFound},
+		{name: "unknown name", project: launch.UUID, ref: "Nope", wantErr: db.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := thingsDB.ResolveHeadingID(tt.project, tt.ref)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("got %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	headings, err := thingsDB.GetProjectHeadings(launch.UUID)
+	if err != nil {
+		t.Fatalf("GetProjectHeadings: %v", err)
+	}
+	if len(headings) != 2 || headings[0].Archived || !headings[1].Archived || headings[1].ProjectUUID != launch.UUID {
+		t.Errorf("expected Prep and archived Wrap up, got %+v", headings)
+	}
+	if _, err := thingsDB.GetHeading(launch.UUID); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("GetHeading(project) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestProjectCountsIncludeHeadings(t *testing.T) {
+	b := dbtest.New(t)
+	project := b.Project("Launch")
+	b.Task("direct").InProject(project)
+	b.Task("done").InProject(project).Completed()
+	b.Task("under heading").UnderHeading(b.Heading(project, "Prep")).Checklist("a").CheckedItems("b")
+	b.Task("trashed").InProject(project).Trashed()
+
+	thingsDB := b.Open()
+	projects, err := thingsDB.ListProjects(false)
+	if err != nil {
+		t.Fatalf("ListProjects: %v", err)
+	}
+	if len(projects) != 1 || projects[0].OpenTasks != 2 || projects[0].TotalTasks != 3 {
+		t.Fatalf("expected Launch with 2/3 tasks, got %+v", projects)
+	}
+
+	tasks, err := thingsDB.GetProjectTasks(project.UUID, false)
+	if err != nil {
+		t.Fatalf("GetProjectTasks: %v", err)
+	}
+	assertTitles(t, tasks, "direct", "under heading")
+}
+
+func TestListTasksTagFilters(t *testing.T) {
+	b := dbtest.New(t)
+	office := b.Area("Office").Tags("work")
+	launch := b.Project("Launch").Tags("urgent")
+	prep := b.Heading(launch, "Prep")
+	ops := b.Project("Ops").InArea(office)
+
+	b.Task("revise essay").Tags("homework")
+	b.Task("fix sink").Tags("home", "urgent")
+	b.Task("water plants").Tags("Home")
+	b.Task("write spec").InProject(launch)
+	b.Task("book venue").UnderHeading(prep).Tags("waiting")
+	b.Task("rotate keys").InProject(ops)
+	b.Task("file expenses").InArea(office).Tags("urgent")
+	b.Task("untagged")
+	thingsDB := b.Open()
+
+	tests := []struct {
+		name   string
+		filter db.TaskFilter
+		want   []string
+	}{
+		{"exact, not substring", db.TaskFilter{Tags: []string{"home"}}, []string{"fix sink", "water plants"}},
+		{"inherited from project and heading's project", db.TaskFilter{Tags: []string{"urgent"}},
+			[]string{"fix sink", "write spec", "book venue", "file expenses"}},
+		{"inherited from area and project's area", db.TaskFilter{Tags: []string{"work"}}, []string{"rotate keys", "file expenses"}},
+		{"all of", db.TaskFilter{Tags: []string{"urgent", "work"}}, []string{"file expenses"}},
+		{"any of", db.TaskFilter{AnyTags: []string{"homework", "waiting"}}, []string{"revise essay", "book venue"}},
+		{"none of", db.TaskFilter{Tags: []string{"urgent"}, NotTags: []string{"waiting", "home"}}, []string{"write spec", "file expenses"}},
+		{"combined", db.TaskFilter{AnyTags: []string{"home", "work"}, NotTags: []string{"urgent"}}, []string{"water plants", "rotate keys"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, err := thingsDB.ListTasks(tt.filter)
+			if err != nil {
+				t.Fatalf("ListTasks: %v", err)
+			}
+			assertTitles(t, tasks, tt.want...)
+		})
+	}
+
+	q, err := db.ParseQuery("tag:none")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := thingsDB.ListTasks(db.TaskFilter{Query: q})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertTitles(t, tasks, "untagged")
+}
+
+func TestListTasksSubtags(t *testing.T) {
+	b := dbtest.New(t)
+	work := b.Tag("work")
+	meetings := b.Tag("meetings").Parent(work)
+	b.Tag("1:1").Parent(meetings)
+	office := b.Area("Office").Tags("meetings")
+
+	b.Task("ship release").Tags("work")
+	b.Task("prep agenda").Tags("meetings")
+	b.Task("career chat").Tags("1:1")
+	b.Task("plan offsite").InArea(office)
+	b.Task("mow lawn").Tags("home")
+	thingsDB := b.Open()
+
+	tests := []struct {
+		name   string
+		filter db.TaskFilter
+		want   []string
+	}{
+		{"exact by default", db.TaskFilter{Tags: []string{"work"}}, []string{"ship release"}},
+		{"descendants", db.TaskFilter{Tags: []string{"work"}, Subtags: true},
+			[]string{"ship release", "prep agenda", "career chat", "plan offsite"}},
+		{"from the middle", db.TaskFilter{Tags: []string{"meetings"}, Subtags: true},
+			[]string{"prep agenda", "career chat", "plan offsite"}},
+		{"excluding a subtree", db.TaskFilter{NotTags: []string{"work"}, Subtags: true}, []string{"mow lawn"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, err := thingsDB.ListTasks(tt.filter)
+			if err != nil {
+				t.Fatalf("ListTasks: %v", err)
+			}
+			assertTitles(t, tasks, tt.want...)
+		})
+	}
+
+	q, err := db.ParseQuery("subtag:meetings -tag:1:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := thingsDB.ListTasks(db.TaskFilter{Query: q})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertTitles(t, tasks, "prep agenda", "plan offsite")
+}
+
+func TestListTagTree(t *testing.T) {
+	b := dbtest.New(t)
+	work := b.Tag("work")
+	meetings := b.Tag("meetings").Parent(work)
+	b.Tag("1:1").Parent(meetings)
+	b.Tag("deep work").Parent(work)
+	b.Tag("home")
+	b.Tag("stray").Parent(&dbtest.Tag{UUID: "gone"})
+	thingsDB := b.Open()
+
+	tags, err := thingsDB.ListTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range tags {
+		if tag.Title == "meetings" && tag.ParentUUID.String != work.UUID {
+			t.Errorf("meetings parent = %q, want %q", tag.ParentUUID.String, work.UUID)
+		}
+	}
+
+	roots, err := thingsDB.ListTagTree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var render func(tags []models.Tag) string
+	render = func(tags []models.Tag) string {
+		var parts []string
+		for _, tag := range tags {
+			s := tag.Title
+			if len(tag.Children) > 0 {
+				s += "(" + render(tag.Children) + ")"
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, " ")
+	}
+	if got, want := render(roots), "home stray work(deep work meetings(1:1))"; got != want {
+		t.Errorf("tree = %s, want %s", got, want)
+	}
+}
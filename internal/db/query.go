@@ -0,0 +1,594 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TaskQuery is a parsed task query, see ParseQuery
+type TaskQuery struct {
+	Input     string
+	Where     Predicate
+	HasStatus bool      // the query filters on status, so ListTasks adds no default
+	Day       time.Time // local midnight that relative dates and list terms were resolved against
+}
+
+// ParseQuery parses the task query language, e.g.
+//
+//	tag:urgent -tag:waiting area:"Work" due<+7d scheduled:today
+//	created>2026-01-01 repeating:no (project:Launch OR project:Ops) "free text"
+//
+// A term is field:value (or field=value), a date comparison such as due<+7d,
+// or free text matched against title and notes. Adjacent terms are ANDed, OR
+// joins alternatives, parentheses group, and a leading - or NOT negates.
+// Values with spaces are quoted. Name matches (tag, area, project, heading)
+// are exact but case-insensitive and also take UUIDs. Tags include those
+// inherited from the task's project and area; subtag:NAME also matches tags
+// nested below NAME.
+//
+// Fields: tag, subtag, area, project, heading, title, notes, status
+// (open|completed|canceled|any), list (inbox|today|evening|anytime|someday|upcoming),
+// repeating (yes|no), and the dates due, scheduled, created, modified, and
+// completed. Dates are YYYY-MM-DD, today, tomorrow, yesterday, or an offset
+// like +7d, -2w, +1m, +1y; date:none and date:any test for a missing or set
+// date. Without a status term only open tasks match.
+//
+// Relative dates and list terms are resolved against today when the query
+// is parsed; a query kept past midnight needs ForDay.
+func ParseQuery(input string) (*TaskQuery, error) {
+	return parseQuery(input, time.Now())
+}
+
+// ForDay returns q as parsed on now's local day: q itself if it already was,
+// otherwise q.Input parsed again, so today, +7d, list:today and the like
+// follow the clock
+func (q *TaskQuery) ForDay(now time.Time) *TaskQuery {
+	if startOfDay(now).Equal(q.Day) {
+		return q
+	}
+	fresh, err := parseQuery(q.Input, now)
+	if err != nil {
+		// q.Input parsed once, so it parses again
+		return q
+	}
+	return fresh
+}
+
+func parseQuery(input string, now time.Time) (*TaskQuery, error) {
+	toks, err := lexQuery(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{input: input, toks: toks, today: startOfDay(now)}
+	where := And()
+	if p.peek().kind != tokEOF {
+		where, err = p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.peek(); tok.kind != tokEOF {
+			return nil, p.errorf(tok.pos, "unexpected %s", tok)
+		}
+	}
+
+	return &TaskQuery{Input: input, Where: where, HasStatus: p.hasStatus, Day: p.today}, nil
+}
+
+// MatchesQuery reports whether the to-do or project with the given UUID
+// matches q. Unlike ListTasks it adds no default status and does not skip
+// trashed items, so it can test an item in any state, e.g. one just
+// completed or deleted. An unknown UUID does not match.
+func (db *ThingsDB) MatchesQuery(uuid string, q *TaskQuery) (bool, error) {
+	query := `
+		SELECT 1
+		FROM TMTask t
+		LEFT JOIN TMArea a ON t.area = a.uuid
+		LEFT JOIN TMTask p ON t.project = p.uuid AND p.type = 1
+		LEFT JOIN TMArea pa ON p.area = pa.uuid
+		LEFT JOIN TMTask h ON t.heading = h.uuid
+		LEFT JOIN TMTask hp ON h.project = hp.uuid AND hp.type = 1
+		LEFT JOIN TMArea hpa ON hp.area = hpa.uuid
+		WHERE t.uuid = ? AND t.type IN (0, 1) AND (` + q.Where.SQL + `)`
+
+	args := append([]interface{}{uuid}, q.Where.Args...)
+	var one int
+	err := db.conn.QueryRow(query, args...).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to match query: %w", err)
+	}
+	return true, nil
+}
+
+// QueryError reports a malformed query
+type QueryError struct {
+	Input string
+	Pos   int // byte offset into Input
+	Msg   string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid query at position %d: %s", e.Pos+1, e.Msg)
+}
+
+// Is makes errors.Is(err, ErrInvalidQuery) match
+func (e *QueryError) Is(target error) bool {
+	return target == ErrInvalidQuery
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokText
+	tokTerm
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type queryToken struct {
+	kind  tokenKind
+	pos   int
+	field string // tokTerm only, lower-cased
+	op    string // tokTerm only: ":", "=", "<", "<=", ">", ">="
+	value string
+}
+
+func (t queryToken) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokOr:
+		return "OR"
+	case tokNot:
+		return "negation"
+	case tokLParen:
+		return `"("`
+	case tokRParen:
+		return `")"`
+	case tokTerm:
+		return fmt.Sprintf("%q", t.field+t.op+t.value)
+	default:
+		return fmt.Sprintf("%q", t.value)
+	}
+}
+
+// lexQuery splits a query into tokens
+func lexQuery(s string) ([]queryToken, error) {
+	var toks []queryToken
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			toks = append(toks, queryToken{kind: tokLParen, pos: i})
+			i++
+		case c == ')':
+			toks = append(toks, queryToken{kind: tokRParen, pos: i})
+			i++
+		case c == '-':
+			if i+1 == len(s) || isQuerySpace(s[i+1]) {
+				return nil, &QueryError{Input: s, Pos: i, Msg: "- must be followed by a term"}
+			}
+			toks = append(toks, queryToken{kind: tokNot, pos: i})
+			i++
+		case c == '"':
+			value, end, err := readQuoted(s, i)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, queryToken{kind: tokText, pos: i, value: value})
+			i = end
+		default:
+			tok, end, err := readWord(s, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			if tok.kind == tokText && tok.value == "AND" {
+				continue
+			}
+			toks = append(toks, tok)
+		}
+	}
+	return append(toks, queryToken{kind: tokEOF, pos: len(s)}), nil
+}
+
+// readWord reads a field term or a bare word starting at i
+func readWord(s string, i int) (queryToken, int, error) {
+	j := i
+	for j < len(s) && isFieldChar(s[j]) {
+		j++
+	}
+	if j > i && j < len(s) && strings.IndexByte(":=<>", s[j]) >= 0 {
+		tok := queryToken{kind: tokTerm, pos: i, field: strings.ToLower(s[i:j])}
+		k := j + 1
+		if (s[j] == '<' || s[j] == '>') && k < len(s) && s[k] == '=' {
+			k++
+		}
+		tok.op = s[j:k]
+
+		end := k
+		if k < len(s) && s[k] == '"' {
+			value, e, err := readQuoted(s, k)
+			if err != nil {
+				return tok, 0, err
+			}
+			tok.value, end = value, e
+		} else {
+			for end < len(s) && !isQuerySpace(s[end]) && s[end] != '(' && s[end] != ')' {
+				end++
+			}
+			tok.value = s[k:end]
+		}
+		if tok.value == "" {
+			return tok, 0, &QueryError{Input: s, Pos: i, Msg: fmt.Sprintf("missing value after %s%s", tok.field, tok.op)}
+		}
+		return tok, end, nil
+	}
+
+	for j < len(s) && !isQuerySpace(s[j]) && s[j] != '(' && s[j] != ')' {
+		j++
+	}
+	word := s[i:j]
+	switch word {
+	case "OR":
+		return queryToken{kind: tokOr, pos: i}, j, nil
+	case "NOT":
+		return queryToken{kind: tokNot, pos: i}, j, nil
+	}
+	return queryToken{kind: tokText, pos: i, value: word}, j, nil
+}
+
+// readQuoted reads a "..." string starting at s[i], with \" and \\ escapes,
+// and returns it with the offset just past the closing quote
+func readQuoted(s string, i int) (string, int, error) {
+	var sb strings.Builder
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			if j+1 < len(s) {
+				j++
+				sb.WriteByte(s[j])
+			}
+		case '"':
+			return sb.String(), j + 1, nil
+		default:
+			sb.WriteByte(s[j])
+		}
+	}
+	return "", 0, &QueryError{Input: s, Pos: i, Msg: "unterminated quote"}
+}
+
+func isQuerySpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+func isFieldChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+}
+
+// queryParser is a recursive-descent parser over the tokens of one query:
+//
+//	or      = and { "OR" and }
+//	and     = unary { unary }
+//	unary   = ( "-" | "NOT" ) unary | primary
+//	primary = "(" or ")" | term | text
+type queryParser struct {
+	input     string
+	toks      []queryToken
+	i         int
+	today     time.Time
+	hasStatus bool
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.toks[p.i]
+}
+
+func (p *queryParser) next() queryToken {
+	tok := p.toks[p.i]
+	if tok.kind != tokEOF {
+		p.i++
+	}
+	return tok
+}
+
+func (p *queryParser) errorf(pos int, format string, args ...interface{}) error {
+	return &QueryError{Input: p.input, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *queryParser) parseOr() (Predicate, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return Predicate{}, err
+	}
+	alternatives := []Predicate{first}
+	for p.peek().kind == tokOr {
+		p.next()
+		next, err := p.parseAnd()
+		if err != nil {
+			return Predicate{}, err
+		}
+		alternatives = append(alternatives, next)
+	}
+	return Or(alternatives...), nil
+}
+
+func (p *queryParser) parseAnd() (Predicate, error) {
+	var terms []Predicate
+	for {
+		switch p.peek().kind {
+		case tokEOF, tokRParen, tokOr:
+			if len(terms) == 0 {
+				tok := p.peek()
+				return Predicate{}, p.errorf(tok.pos, "expected a term before %s", tok)
+			}
+			return And(terms...), nil
+		}
+		term, err := p.parseUnary()
+		if err != nil {
+			return Predicate{}, err
+		}
+		terms = append(terms, term)
+	}
+}
+
+func (p *queryParser) parseUnary() (Predicate, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return Predicate{}, err
+		}
+		return Not(inner), nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (Predicate, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return Predicate{}, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return Predicate{}, p.errorf(tok.pos, "missing ) for this (")
+		}
+		return inner, nil
+	case tokText:
+		pattern := containsPattern(tok.value)
+		return Cond(`(t.title LIKE ? ESCAPE '\' OR COALESCE(t.notes, '') LIKE ? ESCAPE '\')`, pattern, pattern), nil
+	case tokTerm:
+		return p.term(tok)
+	default:
+		return Predicate{}, p.errorf(tok.pos, "unexpected %s", tok)
+	}
+}
+
+// term compiles a field term to a predicate
+func (p *queryParser) term(tok queryToken) (Predicate, error) {
+	switch tok.field {
+	case "due", "deadline":
+		return p.dateTerm(tok, "t.deadline", true)
+	case "scheduled":
+		return p.dateTerm(tok, "t.startDate", true)
+	case "created":
+		return p.dateTerm(tok, "t.creationDate", false)
+	case "modified":
+		return p.dateTerm(tok, "t.userModificationDate", false)
+	case "completed":
+		return p.dateTerm(tok, "t.stopDate", false)
+	}
+
+	if tok.op != ":" && tok.op != "=" {
+		return Predicate{}, p.errorf(tok.pos, "%s does not support %s, use %s:value", tok.field, tok.op, tok.field)
+	}
+	value := tok.value
+	none := strings.EqualFold(value, "none")
+
+	switch tok.field {
+	case "tag":
+		if none {
+			return untagged(), nil
+		}
+		return hasTag(value, false), nil
+	case "subtag":
+		if none {
+			return untagged(), nil
+		}
+		return hasTag(value, true), nil
+	case "area":
+		if none {
+			return Cond(`COALESCE(a.uuid, pa.uuid, hpa.uuid) IS NULL`), nil
+		}
+		return Cond(`(LOWER(COALESCE(a.title, pa.title, hpa.title, '')) = LOWER(?) OR COALESCE(a.uuid, pa.uuid, hpa.uuid, '') = ?)`, value, value), nil
+	case "project":
+		if none {
+			return Cond(`COALESCE(p.uuid, hp.uuid) IS NULL`), nil
+		}
+		return Cond(`(LOWER(COALESCE(p.title, hp.title, '')) = LOWER(?) OR COALESCE(p.uuid, hp.uuid, '') = ?)`, value, value), nil
+	case "heading":
+		if none {
+			return Cond(`t.heading IS NULL`), nil
+		}
+		return Cond(`(LOWER(COALESCE(h.title, '')) = LOWER(?) OR COALESCE(h.uuid, '') = ?)`, value, value), nil
+	case "title":
+		return Cond(`t.title LIKE ? ESCAPE '\'`, containsPattern(value)), nil
+	case "notes":
+		return Cond(`COALESCE(t.notes, '') LIKE ? ESCAPE '\'`, containsPattern(value)), nil
+	case "status":
+		p.hasStatus = true
+		switch strings.ToLower(value) {
+		case "open", "incomplete":
+			return Cond(`t.status = 0`), nil
+		case "completed", "done":
+			return Cond(`t.status = 3`), nil
+		case "canceled", "cancelled":
+			return Cond(`t.status = 2`), nil
+		case "any", "all":
+			return And(), nil
+		}
+		return Predicate{}, p.errorf(tok.pos, "unknown status %q (want open, completed, canceled, or any)", value)
+	case "repeating":
+		switch strings.ToLower(value) {
+		case "yes", "true":
+			return Cond(`t.rt1_repeatingTemplate IS NOT NULL`), nil
+		case "no", "false":
+			return Cond(`t.rt1_repeatingTemplate IS NULL`), nil
+		}
+		return Predicate{}, p.errorf(tok.pos, "repeating takes yes or no, not %q", value)
+	case "list":
+		today := DateToPackedInt(p.today)
+		switch strings.ToLower(value) {
+		case "inbox":
+			return Cond(`t.start = 0`), nil
+		case "today":
+			return todayCondition(today), nil
+		case "evening":
+			return And(todayCondition(today), Cond(`t.startBucket = 1`)), nil
+		case "anytime":
+			return Cond(`(t.start = 1 OR (t.start = 2 AND t.startDate IS NOT NULL AND t.startDate <= ?))`, today), nil
+		case "someday":
+			return Cond(`(t.start = 2 AND t.startDate IS NULL)`), nil
+		case "upcoming":
+			return Cond(`(t.start = 2 AND t.startDate IS NOT NULL AND t.startDate > ?)`, today), nil
+		}
+		return Predicate{}, p.errorf(tok.pos, "unknown list %q (want inbox, today, evening, anytime, someday, or upcoming)", value)
+	}
+
+	return Predicate{}, p.errorf(tok.pos, "unknown field %q (quote free text that contains %s)", tok.field, tok.op)
+}
+
+// dateTerm compiles a comparison on a packed date (deadline, startDate) or a
+// unix timestamp column. Comparisons are by calendar day in local time, and
+// a missing date never matches a comparison, so -due<+7d includes undated tasks.
+func (p *queryParser) dateTerm(tok queryToken, col string, packed bool) (Predicate, error) {
+	eq := tok.op == ":" || tok.op == "="
+	switch strings.ToLower(tok.value) {
+	case "none", "any":
+		if !eq {
+			return Predicate{}, p.errorf(tok.pos, "%s%s%s: none and any only work with :", tok.field, tok.op, tok.value)
+		}
+		if strings.EqualFold(tok.value, "none") {
+			return Cond(col + " IS NULL"), nil
+		}
+		return Cond(col + " IS NOT NULL"), nil
+	}
+
+	day, err := parseQueryDate(tok.value, p.today)
+	if err != nil {
+		return Predicate{}, p.errorf(tok.pos, "%v", err)
+	}
+
+	if packed {
+		op := tok.op
+		if eq {
+			op = "="
+		}
+		return Cond(fmt.Sprintf("(%s IS NOT NULL AND %s %s ?)", col, col, op), DateToPackedInt(day)), nil
+	}
+
+	start, end := day.Unix(), day.AddDate(0, 0, 1).Unix()
+	switch tok.op {
+	case "<":
+		return Cond(fmt.Sprintf("(%s IS NOT NULL AND %s < ?)", col, col), start), nil
+	case "<=":
+		return Cond(fmt.Sprintf("(%s IS NOT NULL AND %s < ?)", col, col), end), nil
+	case ">":
+		return Cond(fmt.Sprintf("(%s IS NOT NULL AND %s >= ?)", col, col), end), nil
+	case ">=":
+		return Cond(fmt.Sprintf("(%s IS NOT NULL AND %s >= ?)", col, col), start), nil
+	default:
+		return Cond(fmt.Sprintf("(%s IS NOT NULL AND %s >= ? AND %s < ?)", col, col, col), start, end), nil
+	}
+}
+
+// relativeDatePattern matches offsets like +7d, -2w, +1m, 3y
+var relativeDatePattern = regexp.MustCompile(`^([+-]?)(\d+)([dwmy])$`)
+
+// parseQueryDate parses an absolute or relative date into local midnight
+func parseQueryDate(value string, today time.Time) (time.Time, error) {
+	switch strings.ToLower(value) {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	if m := relativeDatePattern.FindStringSubmatch(strings.ToLower(value)); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		if m[1] == "-" {
+			n = -n
+		}
+		switch m[3] {
+		case "d":
+			return today.AddDate(0, 0, n), nil
+		case "w":
+			return today.AddDate(0, 0, 7*n), nil
+		case "m":
+			return today.AddDate(0, n, 0), nil
+		default:
+			return today.AddDate(n, 0, 0), nil
+		}
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (want YYYY-MM-DD, today, tomorrow, yesterday, or an offset like +7d)", value)
+	}
+	return t, nil
+}
+
+// ParseHorizon parses how far ahead to look: an offset from today such as
+// 30d, 2w, 3m, or 1y, or a YYYY-MM-DD date. The result is local midnight.
+func ParseHorizon(value string) (time.Time, error) {
+	today := startOfDay(time.Now())
+	day, err := parseQueryDate(value, today)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid horizon %q (want an offset like 30d, 2w, 3m, or a YYYY-MM-DD date)", value)
+	}
+	if day.Before(today) {
+		return time.Time{}, fmt.Errorf("invalid horizon %q: it is in the past", value)
+	}
+	return day, nil
+}
+
+// ParseAge parses a cutoff in the past: an age such as 30d, 2w, 3m, or 1y
+// counted back from today, or a YYYY-MM-DD date. The result is local midnight.
+func ParseAge(value string) (time.Time, error) {
+	today := startOfDay(time.Now())
+	offset := strings.ToLower(value)
+	if m := relativeDatePattern.FindStringSubmatch(offset); m != nil && m[1] == "" {
+		offset = "-" + offset
+	}
+	day, err := parseQueryDate(offset, today)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid age %q (want an age like 30d, 2w, 3m, or a YYYY-MM-DD date)", value)
+	}
+	if day.After(today) {
+		return time.Time{}, fmt.Errorf("invalid age %q: it is in the future", value)
+	}
+	return day, nil
+}
+
+// startOfDay returns local midnight of t's day
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,146 @@
+package db_test
+
+import (
+	"errors"
+	"testing"
+
+	"thingies/internal/db"
+	"thingies/internal/db/dbtest"
+)
+
+func TestParseQueryFilters(t *testing.T) {
+	b := dbtest.New(t)
+	work, home := b.Area("Work"), b.Area("Home")
+	launch := b.Project("Launch").InArea(work)
+	ops := b.Project("Ops").InArea(work)
+	prep := b.Heading(launch, "Prep")
+
+	b.Task("write spec").InProject(launch).Tags("urgent").Deadline(dbtest.DaysFromToday(3))
+	b.Task("book venue").UnderHeading(prep).Tags("urgent", "waiting").Deadline(dbtest.DaysFromToday(10))
+	b.Task("rotate keys").InProject(ops).Tags("Urgent").Scheduled(dbtest.DaysFromToday(0))
+	b.Task("fix sink").InArea(home).Notes("call the plumber").Someday()
+	b.Task("inbox item").Created(dbtest.DaysFromToday(-30))
+	b.Task("50% off").Inbox()
+	b.Task("done thing").InArea(work).Completed()
+	template := b.Task("plant schedule").InArea(home).RepeatingTemplate([]byte{}, dbtest.DaysFromToday(7))
+	b.Task("water plants").InArea(home).InstanceOf(template).Scheduled(dbtest.DaysFromToday(-1))
+	thingsDB := b.Open()
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{`tag:urgent`, []string{"write spec", "book venue", "rotate keys"}},
+		{`tag:urgent -tag:waiting`, []string{"write spec", "rotate keys"}},
+		{`tag:urg`, nil},
+		{`tag:none area:Work`, nil},
+		{`area:"Work"`, []string{"write spec", "book venue", "rotate keys"}},
+		{`area:none`, []string{"inbox item", "50% off"}},
+		{`project:Launch`, []string{"write spec", "book venue"}},
+		{`heading:Prep`, []string{"book venue"}},
+		{`project:launch OR project:Ops`, []string{"write spec", "book venue", "rotate keys"}},
+		{`area:Work -(project:Launch)`, []string{"rotate keys"}},
+		{`due<+7d`, []string{"write spec"}},
+		{`due<=+10d`, []string{"write spec", "book venue"}},
+		{`due:none area:Home`, []string{"fix sink", "water plants", "plant schedule"}},
+		{`-due<+7d area:Work`, []string{"book venue", "rotate keys"}},
+		{`scheduled:today`, []string{"rotate keys"}},
+		{`created<-7d`, []string{"inbox item"}},
+		{`created>=today`, []string{"write spec", "book venue", "rotate keys", "fix sink", "50% off", "water plants", "plant schedule"}},
+		{`repeating:yes`, []string{"water plants"}},
+		{`repeating:no area:Home`, []string{"fix sink", "plant schedule"}},
+		{`plumber`, []string{"fix sink"}},
+		{`"50%"`, []string{"50% off"}},
+		{`title:spec OR notes:plumber`, []string{"write spec", "fix sink"}},
+		{`status:completed`, []string{"done thing"}},
+		{`status:any area:Work -project:Launch -project:Ops`, []string{"done thing"}},
+		{`list:someday`, []string{"fix sink", "plant schedule"}},
+		{`list:today`, []string{"rotate keys", "water plants"}},
+		{`(tag:waiting OR list:someday) AND NOT area:Home`, []string{"book venue"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := db.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery: %v", err)
+			}
+			tasks, err := thingsDB.ListTasks(db.TaskFilter{Query: q})
+			if err != nil {
+				t.Fatalf("ListTasks: %v", err)
+			}
+			assertTitles(t, tasks, tt.want...)
+		})
+	}
+}
+
+func TestMatchesQuery(t *testing.T) {
+	b := dbtest.New(t)
+	work := b.Area("Work")
+	launch := b.Project("Launch").InArea(work).Tags("deploy")
+	shipped := b.Task("ship it").InProject(launch).Completed()
+	trashed := b.Task("old deploy").Tags("deploy").Trashed()
+	plain := b.Task("plain")
+	thingsDB := b.Open()
+
+	tests := []struct {
+		query string
+		uuid  string
+		want  bool
+	}{
+		// No default status: a completed task matches without status:any
+		{`tag:deploy`, shipped.UUID, true},
+		{`tag:deploy status:completed`, shipped.UUID, true},
+		{`tag:deploy status:open`, shipped.UUID, false},
+		{`tag:deploy`, trashed.UUID, true},
+		{`tag:deploy`, plain.UUID, false},
+		{`area:Work`, launch.UUID, true},
+		{`tag:deploy`, "NoSuchTask", false},
+	}
+
+	for _, tt := range tests {
+		q, err := db.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatalf("ParseQuery(%q): %v", tt.query, err)
+		}
+		got, err := thingsDB.MatchesQuery(tt.uuid, q)
+		if err != nil {
+			t.Fatalf("MatchesQuery(%q): %v", tt.query, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s on %s: expected %v, got %v", tt.query, tt.uuid, tt.want, got)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+	}{
+		{`tag<urgent`, 1},
+		{`colour:red`, 1},
+		{`due:someday`, 1},
+		{`due<none`, 1},
+		{`status:later`, 1},
+		{`(tag:a OR tag:b`, 1},
+		{`tag:a)`, 6},
+		{`tag:a OR`, 9},
+		{`"unterminated`, 1},
+		{`tag:`, 1},
+		{`tag:a - tag:b`, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := db.ParseQuery(tt.query)
+			if !errors.Is(err, db.ErrInvalidQuery) {
+				t.Fatalf("expected ErrInvalidQuery, got %v", err)
+			}
+			var qe *db.QueryError
+			if !errors.As(err, &qe) || qe.Pos+1 != tt.pos {
+				t.Errorf("expected error at position %d, got %v", tt.pos, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,358 @@
+package db
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"thingies/internal/models"
+)
+
+// GetTaskRecurrence returns the recurrence rule of a repeating template, or of
+// the template an instance was made from. It returns nil for tasks that do
+// not repeat.
+func (db *ThingsDB) GetTaskRecurrence(uuid string) (*models.Recurrence, error) {
+	var rule []byte
+	err := db.conn.QueryRow(`
+		SELECT COALESCE(t.rt1_recurrenceRule, tpl.rt1_recurrenceRule)
+		FROM TMTask t
+		LEFT JOIN TMTask tpl ON tpl.uuid = t.rt1_repeatingTemplate
+		WHERE t.uuid = ?
+	`, uuid).Scan(&rule)
+	if err == sql.ErrNoRows {
+		return nil, notFound("task", uuid)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recurrence rule: %w", err)
+	}
+	if len(rule) == 0 {
+		return nil, nil
+	}
+	return ParseRecurrenceRule(rule)
+}
+
+// nsDateEpoch is 2001-01-01 UTC, the reference date of plist dates and of
+// the ia and ed keys
+const nsDateEpoch = 978307200
+
+// noEndYear marks the "never" end date Things writes (year 4001)
+const noEndYear = 4000
+
+// recurrenceUnits maps the fu key (an NSCalendarUnit) to a frequency
+var recurrenceUnits = map[int]models.Frequency{
+	16:  models.Daily,
+	256: models.Weekly,
+	8:   models.Monthly,
+	4:   models.Yearly,
+}
+
+// ParseRecurrenceRule decodes an rt1_recurrenceRule plist. Things writes an
+// XML plist dict with these keys:
+//
+//	fu   frequency unit: 16 daily, 256 weekly, 8 monthly, 4 yearly
+//	fa   interval ("every fa units")
+//	tp   0 for a fixed schedule, 1 for after completion
+//	of   array of offsets: wd weekday (1 = Sunday), wdo weekday ordinal
+//	     (-1 = last), dy day of month (-1 = last), mo month
+//	ia   first day of the schedule, seconds since 2001-01-01
+//	ed   end date in the same form; year 4001 means never
+//	rc   number of occurrences, 0 for no limit
+//
+// Errors wrap ErrInvalidRecurrence.
+func ParseRecurrenceRule(data []byte) (*models.Recurrence, error) {
+	if bytes.HasPrefix(data, []byte("bplist")) {
+		return nil, fmt.Errorf("%w: binary plists are not supported", ErrInvalidRecurrence)
+	}
+	v, err := decodePlist(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRecurrence, err)
+	}
+	dict, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: top-level value is not a dict", ErrInvalidRecurrence)
+	}
+
+	unit, _ := plistInt(dict["fu"])
+	freq, ok := recurrenceUnits[unit]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown frequency unit %d", ErrInvalidRecurrence, unit)
+	}
+
+	r := &models.Recurrence{Frequency: freq, Interval: 1}
+	if n, ok := plistInt(dict["fa"]); ok && n > 0 {
+		r.Interval = n
+	}
+	if tp, _ := plistInt(dict["tp"]); tp == 1 {
+		r.AfterCompletion = true
+	}
+	if n, ok := plistInt(dict["rc"]); ok && n > 0 {
+		r.Count = n
+	}
+	if start, ok := plistDay(dict["ia"]); ok {
+		r.Start = start
+	}
+	if end, ok := plistDay(dict["ed"]); ok && end.Year() < noEndYear {
+		r.End = sql.NullTime{Time: end, Valid: true}
+	}
+
+	offsets, _ := dict["of"].([]interface{})
+	for _, o := range offsets {
+		offset, ok := o.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if wd, ok := plistInt(offset["wd"]); ok && wd >= 1 && wd <= 7 {
+			r.Weekdays = append(r.Weekdays, time.Weekday(wd-1))
+		}
+		if n, ok := plistInt(offset["wdo"]); ok && n != 0 {
+			r.Ordinal = n
+		}
+		if dy, ok := plistInt(offset["dy"]); ok && dy != 0 && freq != models.Daily && freq != models.Weekly {
+			r.DaysOfMonth = append(r.DaysOfMonth, dy)
+		}
+		if mo, ok := plistInt(offset["mo"]); ok && mo >= 1 && mo <= 12 {
+			r.Month = time.Month(mo)
+		}
+	}
+	return r, nil
+}
+
+// plistInt reads an integer or real plist value
+func plistInt(v interface{}) (int, bool) {
+	f, ok := v.(float64)
+	return int(f), ok
+}
+
+// plistDay reads a date, given as seconds since 2001 or a plist date, as a
+// local calendar day at UTC midnight
+func plistDay(v interface{}) (time.Time, bool) {
+	var t time.Time
+	switch v := v.(type) {
+	case float64:
+		t = time.Unix(nsDateEpoch+int64(v), 0)
+	case time.Time:
+		t = v.Local()
+	default:
+		return time.Time{}, false
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), true
+}
+
+// decodePlist decodes an XML plist into maps, slices, strings, bools,
+// float64s (integer and real), and time.Times
+func decodePlist(data []byte) (interface{}, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	inPlist := false
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil, errors.New("no plist value")
+		}
+		if err != nil {
+			return nil, err
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if se.Name.Local == "plist" && !inPlist {
+			inPlist = true
+			continue
+		}
+		return decodePlistValue(d, se)
+	}
+}
+
+// decodePlistValue decodes the value whose start element was just read
+func decodePlistValue(d *xml.Decoder, se xml.StartElement) (interface{}, error) {
+	switch se.Name.Local {
+	case "dict":
+		dict := make(map[string]interface{})
+		key := ""
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					if err := d.DecodeElement(&key, &t); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				v, err := decodePlistValue(d, t)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = v
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+	case "array":
+		var items []interface{}
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				v, err := decodePlistValue(d, t)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, v)
+			case xml.EndElement:
+				return items, nil
+			}
+		}
+	case "true", "false":
+		return se.Name.Local == "true", d.Skip()
+	}
+
+	var text string
+	if err := d.DecodeElement(&text, &se); err != nil {
+		return nil, err
+	}
+	text = strings.TrimSpace(text)
+	switch se.Name.Local {
+	case "integer", "real":
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad <%s> %q", se.Name.Local, text)
+		}
+		return f, nil
+	case "date":
+		t, err := time.Parse(time.RFC3339, text)
+		if err != nil {
+			return nil, fmt.Errorf("bad <date> %q", text)
+		}
+		return t, nil
+	default:
+		return text, nil
+	}
+}
+
+// maxProjectedPerTemplate caps the occurrences ProjectRepeating makes for one
+// template, so a daily rule with a far horizon stays bounded
+const maxProjectedPerTemplate = 500
+
+// ProjectRepeating returns virtual occurrences of the open repeating templates
+// that fall after each template's next instance, up to and including until.
+// Each is a copy of the template with Scheduled set to the occurrence,
+// Projected and IsRepeating set, and the template's UUID. Paused templates,
+// rules that can't be decoded, and after-completion rules (whose dates depend
+// on when each instance gets done) are skipped.
+func (db *ThingsDB) ProjectRepeating(until time.Time) ([]models.Task, error) {
+	rows, err := db.conn.Query(`
+		SELECT t.uuid, t.rt1_recurrenceRule, t.rt1_nextInstanceStartDate
+		FROM TMTask t
+		LEFT JOIN TMTask p ON t.project = p.uuid
+		WHERE t.type = 0 AND t.trashed = 0 AND t.status = 0
+			AND t.rt1_recurrenceRule IS NOT NULL
+			AND COALESCE(t.rt1_instanceCreationPaused, 0) = 0
+			AND (p.uuid IS NULL OR p.trashed = 0)
+		ORDER BY t."index"
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query repeating templates: %w", err)
+	}
+
+	type template struct {
+		uuid string
+		rule *models.Recurrence
+		next sql.NullTime
+	}
+	var templates []template
+	for rows.Next() {
+		var uuid string
+		var rule []byte
+		var next sql.NullFloat64
+		if err := rows.Scan(&uuid, &rule, &next); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan repeating template: %w", err)
+		}
+		r, err := ParseRecurrenceRule(rule)
+		if err != nil || r.AfterCompletion {
+			continue
+		}
+		templates = append(templates, template{uuid: uuid, rule: r, next: thingsDateToNullTime(next)})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	last := time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, time.UTC)
+
+	var projected []models.Task
+	for _, tpl := range templates {
+		after := today
+		if tpl.next.Valid && tpl.next.Time.After(after) {
+			after = tpl.next.Time
+		}
+		days := tpl.rule.Next(after, maxProjectedPerTemplate)
+		if len(days) == 0 || days[0].After(last) {
+			continue
+		}
+
+		task, err := db.GetTask(tpl.uuid)
+		if err != nil {
+			return nil, err
+		}
+		for _, day := range days {
+			if day.After(last) {
+				break
+			}
+			occurrence := *task
+			occurrence.Scheduled = sql.NullTime{Time: day, Valid: true}
+			occurrence.Projected = true
+			occurrence.IsRepeating = true
+			projected = append(projected, occurrence)
+		}
+	}
+
+	sortByScheduled(projected)
+	return projected, nil
+}
+
+// GetUpcomingWithProjections returns GetUpcomingTasks merged with
+// ProjectRepeating(until), ordered by scheduled date
+func (db *ThingsDB) GetUpcomingWithProjections(until time.Time) ([]models.Task, error) {
+	tasks, err := db.GetUpcomingTasks()
+	if err != nil {
+		return nil, err
+	}
+	projected, err := db.ProjectRepeating(until)
+	if err != nil {
+		return nil, err
+	}
+	tasks = append(tasks, projected...)
+	sortByScheduled(tasks)
+	return tasks, nil
+}
+
+// sortByScheduled orders tasks by scheduled date, undated last, keeping the
+// existing order among equal dates
+func sortByScheduled(tasks []models.Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i].Scheduled, tasks[j].Scheduled
+		if !a.Valid || !b.Valid {
+			return a.Valid && !b.Valid
+		}
+		return a.Time.Before(b.Time)
+	})
+}
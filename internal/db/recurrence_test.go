@@ -0,0 +1,251 @@
+package db_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"thingies/internal/db"
+	"thingies/internal/db/dbtest"
+)
+
+// nsDate returns local midnight of the day as seconds since 2001-01-01, the
+// way Things stores ia and ed
+func nsDate(day string) int64 {
+	t, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t.Unix() - 978307200
+}
+
+// rulePlist wraps dict entries in an XML plist like rt1_recurrenceRule
+func rulePlist(entries string) []byte {
+	return []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>ed</key><real>64092211200</real>
+	<key>rrv</key><integer>4</integer>
+	<key>ts</key><integer>0</integer>
+	` + entries + `
+</dict>
+</plist>`)
+}
+
+func TestParseRecurrenceRule(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  []byte
+		after string
+		n     int
+		want  string
+		next  []string
+	}{
+		{
+			name: "weekly on several days",
+			rule: rulePlist(fmt.Sprintf(`<key>fu</key><integer>256</integer><key>fa</key><integer>2</integer>
+				<key>tp</key><integer>0</integer><key>ia</key><real>%d</real>
+				<key>of</key><array><dict><key>wd</key><integer>2</integer></dict><dict><key>wd</key><integer>5</integer></dict></array>`,
+				nsDate("2026-01-05"))),
+			after: "2026-01-05", n: 4,
+			want: "every 2 weeks on Mon, Thu",
+			next: []string{"2026-01-08", "2026-01-19", "2026-01-22", "2026-02-02"},
+		},
+		{
+			name: "weekly, skipping ahead",
+			rule: rulePlist(fmt.Sprintf(`<key>fu</key><integer>256</integer><key>fa</key><integer>2</integer>
+				<key>ia</key><real>%d</real>
+				<key>of</key><array><dict><key>wd</key><integer>2</integer></dict><dict><key>wd</key><integer>5</integer></dict></array>`,
+				nsDate("2026-01-05"))),
+			after: "2026-03-01", n: 3,
+			want: "every 2 weeks on Mon, Thu",
+			next: []string{"2026-03-02", "2026-03-05", "2026-03-16"},
+		},
+		{
+			name: "last day of the month",
+			rule: rulePlist(fmt.Sprintf(`<key>fu</key><integer>8</integer><key>fa</key><integer>1</integer>
+				<key>ia</key><real>%d</real>
+				<key>of</key><array><dict><key>dy</key><integer>-1</integer></dict></array>`, nsDate("2026-01-31"))),
+			after: "2026-01-31", n: 3,
+			want: "every month on the last day",
+			next: []string{"2026-02-28", "2026-03-31", "2026-04-30"},
+		},
+		{
+			name: "nth weekday of the month",
+			rule: rulePlist(fmt.Sprintf(`<key>fu</key><integer>8</integer><key>fa</key><integer>1</integer>
+				<key>ia</key><real>%d</real>
+				<key>of</key><array><dict><key>wd</key><integer>3</integer><key>wdo</key><integer>2</integer></dict></array>`,
+				nsDate("2026-01-13"))),
+			after: "2026-01-13", n: 2,
+			want: "every month on the 2nd Tuesday",
+			next: []string{"2026-02-10", "2026-03-10"},
+		},
+		{
+			name: "after completion",
+			rule: rulePlist(fmt.Sprintf(`<key>fu</key><integer>16</integer><key>fa</key><integer>3</integer>
+				<key>tp</key><integer>1</integer><key>ia</key><real>%d</real>
+				<key>of</key><array><dict><key>dy</key><integer>0</integer></dict></array>`, nsDate("2026-01-01"))),
+			after: "2026-01-01", n: 2,
+			want: "every 3 days after completion",
+			next: []string{"2026-01-04", "2026-01-07"},
+		},
+		{
+			name: "yearly with an end date",
+			rule: []byte(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict>
+	<key>fu</key><integer>4</integer><key>fa</key><integer>1</integer>
+	<key>ia</key><real>%d</real><key>ed</key><real>%d</real>
+	<key>of</key><array><dict><key>dy</key><integer>5</integer><key>mo</key><integer>3</integer></dict></array>
+</dict></plist>`, nsDate("2026-03-05"), nsDate("2028-12-31"))),
+			after: "2026-01-01", n: 5,
+			want: "every year on March 5 until 2028-12-31",
+			next: []string{"2026-03-05", "2027-03-05", "2028-03-05"},
+		},
+		{
+			name: "limited count",
+			rule: rulePlist(fmt.Sprintf(`<key>fu</key><integer>16</integer><key>fa</key><integer>1</integer>
+				<key>rc</key><integer>3</integer><key>ia</key><real>%d</real>`, nsDate("2026-01-01"))),
+			after: "2025-12-31", n: 10,
+			want: "every day, 3 times",
+			next: []string{"2026-01-01", "2026-01-02", "2026-01-03"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := db.ParseRecurrenceRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrenceRule: %v", err)
+			}
+			if got := r.Describe(); got != tt.want {
+				t.Errorf("Describe() = %q, want %q", got, tt.want)
+			}
+			after, _ := time.Parse("2006-01-02", tt.after)
+			var next []string
+			for _, day := range r.Next(after, tt.n) {
+				next = append(next, day.Format("2006-01-02"))
+			}
+			if !slices.Equal(next, tt.next) {
+				t.Errorf("Next() = %q, want %q", next, tt.next)
+			}
+		})
+	}
+}
+
+func TestParseRecurrenceRuleErrors(t *testing.T) {
+	for name, rule := range map[string][]byte{
+		"binary plist": []byte("bplist00\x00\x01"),
+		"not xml":      []byte("rule"),
+		"unknown unit": rulePlist(`<key>fu</key><integer>99</integer>`),
+		"not a dict":   []byte(`<plist version="1.0"><array></array></plist>`),
+	} {
+		if _, err := db.ParseRecurrenceRule(rule); !errors.Is(err, db.ErrInvalidRecurrence) {
+			t.Errorf("%s: expected ErrInvalidRecurrence, got %v", name, err)
+		}
+	}
+}
+
+func TestGetTaskRecurrence(t *testing.T) {
+	b := dbtest.New(t)
+	rule := rulePlist(`<key>fu</key><integer>256</integer><key>fa</key><integer>1</integer>
+		<key>of</key><array><dict><key>wd</key><integer>6</integer></dict></array>`)
+	template := b.Task("Review week").RepeatingTemplate(rule, dbtest.DaysFromToday(7))
+	instance := b.Task("Review week").InstanceOf(template).Today()
+	plain := b.Task("One-off")
+	thingsDB := b.Open()
+
+	for _, uuid := range []string{template.UUID, instance.UUID} {
+		r, err := thingsDB.GetTaskRecurrence(uuid)
+		if err != nil {
+			t.Fatalf("GetTaskRecurrence(%s): %v", uuid, err)
+		}
+		if r == nil || r.Describe() != "every week on Fri" {
+			t.Errorf("GetTaskRecurrence(%s) = %+v, want every week on Fri", uuid, r)
+		}
+	}
+
+	if r, err := thingsDB.GetTaskRecurrence(plain.UUID); err != nil || r != nil {
+		t.Errorf("expected no recurrence for a plain task, got %+v, %v", r, err)
+	}
+	if _, err := thingsDB.GetTaskRecurrence("missing"); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestProjectRepeating(t *testing.T) {
+	b := dbtest.New(t)
+	today := dbtest.DaysFromToday(0)
+	daily := rulePlist(fmt.Sprintf(`<key>fu</key><integer>16</integer><key>fa</key><integer>1</integer>
+		<key>ia</key><real>%d</real>`, today.Unix()-978307200))
+	afterCompletion := rulePlist(`<key>fu</key><integer>16</integer><key>fa</key><integer>1</integer><key>tp</key><integer>1</integer>`)
+
+	home := b.Area("Home")
+	b.Task("Feed cat").InArea(home).RepeatingTemplate(daily, dbtest.DaysFromToday(2))
+	b.Task("Water plants").RepeatingTemplate(afterCompletion, dbtest.DaysFromToday(2))
+	b.Task("Old chore").RepeatingTemplate(daily, dbtest.DaysFromToday(2)).Trashed()
+	b.Task("Broken rule").RepeatingTemplate([]byte("rule"), dbtest.DaysFromToday(2))
+	b.Task("Dentist").Scheduled(dbtest.DaysFromToday(4))
+	thingsDB := b.Open()
+
+	projected, err := thingsDB.ProjectRepeating(dbtest.DaysFromToday(5))
+	if err != nil {
+		t.Fatalf("ProjectRepeating: %v", err)
+	}
+	var got []string
+	for _, task := range projected {
+		if !task.Projected || !task.IsRepeating || !task.AreaName.Valid {
+			t.Errorf("expected a projected copy of the template, got %+v", task)
+		}
+		got = append(got, task.Title+" "+task.Scheduled.Time.Format("2006-01-02"))
+	}
+	want := []string{
+		"Feed cat " + dbtest.DaysFromToday(3).Format("2006-01-02"),
+		"Feed cat " + dbtest.DaysFromToday(4).Format("2006-01-02"),
+		"Feed cat " + dbtest.DaysFromToday(5).Format("2006-01-02"),
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	upcoming, err := thingsDB.GetUpcomingWithProjections(dbtest.DaysFromToday(5))
+	if err != nil {
+		t.Fatalf("GetUpcomingWithProjections: %v", err)
+	}
+	got = nil
+	for _, task := range upcoming {
+		got = append(got, fmt.Sprintf("%s %s %v", task.Title, task.Scheduled.Time.Format("01-02"), task.Projected))
+	}
+	day := func(n int) string { return dbtest.DaysFromToday(n).Format("01-02") }
+	want = []string{
+		"Feed cat " + day(2) + " false",
+		"Water plants " + day(2) + " false",
+		"Broken rule " + day(2) + " false",
+		"Feed cat " + day(3) + " true",
+		"Dentist " + day(4) + " false",
+		"Feed cat " + day(4) + " true",
+		"Feed cat " + day(5) + " true",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q,\nwant %q", got, want)
+	}
+}
+
+func TestParseHorizon(t *testing.T) {
+	for value, want := range map[string]time.Time{
+		"30d": dbtest.DaysFromToday(30),
+		"+2w": dbtest.DaysFromToday(14),
+	} {
+		got, err := db.ParseHorizon(value)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseHorizon(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"-3d", "soon", "2001-01-01"} {
+		if _, err := db.ParseHorizon(value); err == nil {
+			t.Errorf("ParseHorizon(%q): expected an error", value)
+		}
+	}
+}
@@ -2,9 +2,9 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"regexp"
-	"strings"
 )
 
 // uuidPattern matches Things UUIDs: 22-character base62 alphanumeric strings
@@ -16,7 +16,7 @@ func LooksLikeUUID(s string) bool {
 }
 
 // GetProjectUUIDByName looks up a project by name, returns UUID
-// Returns error if no match or multiple matches found
+// Returns ErrNotFound if nothing matches or an *AmbiguousError if several do
 func (db *ThingsDB) GetProjectUUIDByName(name string) (string, error) {
 	query := `SELECT uuid FROM TMTask WHERE type = 1 AND trashed = 0 AND title = ?`
 	rows, err := db.conn.Query(query, name)
@@ -38,16 +38,16 @@ func (db *ThingsDB) GetProjectUUIDByName(name string) (string, error) {
 	}
 
 	if len(uuids) == 0 {
-		return "", fmt.Errorf("project not found: %s", name)
+		return "", notFound("project", name)
 	}
 	if len(uuids) > 1 {
-		return "", fmt.Errorf("multiple projects match '%s', use UUID", name)
+		return "", newAmbiguousError("project", name, true, uuids)
 	}
 	return uuids[0], nil
 }
 
 // GetAreaUUIDByName looks up an area by name, returns UUID
-// Returns error if no match or multiple matches found
+// Returns ErrNotFound if nothing matches or an *AmbiguousError if several do
 func (db *ThingsDB) GetAreaUUIDByName(name string) (string, error) {
 	query := `SELECT uuid FROM TMArea WHERE title = ?`
 	rows, err := db.conn.Query(query, name)
@@ -69,10 +69,10 @@ func (db *ThingsDB) GetAreaUUIDByName(name string) (string, error) {
 	}
 
 	if len(uuids) == 0 {
-		return "", fmt.Errorf("area not found: %s", name)
+		return "", notFound("area", name)
 	}
 	if len(uuids) > 1 {
-		return "", fmt.Errorf("multiple areas match '%s', use UUID", name)
+		return "", newAmbiguousError("area", name, true, uuids)
 	}
 	return uuids[0], nil
 }
@@ -85,7 +85,7 @@ func (db *ThingsDB) ResolveProjectID(nameOrUUID string) (string, error) {
 		var exists int
 		err := db.conn.QueryRow(`SELECT 1 FROM TMTask WHERE uuid = ? AND type = 1 AND trashed = 0`, nameOrUUID).Scan(&exists)
 		if err == sql.ErrNoRows {
-			return "", fmt.Errorf("project not found: %s", nameOrUUID)
+			return "", notFound("project", nameOrUUID)
 		}
 		if err != nil {
 			return "", fmt.Errorf("failed to query project: %w", err)
@@ -98,9 +98,10 @@ func (db *ThingsDB) ResolveProjectID(nameOrUUID string) (string, error) {
 	if err == nil {
 		return resolved, nil
 	}
-	// Only fall through to name lookup for "not found" errors;
-	// surface ambiguous prefix and DB errors immediately
-	if !strings.Contains(err.Error(), "not found") {
+	// Names with spaces or punctuation are never a valid prefix; fall
+	// through to name lookup for those and for "not found", surfacing
+	// ambiguous prefix and DB errors immediately
+	if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrInvalidPrefix) {
 		return "", err
 	}
 
@@ -116,7 +117,7 @@ func (db *ThingsDB) ResolveAreaID(nameOrUUID string) (string, error) {
 		var exists int
 		err := db.conn.QueryRow(`SELECT 1 FROM TMArea WHERE uuid = ?`, nameOrUUID).Scan(&exists)
 		if err == sql.ErrNoRows {
-			return "", fmt.Errorf("area not found: %s", nameOrUUID)
+			return "", notFound("area", nameOrUUID)
 		}
 		if err != nil {
 			return "", fmt.Errorf("failed to query area: %w", err)
@@ -129,12 +130,184 @@ func (db *ThingsDB) ResolveAreaID(nameOrUUID string) (string, error) {
 	if err == nil {
 		return resolved, nil
 	}
-	// Only fall through to name lookup for "not found" errors;
-	// surface ambiguous prefix and DB errors immediately
-	if !strings.Contains(err.Error(), "not found") {
+	// Names with spaces or punctuation are never a valid prefix; fall
+	// through to name lookup for those and for "not found", surfacing
+	// ambiguous prefix and DB errors immediately
+	if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrInvalidPrefix) {
 		return "", err
 	}
 
 	// Fall back to name lookup
 	return db.GetAreaUUIDByName(nameOrUUID)
 }
+
+// MoveTarget is a resolved destination for moving a task. Exactly one of
+// Inbox, Area, or Project is set; Heading is set only together with Project.
+type MoveTarget struct {
+	Inbox   bool
+	Area    string
+	Project string
+	Heading string
+}
+
+// ResolveMoveTarget resolves a move destination given as project and area
+// names, UUIDs, or prefixes and a heading UUID or prefix. Exactly one of
+// inbox, area, or project/heading may be given. A heading implies its
+// project; given both, the heading must belong to the project. Errors for a
+// bad combination wrap ErrInvalidMove.
+func (db *ThingsDB) ResolveMoveTarget(inbox bool, project, area, heading string) (*MoveTarget, error) {
+	given := 0
+	for _, set := range []bool{inbox, area != "", project != "" || heading != ""} {
+		if set {
+			given++
+		}
+	}
+	if given != 1 {
+		return nil, fmt.Errorf("%w: give exactly one of inbox, area, or project/heading", ErrInvalidMove)
+	}
+
+	switch {
+	case inbox:
+		return &MoveTarget{Inbox: true}, nil
+	case area != "":
+		uuid, err := db.ResolveAreaID(area)
+		if err != nil {
+			return nil, err
+		}
+		return &MoveTarget{Area: uuid}, nil
+	}
+
+	target := &MoveTarget{}
+	if project != "" {
+		uuid, err := db.ResolveProjectID(project)
+		if err != nil {
+			return nil, err
+		}
+		target.Project = uuid
+	}
+	if heading != "" {
+		uuid, err := db.ResolveHeadingUUID(heading)
+		if err != nil {
+			return nil, err
+		}
+		var headingProject sql.NullString
+		err = db.conn.QueryRow(`SELECT project FROM TMTask WHERE uuid = ?`, uuid).Scan(&headingProject)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query heading: %w", err)
+		}
+		if target.Project == "" {
+			target.Project = headingProject.String
+		} else if headingProject.String != target.Project {
+			return nil, fmt.Errorf("%w: heading %s does not belong to project %s", ErrInvalidMove, uuid, target.Project)
+		}
+		target.Heading = uuid
+	}
+	return target, nil
+}
+
+// GetTagUUIDByName looks up a tag by name, returns UUID
+// Returns ErrNotFound if nothing matches or an *AmbiguousError if several do
+func (db *ThingsDB) GetTagUUIDByName(name string) (string, error) {
+	query := `SELECT uuid FROM TMTag WHERE title = ?`
+	rows, err := db.conn.Query(query, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to query tag: %w", err)
+	}
+	defer rows.Close()
+
+	var uuids []string
+	for rows.Next() {
+		var uuid string
+		if err := rows.Scan(&uuid); err != nil {
+			return "", err
+		}
+		uuids = append(uuids, uuid)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("error iterating tags: %w", err)
+	}
+
+	if len(uuids) == 0 {
+		return "", notFound("tag", name)
+	}
+	if len(uuids) > 1 {
+		return "", newAmbiguousError("tag", name, true, uuids)
+	}
+	return uuids[0], nil
+}
+
+// ResolveTagID returns UUID for a tag given name, full UUID, or short UUID prefix.
+// Tries in order: full UUID match, short UUID prefix, name lookup.
+func (db *ThingsDB) ResolveTagID(nameOrUUID string) (string, error) {
+	resolved, err := db.ResolveTagUUID(nameOrUUID)
+	if err == nil {
+		return resolved, nil
+	}
+	// Tag names often contain spaces or punctuation, which are never a valid
+	// prefix; fall through to name lookup for those and for "not found",
+	// surfacing ambiguous prefix and DB errors immediately
+	if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrInvalidPrefix) {
+		return "", err
+	}
+
+	return db.GetTagUUIDByName(nameOrUUID)
+}
+
+// GetHeadingUUIDByName looks up a heading of a project by name, returns UUID
+// Returns ErrNotFound if nothing matches or an *AmbiguousError if several do
+func (db *ThingsDB) GetHeadingUUIDByName(projectUUID, name string) (string, error) {
+	query := `SELECT uuid FROM TMTask WHERE type = 2 AND trashed = 0 AND project = ? AND title = ?`
+	rows, err := db.conn.Query(query, projectUUID, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to query heading: %w", err)
+	}
+	defer rows.Close()
+
+	var uuids []string
+	for rows.Next() {
+		var uuid string
+		if err := rows.Scan(&uuid); err != nil {
+			return "", err
+		}
+		uuids = append(uuids, uuid)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("error iterating headings: %w", err)
+	}
+
+	if len(uuids) == 0 {
+		return "", notFound("heading", name)
+	}
+	if len(uuids) > 1 {
+		return "", newAmbiguousError("heading", name, true, uuids)
+	}
+	return uuids[0], nil
+}
+
+// ResolveHeadingID returns UUID for a heading given a full UUID, short UUID
+// prefix, or, when projectUUID is set, its name within that project. A
+// heading given by UUID must belong to projectUUID if that is set.
+func (db *ThingsDB) ResolveHeadingID(projectUUID, nameOrUUID string) (string, error) {
+	resolved, err := db.ResolveHeadingUUID(nameOrUUID)
+	if err == nil {
+		if projectUUID == "" {
+			return resolved, nil
+		}
+		heading, err := db.GetHeading(resolved)
+		if err != nil {
+			return "", err
+		}
+		if heading.ProjectUUID == projectUUID {
+			return resolved, nil
+		}
+	} else if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrInvalidPrefix) {
+		return "", err
+	}
+	// A prefix that matches no heading of the project may still be the
+	// heading's name; without a project there is nothing to look names up in
+	if projectUUID == "" {
+		return "", err
+	}
+
+	return db.GetHeadingUUIDByName(projectUUID, nameOrUUID)
+}
@@ -15,7 +15,7 @@ func scanTasks(rows *sql.Rows) ([]models.Task, error) {
 	for rows.Next() {
 		var task models.Task
 		var createdTS, modifiedTS, startTS, deadlineTS, completedTS sql.NullFloat64
-		var isRepeating int
+		var isRepeating, startBucket, checklistOpen int
 
 		err := rows.Scan(
 			&task.UUID,
@@ -36,6 +36,9 @@ func scanTasks(rows *sql.Rows) ([]models.Task, error) {
 			&task.Tags,
 			&isRepeating,
 			&task.TodayIndex,
+			&startBucket,
+			&task.ChecklistTotal,
+			&checklistOpen,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan task: %w", err)
@@ -47,6 +50,8 @@ func scanTasks(rows *sql.Rows) ([]models.Task, error) {
 		task.Deadline = thingsDateToNullTime(deadlineTS)
 		task.Completed = timestampToNullTime(completedTS)
 		task.IsRepeating = isRepeating == 1
+		task.Evening = startBucket == 1
+		task.ChecklistDone = task.ChecklistTotal - checklistOpen
 
 		tasks = append(tasks, task)
 	}
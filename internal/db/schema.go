@@ -0,0 +1,133 @@
+package db
+
+// Schema is the subset of the Things 3 database schema that thingies reads
+// and writes. Column names and types mirror main.sqlite so that a database
+// created from it can stand in for the real one (sandboxes, test fixtures).
+//
+// Date columns come in two flavours:
+//   - creationDate, userModificationDate, stopDate: Unix timestamps (REAL)
+//   - startDate, deadline, rt1_nextInstanceStartDate: packed dates (INTEGER),
+//     see DateToPackedInt
+const Schema = `
+CREATE TABLE IF NOT EXISTS TMTask (
+	uuid TEXT PRIMARY KEY,
+	leavesTombstone INTEGER DEFAULT 0,
+	creationDate REAL,
+	userModificationDate REAL,
+	type INTEGER DEFAULT 0,
+	status INTEGER DEFAULT 0,
+	stopDate REAL,
+	trashed INTEGER DEFAULT 0,
+	title TEXT DEFAULT '',
+	notes TEXT DEFAULT '',
+	notesSync INTEGER DEFAULT 0,
+	cachedTags BLOB,
+	start INTEGER DEFAULT 0,
+	startDate INTEGER,
+	startBucket INTEGER DEFAULT 0,
+	reminderTime INTEGER,
+	lastReminderInteractionDate REAL,
+	deadline INTEGER,
+	deadlineSuppressionDate INTEGER,
+	t2_deadlineOffset INTEGER DEFAULT 0,
+	"index" INTEGER DEFAULT 0,
+	todayIndex INTEGER DEFAULT 0,
+	todayIndexReferenceDate INTEGER,
+	area TEXT,
+	project TEXT,
+	heading TEXT,
+	contact TEXT,
+	untrashedLeafActionsCount INTEGER DEFAULT 0,
+	openUntrashedLeafActionsCount INTEGER DEFAULT 0,
+	checklistItemsCount INTEGER DEFAULT 0,
+	openChecklistItemsCount INTEGER DEFAULT 0,
+	rt1_repeatingTemplate TEXT,
+	rt1_recurrenceRule BLOB,
+	rt1_instanceCreationStartDate INTEGER,
+	rt1_instanceCreationPaused INTEGER DEFAULT 0,
+	rt1_instanceCreationCount INTEGER DEFAULT 0,
+	rt1_afterCompletionReferenceDate INTEGER,
+	rt1_nextInstanceStartDate INTEGER,
+	experimental BLOB,
+	repeater BLOB,
+	repeaterMigrationDate REAL
+);
+
+CREATE TABLE IF NOT EXISTS TMArea (
+	uuid TEXT PRIMARY KEY,
+	title TEXT DEFAULT '',
+	visible INTEGER,
+	"index" INTEGER DEFAULT 0,
+	cachedTags BLOB,
+	experimental BLOB
+);
+
+CREATE TABLE IF NOT EXISTS TMTag (
+	uuid TEXT PRIMARY KEY,
+	title TEXT DEFAULT '',
+	shortcut TEXT,
+	usedDate REAL,
+	parent TEXT,
+	"index" INTEGER DEFAULT 0,
+	experimental BLOB
+);
+
+CREATE TABLE IF NOT EXISTS TMTaskTag (
+	tasks TEXT NOT NULL,
+	tags TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS TMAreaTag (
+	areas TEXT NOT NULL,
+	tags TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS TMChecklistItem (
+	uuid TEXT PRIMARY KEY,
+	userModificationDate REAL,
+	creationDate REAL,
+	title TEXT DEFAULT '',
+	status INTEGER DEFAULT 0,
+	stopDate REAL,
+	"index" INTEGER DEFAULT 0,
+	task TEXT,
+	leavesTombstone INTEGER DEFAULT 0,
+	experimental BLOB
+);
+
+CREATE TABLE IF NOT EXISTS TMSettings (
+	uuid TEXT PRIMARY KEY,
+	logInterval INTEGER,
+	manualLogDate REAL,
+	groupTodayByParent INTEGER,
+	uriSchemeAuthenticationToken TEXT,
+	experimental BLOB
+);
+
+CREATE INDEX IF NOT EXISTS index_TMTask_project ON TMTask(project);
+CREATE INDEX IF NOT EXISTS index_TMTask_heading ON TMTask(heading);
+CREATE INDEX IF NOT EXISTS index_TMTask_area ON TMTask(area);
+CREATE INDEX IF NOT EXISTS index_TMTaskTag_tasks ON TMTaskTag(tasks);
+CREATE INDEX IF NOT EXISTS index_TMChecklistItem_task ON TMChecklistItem(task);
+`
+
+// Recount refreshes the counters Things caches on projects (leaf action
+// counts, read by ListProjects) and tasks (checklist item counts). Anything
+// that writes rows without going through the app must run it afterwards.
+const Recount = `
+UPDATE TMTask SET
+	untrashedLeafActionsCount = (
+		SELECT COUNT(*) FROM TMTask c LEFT JOIN TMTask h ON c.heading = h.uuid
+		WHERE c.type = 0 AND c.trashed = 0 AND (c.project = TMTask.uuid OR h.project = TMTask.uuid)
+	),
+	openUntrashedLeafActionsCount = (
+		SELECT COUNT(*) FROM TMTask c LEFT JOIN TMTask h ON c.heading = h.uuid
+		WHERE c.type = 0 AND c.trashed = 0 AND c.status = 0 AND (c.project = TMTask.uuid OR h.project = TMTask.uuid)
+	)
+WHERE type = 1;
+
+UPDATE TMTask SET
+	checklistItemsCount = (SELECT COUNT(*) FROM TMChecklistItem c WHERE c.task = TMTask.uuid),
+	openChecklistItemsCount = (SELECT COUNT(*) FROM TMChecklistItem c WHERE c.task = TMTask.uuid AND c.status = 0)
+WHERE type = 0;
+`
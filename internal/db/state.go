@@ -0,0 +1,172 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"thingies/internal/models"
+)
+
+// Kinds of item in a State
+const (
+	KindTask    = "task"
+	KindProject = "project"
+	KindHeading = "heading"
+	KindArea    = "area"
+	KindTag     = "tag"
+)
+
+// ItemState is the part of a task, project, heading, area, or tag that
+// change detection compares. Containers are UUIDs; a task under a heading
+// has the heading's project as Project.
+type ItemState struct {
+	Kind      string
+	UUID      string
+	Title     string
+	Notes     string
+	Status    models.TaskStatus
+	Trashed   bool
+	Area      string
+	Project   string
+	Heading   string
+	Start     int   // TMTask.start: 0 inbox, 1 anytime, 2 someday
+	StartDate int64 // packed date
+	Evening   bool
+	Deadline  int64 // packed date
+	Tags      string
+	Checklist string // "done/total", empty without a checklist
+	Parent    string // tags: parent tag UUID
+	Shortcut  string // tags
+	Modified  float64
+}
+
+// State is every task, project, heading, area, and tag, trashed ones
+// included, keyed by UUID. Diff two States to find what changed.
+type State map[string]ItemState
+
+// State reads the current State of the database
+func (db *ThingsDB) State() (State, error) {
+	tags, err := db.taskTagTitles()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT
+			t.uuid,
+			t.type,
+			COALESCE(t.title, ''),
+			COALESCE(t.notes, ''),
+			t.status,
+			t.trashed,
+			COALESCE(t.area, ''),
+			COALESCE(t.project, h.project, ''),
+			COALESCE(t.heading, ''),
+			COALESCE(t.start, 0),
+			COALESCE(t.startDate, 0),
+			COALESCE(t.startBucket, 0),
+			COALESCE(t.deadline, 0),
+			COALESCE(t.checklistItemsCount, 0),
+			COALESCE(t.openChecklistItemsCount, 0),
+			COALESCE(t.userModificationDate, 0)
+		FROM TMTask t
+		LEFT JOIN TMTask h ON t.heading = h.uuid
+		WHERE t.type IN (0, 1, 2)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query task state: %w", err)
+	}
+	defer rows.Close()
+
+	state := make(State)
+	for rows.Next() {
+		var s ItemState
+		var taskType models.TaskType
+		var bucket, checklistTotal, checklistOpen int
+		if err := rows.Scan(&s.UUID, &taskType, &s.Title, &s.Notes, &s.Status, &s.Trashed,
+			&s.Area, &s.Project, &s.Heading, &s.Start, &s.StartDate, &bucket, &s.Deadline,
+			&checklistTotal, &checklistOpen, &s.Modified); err != nil {
+			return nil, fmt.Errorf("failed to scan task state: %w", err)
+		}
+		switch taskType {
+		case models.TypeProject:
+			s.Kind = KindProject
+		case models.TypeHeading:
+			s.Kind = KindHeading
+		default:
+			s.Kind = KindTask
+		}
+		s.Evening = bucket == 1
+		s.Tags = tags[s.UUID]
+		if checklistTotal > 0 {
+			s.Checklist = fmt.Sprintf("%d/%d", checklistTotal-checklistOpen, checklistTotal)
+		}
+		state[s.UUID] = s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	if err := db.containerState(state, KindArea, `SELECT uuid, COALESCE(title, ''), '', '' FROM TMArea`); err != nil {
+		return nil, err
+	}
+	if err := db.containerState(state, KindTag, `SELECT uuid, COALESCE(title, ''), COALESCE(parent, ''), COALESCE(shortcut, '') FROM TMTag`); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// containerState adds the areas or tags selected by query (uuid, title,
+// parent, shortcut) to state
+func (db *ThingsDB) containerState(state State, kind, query string) error {
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return fmt.Errorf("failed to query %s state: %w", kind, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s := ItemState{Kind: kind}
+		if err := rows.Scan(&s.UUID, &s.Title, &s.Parent, &s.Shortcut); err != nil {
+			return fmt.Errorf("failed to scan %s state: %w", kind, err)
+		}
+		state[s.UUID] = s
+	}
+	return rows.Err()
+}
+
+// taskTagTitles returns each tagged item's own tag titles, sorted and joined
+// with ", "
+func (db *ThingsDB) taskTagTitles() (map[string]string, error) {
+	rows, err := db.conn.Query(`
+		SELECT tt.tasks, tag.title
+		FROM TMTaskTag tt
+		JOIN TMTag tag ON tt.tags = tag.uuid
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query task tags: %w", err)
+	}
+	defer rows.Close()
+
+	titles := make(map[string][]string)
+	for rows.Next() {
+		var uuid string
+		var title sql.NullString
+		if err := rows.Scan(&uuid, &title); err != nil {
+			return nil, fmt.Errorf("failed to scan task tag: %w", err)
+		}
+		titles[uuid] = append(titles[uuid], title.String)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	tags := make(map[string]string, len(titles))
+	for uuid, list := range titles {
+		sort.Strings(list)
+		tags[uuid] = strings.Join(list, ", ")
+	}
+	return tags, nil
+}
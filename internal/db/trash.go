@@ -0,0 +1,127 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"thingies/internal/models"
+)
+
+// trashSelect selects trashed tasks, projects, and headings with their
+// original area, project, and heading. Things only flags the item that was
+// deleted, so the children of a trashed project or heading are not listed.
+const trashSelect = `
+	SELECT
+		t.uuid,
+		t.title,
+		t.type,
+		t.status,
+		COALESCE(t.start, 0) as start,
+		t.startDate,
+		t.userModificationDate,
+		COALESCE(a.uuid, pa.uuid, hpa.uuid) as area_uuid,
+		COALESCE(a.title, pa.title, hpa.title) as area_name,
+		COALESCE(p.uuid, hp.uuid) as project_uuid,
+		COALESCE(p.title, hp.title) as project_name,
+		COALESCE(p.trashed, hp.trashed, 0) as project_trashed,
+		h.uuid as heading_uuid,
+		h.title as heading_name
+	FROM TMTask t
+	LEFT JOIN TMArea a ON t.area = a.uuid
+	LEFT JOIN TMTask p ON t.project = p.uuid AND p.type = 1
+	LEFT JOIN TMArea pa ON p.area = pa.uuid
+	LEFT JOIN TMTask h ON t.heading = h.uuid
+	LEFT JOIN TMTask hp ON h.project = hp.uuid AND hp.type = 1
+	LEFT JOIN TMArea hpa ON hp.area = hpa.uuid
+	WHERE t.trashed = 1 AND t.type IN (0, 1, 2)`
+
+// ListTrash returns the trashed tasks, projects, and headings, most recently
+// trashed first
+func (db *ThingsDB) ListTrash() ([]models.TrashItem, error) {
+	rows, err := db.conn.Query(trashSelect + `
+		ORDER BY t.userModificationDate DESC, t."index"
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trash: %w", err)
+	}
+	defer rows.Close()
+
+	var items []models.TrashItem
+	for rows.Next() {
+		item, err := scanTrashItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return items, nil
+}
+
+// GetTrashItem returns a single trashed item by UUID
+func (db *ThingsDB) GetTrashItem(uuid string) (*models.TrashItem, error) {
+	rows, err := db.conn.Query(trashSelect+` AND t.uuid = ?`, uuid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trash: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to query trash: %w", err)
+		}
+		return nil, notFound("trashed item", uuid)
+	}
+	return scanTrashItem(rows)
+}
+
+// ResolveTrashUUID resolves a short UUID prefix to the full UUID of a
+// trashed task, project, or heading
+func (db *ThingsDB) ResolveTrashUUID(prefix string) (string, error) {
+	if len(prefix) >= 22 {
+		var exists int
+		err := db.conn.QueryRow(`SELECT 1 FROM TMTask WHERE uuid = ? AND trashed = 1`, prefix).Scan(&exists)
+		if err == sql.ErrNoRows {
+			return "", notFound("trashed item", prefix)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to query trashed item: %w", err)
+		}
+		return prefix, nil
+	}
+
+	query := `SELECT uuid FROM TMTask WHERE uuid LIKE ? || '%' AND trashed = 1`
+	return resolvePrefix(db, query, prefix, "trashed item")
+}
+
+// scanTrashItem scans one row of trashSelect
+func scanTrashItem(rows *sql.Rows) (*models.TrashItem, error) {
+	var item models.TrashItem
+	var startTS, modifiedTS sql.NullFloat64
+	var projectTrashed int
+	err := rows.Scan(
+		&item.UUID,
+		&item.Title,
+		&item.Type,
+		&item.Status,
+		&item.Start,
+		&startTS,
+		&modifiedTS,
+		&item.AreaUUID,
+		&item.AreaName,
+		&item.ProjectUUID,
+		&item.ProjectName,
+		&projectTrashed,
+		&item.HeadingUUID,
+		&item.HeadingName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan trashed item: %w", err)
+	}
+	item.Scheduled = thingsDateToNullTime(startTS)
+	item.Trashed = timestampToNullTime(modifiedTS)
+	item.ProjectTrashed = projectTrashed == 1
+	return &item, nil
+}
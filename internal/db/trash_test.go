@@ -0,0 +1,90 @@
+package db_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"thingies/internal/db"
+	"thingies/internal/db/dbtest"
+)
+
+func TestListTrash(t *testing.T) {
+	b := dbtest.New(t)
+	work := b.Area("Work")
+	launch := b.Project("Launch").InArea(work)
+	prep := b.Heading(launch, "Prep").Trashed().Modified(time.Now().Add(-3 * time.Hour))
+	b.Task("Draft").InProject(launch).UnderHeading(prep)
+	b.Task("Old errand").Inbox().Trashed().Modified(time.Now().Add(-48 * time.Hour))
+	b.Task("Slides").InProject(launch).UnderHeading(prep).Trashed().Modified(time.Now().Add(-time.Hour))
+	b.Project("Retired").InArea(work).Someday().Trashed().Modified(time.Now().Add(-2 * time.Hour))
+	b.Task("Live")
+	thingsDB := b.Open()
+
+	items, err := thingsDB.ListTrash()
+	if err != nil {
+		t.Fatalf("ListTrash: %v", err)
+	}
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Title)
+	}
+	want := []string{"Slides", "Retired", "Prep", "Old errand"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v (most recent first), got %v", want, got)
+		}
+	}
+
+	slides := items[0]
+	if slides.ProjectName.String != "Launch" || slides.HeadingName.String != "Prep" || slides.AreaName.String != "Work" {
+		t.Errorf("expected Slides from Work > Launch > Prep, got %q > %q > %q",
+			slides.AreaName.String, slides.ProjectName.String, slides.HeadingName.String)
+	}
+	if items[1].List() != "Someday" || items[1].AreaUUID.String == "" {
+		t.Errorf("expected Retired from Someday in Work, got list %q area %q", items[1].List(), items[1].AreaUUID.String)
+	}
+	if items[2].ProjectName.String != "Launch" || items[2].List() != "" {
+		t.Errorf("expected heading Prep from Launch with no list, got %q, %q", items[2].ProjectName.String, items[2].List())
+	}
+	if items[3].List() != "Inbox" {
+		t.Errorf("expected Old errand from the Inbox, got %q", items[3].List())
+	}
+}
+
+func TestResolveTrashUUID(t *testing.T) {
+	b := dbtest.New(t)
+	trashed := b.Task("Gone").WithUUID("TrashedTask00000000000").Trashed()
+	b.Task("Here").WithUUID("LiveTask00000000000000")
+	thingsDB := b.Open()
+
+	if uuid, err := thingsDB.ResolveTrashUUID("Trashed"); err != nil || uuid != trashed.UUID {
+		t.Errorf("ResolveTrashUUID(prefix) = %q, %v", uuid, err)
+	}
+	if _, err := thingsDB.ResolveTrashUUID("LiveTask00000000000000"); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an untrashed task, got %v", err)
+	}
+	if _, err := thingsDB.GetTrashItem("LiveTask00000000000000"); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("expected GetTrashItem ErrNotFound for an untrashed task, got %v", err)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	for value, want := range map[string]time.Time{
+		"30d": dbtest.DaysFromToday(-30),
+		"-2w": dbtest.DaysFromToday(-14),
+	} {
+		got, err := db.ParseAge(value)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseAge(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"+3d", "ages", "2999-01-01"} {
+		if _, err := db.ParseAge(value); err == nil {
+			t.Errorf("ParseAge(%q): expected an error", value)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package models
+
+import "time"
+
+// Changes is what changed in the database after a point in time, as
+// returned by db.ThingsDB.Changes
+type Changes struct {
+	Cursor  string    // opaque; pass it back to get the changes after this call
+	Since   time.Time // the modification date the changes were taken after, zero for a full sync
+	Created ChangeSet
+	Updated ChangeSet
+	Deleted DeletedSet
+	Areas   []Area // the full area list when areas changed, nil otherwise
+	Tags    []Tag  // the full tag list when tags changed, nil otherwise
+}
+
+// ChangeSet holds the tasks, projects, and headings created or updated
+type ChangeSet struct {
+	Tasks    []Task
+	Projects []Project
+	Headings []Heading
+}
+
+// DeletedSet holds the UUIDs of trashed tasks, projects, and headings. The
+// tasks and headings of a trashed project are not listed on their own.
+type DeletedSet struct {
+	Tasks    []string `json:"tasks"`
+	Projects []string `json:"projects"`
+	Headings []string `json:"headings"`
+}
+
+// ChangesJSON is the JSON-serializable version of Changes. Areas and Tags
+// are null when they did not change.
+type ChangesJSON struct {
+	Cursor  string        `json:"cursor"`
+	Since   string        `json:"since,omitempty"`
+	Created ChangeSetJSON `json:"created"`
+	Updated ChangeSetJSON `json:"updated"`
+	Deleted DeletedSet    `json:"deleted"`
+	Areas   []Area        `json:"areas"`
+	Tags    []TagJSON     `json:"tags"`
+}
+
+// ChangeSetJSON is the JSON-serializable version of ChangeSet
+type ChangeSetJSON struct {
+	Tasks    []TaskJSON    `json:"tasks"`
+	Projects []ProjectJSON `json:"projects"`
+	Headings []Heading     `json:"headings"`
+}
+
+// Empty reports whether nothing changed
+func (c *Changes) Empty() bool {
+	return c.Created.Len() == 0 && c.Updated.Len() == 0 && c.Deleted.Len() == 0 &&
+		c.Areas == nil && c.Tags == nil
+}
+
+// Len returns the number of items in the set
+func (s *ChangeSet) Len() int {
+	return len(s.Tasks) + len(s.Projects) + len(s.Headings)
+}
+
+// Len returns the number of items in the set
+func (s *DeletedSet) Len() int {
+	return len(s.Tasks) + len(s.Projects) + len(s.Headings)
+}
+
+// ToJSON converts Changes to its JSON-serializable form. Empty sets become
+// empty arrays rather than null.
+func (c *Changes) ToJSON() ChangesJSON {
+	out := ChangesJSON{
+		Cursor:  c.Cursor,
+		Created: c.Created.ToJSON(),
+		Updated: c.Updated.ToJSON(),
+		Deleted: DeletedSet{
+			Tasks:    nonNil(c.Deleted.Tasks),
+			Projects: nonNil(c.Deleted.Projects),
+			Headings: nonNil(c.Deleted.Headings),
+		},
+	}
+	if !c.Since.IsZero() {
+		out.Since = c.Since.Format(time.RFC3339)
+	}
+	if c.Areas != nil {
+		out.Areas = make([]Area, 0, len(c.Areas))
+		out.Areas = append(out.Areas, c.Areas...)
+	}
+	if c.Tags != nil {
+		out.Tags = make([]TagJSON, 0, len(c.Tags))
+		for _, tag := range c.Tags {
+			out.Tags = append(out.Tags, tag.ToJSON())
+		}
+	}
+	return out
+}
+
+// ToJSON converts ChangeSet to its JSON-serializable form
+func (s *ChangeSet) ToJSON() ChangeSetJSON {
+	out := ChangeSetJSON{
+		Tasks:    make([]TaskJSON, 0, len(s.Tasks)),
+		Projects: make([]ProjectJSON, 0, len(s.Projects)),
+		Headings: make([]Heading, 0, len(s.Headings)),
+	}
+	for _, t := range s.Tasks {
+		out.Tasks = append(out.Tasks, t.ToJSON())
+	}
+	for _, p := range s.Projects {
+		out.Projects = append(out.Projects, p.ToJSON())
+	}
+	out.Headings = append(out.Headings, s.Headings...)
+	return out
+}
+
+func nonNil(uuids []string) []string {
+	if uuids == nil {
+		return []string{}
+	}
+	return uuids
+}
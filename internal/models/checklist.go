@@ -1,9 +1,27 @@
 package models
 
+import "fmt"
+
 // ChecklistItem represents a Things 3 checklist item within a task
 type ChecklistItem struct {
 	UUID      string `json:"uuid"`
 	Title     string `json:"title"`
 	Completed bool   `json:"completed"`
+	Canceled  bool   `json:"canceled,omitempty"`
 	Index     int    `json:"index"`
 }
+
+// Done reports whether the item no longer counts as open, the way Things
+// counts checklist progress
+func (c ChecklistItem) Done() bool {
+	return c.Completed || c.Canceled
+}
+
+// ChecklistProgress formats done/total checklist items, e.g. "3/7", or ""
+// when there is no checklist
+func ChecklistProgress(done, total int) string {
+	if total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", done, total)
+}
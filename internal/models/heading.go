@@ -2,7 +2,9 @@ package models
 
 // Heading represents a Things 3 heading (section within a project)
 type Heading struct {
-	UUID  string `json:"uuid"`
-	Title string `json:"title"`
-	Index int    `json:"index"`
+	UUID        string `json:"uuid"`
+	Title       string `json:"title"`
+	Index       int    `json:"index"`
+	Archived    bool   `json:"archived,omitempty"`
+	ProjectUUID string `json:"project_uuid,omitempty"`
 }
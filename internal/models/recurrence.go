@@ -0,0 +1,392 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Frequency is the unit a repeating task recurs in
+type Frequency string
+
+const (
+	Daily   Frequency = "daily"
+	Weekly  Frequency = "weekly"
+	Monthly Frequency = "monthly"
+	Yearly  Frequency = "yearly"
+)
+
+// Recurrence is the schedule of a repeating task, decoded from its
+// template's rt1_recurrenceRule. Dates are calendar days at UTC midnight,
+// like Task.Scheduled.
+type Recurrence struct {
+	Frequency       Frequency
+	Interval        int            // every Interval days, weeks, months, or years
+	Weekdays        []time.Weekday // weekly: the days; monthly/yearly: the day Ordinal counts
+	Ordinal         int            // monthly/yearly: 1-4 for "2nd Tuesday", -1 for the last; 0 if unset
+	DaysOfMonth     []int          // monthly/yearly: day numbers, -1 for the last day
+	Month           time.Month     // yearly; 0 means the month of Start
+	AfterCompletion bool           // counts from the last completion instead of a fixed schedule
+	Start           time.Time      // first day of the schedule
+	End             sql.NullTime   // no occurrences after this day
+	Count           int            // stops after this many occurrences; 0 for no limit
+}
+
+// maxRecurrencePeriods bounds the search in Next, so rules that can never
+// match (the 31st in a February-only schedule) still terminate
+const maxRecurrencePeriods = 10000
+
+// Describe renders the rule in plain language, e.g. "every 2 weeks on Mon, Thu"
+func (r *Recurrence) Describe() string {
+	interval := r.interval()
+	unit := map[Frequency]string{Daily: "day", Weekly: "week", Monthly: "month", Yearly: "year"}[r.Frequency]
+	if unit == "" {
+		unit = string(r.Frequency)
+	}
+
+	var sb strings.Builder
+	if interval == 1 {
+		fmt.Fprintf(&sb, "every %s", unit)
+	} else {
+		fmt.Fprintf(&sb, "every %d %ss", interval, unit)
+	}
+
+	if r.AfterCompletion {
+		sb.WriteString(" after completion")
+	} else {
+		switch r.Frequency {
+		case Weekly:
+			if len(r.Weekdays) > 0 {
+				sb.WriteString(" on " + joinWeekdays(r.Weekdays))
+			}
+		case Monthly:
+			if on := r.dayOfMonthPhrase(); on != "" {
+				sb.WriteString(" on " + on)
+			}
+		case Yearly:
+			month := r.Month
+			if month == 0 && !r.Start.IsZero() {
+				month = r.Start.Month()
+			}
+			if r.Ordinal != 0 && len(r.Weekdays) > 0 {
+				sb.WriteString(" on " + r.dayOfMonthPhrase())
+				if month != 0 {
+					sb.WriteString(" of " + month.String())
+				}
+			} else if month != 0 {
+				days := r.DaysOfMonth
+				if len(days) == 0 && !r.Start.IsZero() {
+					days = []int{r.Start.Day()}
+				}
+				parts := make([]string, len(days))
+				for i, d := range days {
+					if d == -1 {
+						parts[i] = "the last day of " + month.String()
+					} else {
+						parts[i] = fmt.Sprintf("%s %d", month, d)
+					}
+				}
+				if len(parts) > 0 {
+					sb.WriteString(" on " + strings.Join(parts, ", "))
+				}
+			}
+		}
+	}
+
+	if r.Count > 0 {
+		fmt.Fprintf(&sb, ", %d times", r.Count)
+	}
+	if r.End.Valid {
+		sb.WriteString(" until " + r.End.Time.Format("2006-01-02"))
+	}
+	return sb.String()
+}
+
+// dayOfMonthPhrase renders the day part of a monthly rule, e.g. "the 1st, 15th"
+// or "the last Friday"
+func (r *Recurrence) dayOfMonthPhrase() string {
+	if r.Ordinal != 0 && len(r.Weekdays) > 0 {
+		names := make([]string, len(r.Weekdays))
+		for i, wd := range r.Weekdays {
+			names[i] = wd.String()
+		}
+		return "the " + ordinal(r.Ordinal) + " " + strings.Join(names, ", ")
+	}
+	if len(r.DaysOfMonth) == 0 {
+		return ""
+	}
+	parts := make([]string, len(r.DaysOfMonth))
+	for i, d := range r.DaysOfMonth {
+		if d == -1 {
+			parts[i] = "last day"
+		} else {
+			parts[i] = ordinal(d)
+		}
+	}
+	return "the " + strings.Join(parts, ", ")
+}
+
+// Next returns up to n occurrences falling after the day of after. For an
+// after-completion rule each occurrence assumes the one before it was
+// completed on its day, so the projection is a best case.
+func (r *Recurrence) Next(after time.Time, n int) []time.Time {
+	if n <= 0 {
+		return nil
+	}
+	from := dateOf(after)
+	interval := r.interval()
+	var out []time.Time
+
+	if r.AfterCompletion {
+		day := from
+		for len(out) < n && (r.Count == 0 || len(out) < r.Count) {
+			day = r.advance(day, interval)
+			if r.End.Valid && day.After(dateOf(r.End.Time)) {
+				break
+			}
+			out = append(out, day)
+		}
+		return out
+	}
+
+	start := dateOf(r.Start)
+	if r.Start.IsZero() {
+		start = from
+	}
+
+	// Without a count limit, earlier periods can be skipped
+	first := 0
+	if r.Count == 0 && from.After(start) {
+		first = r.periodsBetween(start, from)
+		first -= first % interval
+	}
+
+	seen := 0
+	for period := first; period < first+maxRecurrencePeriods*interval; period += interval {
+		for _, day := range r.occurrencesIn(start, period) {
+			if day.Before(start) {
+				continue
+			}
+			if r.End.Valid && day.After(dateOf(r.End.Time)) {
+				return out
+			}
+			seen++
+			if r.Count > 0 && seen > r.Count {
+				return out
+			}
+			if day.After(from) {
+				out = append(out, day)
+				if len(out) == n {
+					return out
+				}
+			}
+		}
+	}
+	return out
+}
+
+func (r *Recurrence) interval() int {
+	if r.Interval < 1 {
+		return 1
+	}
+	return r.Interval
+}
+
+// advance moves day forward by interval units of the rule's frequency
+func (r *Recurrence) advance(day time.Time, interval int) time.Time {
+	switch r.Frequency {
+	case Weekly:
+		return day.AddDate(0, 0, 7*interval)
+	case Monthly:
+		return day.AddDate(0, interval, 0)
+	case Yearly:
+		return day.AddDate(interval, 0, 0)
+	default:
+		return day.AddDate(0, 0, interval)
+	}
+}
+
+// periodsBetween counts whole periods from the one containing start to the
+// one containing day
+func (r *Recurrence) periodsBetween(start, day time.Time) int {
+	switch r.Frequency {
+	case Weekly:
+		return int(weekStart(day).Sub(weekStart(start)).Hours()/24) / 7
+	case Monthly:
+		return (day.Year()-start.Year())*12 + int(day.Month()) - int(start.Month())
+	case Yearly:
+		return day.Year() - start.Year()
+	default:
+		return int(day.Sub(start).Hours() / 24)
+	}
+}
+
+// occurrencesIn returns the rule's days in the given period after start's,
+// in order
+func (r *Recurrence) occurrencesIn(start time.Time, period int) []time.Time {
+	switch r.Frequency {
+	case Weekly:
+		base := weekStart(start).AddDate(0, 0, 7*period)
+		weekdays := r.Weekdays
+		if len(weekdays) == 0 {
+			weekdays = []time.Weekday{start.Weekday()}
+		}
+		days := make([]time.Time, 0, len(weekdays))
+		for wd := time.Sunday; wd <= time.Saturday; wd++ {
+			for _, want := range weekdays {
+				if wd == want {
+					days = append(days, base.AddDate(0, 0, int(wd)))
+					break
+				}
+			}
+		}
+		return days
+	case Monthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(period), 1, 0, 0, 0, 0, time.UTC)
+		return r.daysInMonth(first.Year(), first.Month(), start)
+	case Yearly:
+		month := r.Month
+		if month == 0 {
+			month = start.Month()
+		}
+		return r.daysInMonth(start.Year()+period, month, start)
+	default:
+		return []time.Time{start.AddDate(0, 0, period)}
+	}
+}
+
+// daysInMonth returns the rule's days in one month, in order
+func (r *Recurrence) daysInMonth(year int, month time.Month, start time.Time) []time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	var numbers []int
+	if r.Ordinal != 0 && len(r.Weekdays) > 0 {
+		for _, wd := range r.Weekdays {
+			if d := nthWeekday(year, month, wd, r.Ordinal); d > 0 {
+				numbers = append(numbers, d)
+			}
+		}
+	} else {
+		days := r.DaysOfMonth
+		if len(days) == 0 {
+			days = []int{start.Day()}
+		}
+		for _, d := range days {
+			if d == -1 {
+				d = last
+			}
+			if d >= 1 && d <= last {
+				numbers = append(numbers, d)
+			}
+		}
+	}
+
+	out := make([]time.Time, 0, len(numbers))
+	for d := 1; d <= last; d++ {
+		for _, n := range numbers {
+			if n == d {
+				out = append(out, time.Date(year, month, d, 0, 0, 0, 0, time.UTC))
+				break
+			}
+		}
+	}
+	return out
+}
+
+// nthWeekday returns the day of the nth (or, for -1, last) wd in the month,
+// or 0 if the month has no such day
+func nthWeekday(year int, month time.Month, wd time.Weekday, n int) int {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+	if n < 0 {
+		return last.Day() - (int(last.Weekday())-int(wd)+7)%7
+	}
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	day := 1 + (int(wd)-int(first.Weekday())+7)%7 + 7*(n-1)
+	if day > last.Day() {
+		return 0
+	}
+	return day
+}
+
+// dateOf returns t's calendar day at UTC midnight
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// weekStart returns the Sunday starting day's week
+func weekStart(day time.Time) time.Time {
+	return day.AddDate(0, 0, -int(day.Weekday()))
+}
+
+func joinWeekdays(weekdays []time.Weekday) string {
+	names := make([]string, len(weekdays))
+	for i, wd := range weekdays {
+		names[i] = wd.String()[:3]
+	}
+	return strings.Join(names, ", ")
+}
+
+// ordinal renders 1 as "1st", 22 as "22nd", and -1 as "last"
+func ordinal(n int) string {
+	if n == -1 {
+		return "last"
+	}
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// RecurrenceJSON is the JSON-serializable version of Recurrence
+type RecurrenceJSON struct {
+	Frequency       string   `json:"frequency"`
+	Interval        int      `json:"interval"`
+	Weekdays        []string `json:"weekdays,omitempty"`
+	Ordinal         int      `json:"weekday_ordinal,omitempty"`
+	DaysOfMonth     []int    `json:"days_of_month,omitempty"`
+	Month           int      `json:"month,omitempty"`
+	AfterCompletion bool     `json:"after_completion"`
+	Start           string   `json:"start,omitempty"`
+	End             string   `json:"end,omitempty"`
+	Count           int      `json:"count,omitempty"`
+	Description     string   `json:"description"`
+	Next            []string `json:"next,omitempty"` // next occurrences from today, fixed schedules only
+}
+
+// recurrenceJSONNext is how many upcoming occurrences ToJSON includes
+const recurrenceJSONNext = 3
+
+// ToJSON converts Recurrence to its JSON-serializable form
+func (r *Recurrence) ToJSON() *RecurrenceJSON {
+	j := &RecurrenceJSON{
+		Frequency:       string(r.Frequency),
+		Interval:        r.interval(),
+		Ordinal:         r.Ordinal,
+		DaysOfMonth:     r.DaysOfMonth,
+		Month:           int(r.Month),
+		AfterCompletion: r.AfterCompletion,
+		End:             formatDate(r.End),
+		Count:           r.Count,
+		Description:     r.Describe(),
+	}
+	for _, wd := range r.Weekdays {
+		j.Weekdays = append(j.Weekdays, wd.String()[:3])
+	}
+	if !r.Start.IsZero() {
+		j.Start = r.Start.Format("2006-01-02")
+	}
+	if !r.AfterCompletion {
+		for _, day := range r.Next(time.Now(), recurrenceJSONNext) {
+			j.Next = append(j.Next, day.Format("2006-01-02"))
+		}
+	}
+	return j
+}
@@ -4,18 +4,22 @@ import "database/sql"
 
 // Tag represents a Things 3 tag
 type Tag struct {
-	UUID      string         `json:"uuid"`
-	Title     string         `json:"title"`
-	Shortcut  sql.NullString `json:"shortcut,omitempty"`
-	TaskCount int            `json:"task_count"`
+	UUID       string         `json:"uuid"`
+	Title      string         `json:"title"`
+	Shortcut   sql.NullString `json:"shortcut,omitempty"`
+	ParentUUID sql.NullString `json:"parent_uuid,omitempty"`
+	TaskCount  int            `json:"task_count"`
+	Children   []Tag          `json:"children,omitempty"` // filled only by tree listings
 }
 
 // TagJSON is the JSON-serializable version of Tag
 type TagJSON struct {
-	UUID      string `json:"uuid"`
-	Title     string `json:"title"`
-	Shortcut  string `json:"shortcut,omitempty"`
-	TaskCount int    `json:"task_count"`
+	UUID       string    `json:"uuid"`
+	Title      string    `json:"title"`
+	Shortcut   string    `json:"shortcut,omitempty"`
+	ParentUUID string    `json:"parent_uuid,omitempty"`
+	TaskCount  int       `json:"task_count"`
+	Children   []TagJSON `json:"children,omitempty"`
 }
 
 // ToJSON converts Tag to its JSON-serializable form
@@ -24,10 +28,16 @@ func (t *Tag) ToJSON() TagJSON {
 	if t.Shortcut.Valid {
 		shortcut = t.Shortcut.String
 	}
+	var children []TagJSON
+	for _, child := range t.Children {
+		children = append(children, child.ToJSON())
+	}
 	return TagJSON{
-		UUID:      t.UUID,
-		Title:     t.Title,
-		Shortcut:  shortcut,
-		TaskCount: t.TaskCount,
+		UUID:       t.UUID,
+		Title:      t.Title,
+		Shortcut:   shortcut,
+		ParentUUID: nullString(t.ParentUUID),
+		TaskCount:  t.TaskCount,
+		Children:   children,
 	}
 }
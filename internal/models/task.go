@@ -25,7 +25,12 @@ type Task struct {
 	Tags           sql.NullString  `json:"tags,omitempty"`
 	IsRepeating    bool            `json:"is_repeating"`
 	TodayIndex     sql.NullInt64   `json:"today_index,omitempty"`
+	Evening        bool            `json:"evening"` // in This Evening (TMTask.startBucket = 1)
 	ChecklistItems []ChecklistItem `json:"checklist_items,omitempty"`
+	ChecklistTotal int             `json:"checklist_total"`      // from TMTask.checklistItemsCount
+	ChecklistDone  int             `json:"checklist_done"`       // completed or canceled checklist items
+	Recurrence     *Recurrence     `json:"recurrence,omitempty"` // set by callers that load it, see db.GetTaskRecurrence
+	Projected      bool            `json:"projected"`            // a future occurrence computed from a repeating template, not a row
 }
 
 // TaskJSON is the JSON-serializable version of Task
@@ -47,11 +52,19 @@ type TaskJSON struct {
 	HeadingName    string          `json:"heading_name,omitempty"`
 	Tags           string          `json:"tags,omitempty"`
 	IsRepeating    bool            `json:"is_repeating"`
+	Evening        bool            `json:"evening,omitempty"`
 	ChecklistItems []ChecklistItem `json:"checklist_items,omitempty"`
+	Checklist      string          `json:"checklist,omitempty"` // progress, e.g. "3/7"
+	Recurrence     *RecurrenceJSON `json:"recurrence,omitempty"`
+	Projected      bool            `json:"projected,omitempty"`
 }
 
 // ToJSON converts Task to its JSON-serializable form
 func (t *Task) ToJSON() TaskJSON {
+	var recurrence *RecurrenceJSON
+	if t.Recurrence != nil {
+		recurrence = t.Recurrence.ToJSON()
+	}
 	return TaskJSON{
 		UUID:           t.UUID,
 		Title:          t.Title,
@@ -70,7 +83,11 @@ func (t *Task) ToJSON() TaskJSON {
 		HeadingName:    nullString(t.HeadingName),
 		Tags:           nullString(t.Tags),
 		IsRepeating:    t.IsRepeating,
+		Evening:        t.Evening,
 		ChecklistItems: t.ChecklistItems,
+		Checklist:      ChecklistProgress(t.ChecklistDone, t.ChecklistTotal),
+		Recurrence:     recurrence,
+		Projected:      t.Projected,
 	}
 }
 
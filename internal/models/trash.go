@@ -0,0 +1,86 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// TrashItem is a trashed task, project, or heading together with the
+// container it was trashed from
+type TrashItem struct {
+	UUID           string
+	Title          string
+	Type           TaskType
+	Status         TaskStatus
+	Start          int          // TMTask.start: 0 inbox, 1 anytime, 2 someday
+	Scheduled      sql.NullTime // start date, for items trashed from Today or Upcoming
+	Trashed        sql.NullTime // approximated by the modification date, which trashing bumps
+	AreaUUID       sql.NullString
+	AreaName       sql.NullString
+	ProjectUUID    sql.NullString
+	ProjectName    sql.NullString
+	ProjectTrashed bool // the project is in the trash too
+	HeadingUUID    sql.NullString
+	HeadingName    sql.NullString
+}
+
+// List returns the built-in list the item was in: Inbox, Today, Upcoming,
+// Anytime, or Someday. Headings belong to no list and return "".
+func (t *TrashItem) List() string {
+	if t.Type == TypeHeading {
+		return ""
+	}
+	// Things dates scan as UTC midnight of the local calendar day
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch {
+	case t.Start == 0:
+		return "Inbox"
+	case t.Scheduled.Valid && t.Scheduled.Time.After(today):
+		return "Upcoming"
+	case t.Scheduled.Valid:
+		return "Today"
+	case t.Start == 2:
+		return "Someday"
+	default:
+		return "Anytime"
+	}
+}
+
+// TrashItemJSON is the JSON-serializable version of TrashItem
+type TrashItemJSON struct {
+	UUID           string `json:"uuid"`
+	Title          string `json:"title"`
+	Type           string `json:"type"`
+	Status         string `json:"status"`
+	List           string `json:"list,omitempty"`
+	Scheduled      string `json:"scheduled,omitempty"`
+	Trashed        string `json:"trashed,omitempty"`
+	AreaUUID       string `json:"area_uuid,omitempty"`
+	AreaName       string `json:"area_name,omitempty"`
+	ProjectUUID    string `json:"project_uuid,omitempty"`
+	ProjectName    string `json:"project_name,omitempty"`
+	ProjectTrashed bool   `json:"project_trashed,omitempty"`
+	HeadingUUID    string `json:"heading_uuid,omitempty"`
+	HeadingName    string `json:"heading_name,omitempty"`
+}
+
+// ToJSON converts TrashItem to its JSON-serializable form
+func (t *TrashItem) ToJSON() TrashItemJSON {
+	return TrashItemJSON{
+		UUID:           t.UUID,
+		Title:          t.Title,
+		Type:           t.Type.String(),
+		Status:         t.Status.String(),
+		List:           t.List(),
+		Scheduled:      formatDate(t.Scheduled),
+		Trashed:        formatTime(t.Trashed),
+		AreaUUID:       nullString(t.AreaUUID),
+		AreaName:       nullString(t.AreaName),
+		ProjectUUID:    nullString(t.ProjectUUID),
+		ProjectName:    nullString(t.ProjectName),
+		ProjectTrashed: t.ProjectTrashed,
+		HeadingUUID:    nullString(t.HeadingUUID),
+		HeadingName:    nullString(t.HeadingName),
+	}
+}
@@ -7,11 +7,15 @@ import (
 // Formatter defines the interface for output formatting
 type Formatter interface {
 	FormatTasks(tasks []models.Task) error
+	FormatToday(tasks []models.Task) error // split into Today and This Evening
 	FormatTask(task *models.Task) error
+	FormatChecklist(items []models.ChecklistItem) error
 	FormatProjects(projects []models.Project) error
-	FormatProject(project *models.Project, tasks []models.Task) error
+	FormatProject(project *models.Project, headings []models.Heading, tasks []models.Task) error
+	FormatHeadings(headings []models.Heading) error
 	FormatAreas(areas []models.Area) error
 	FormatArea(area *models.Area, projects []models.Project, tasks []models.Task) error
 	FormatTags(tags []models.Tag) error
+	FormatTagTree(roots []models.Tag) error
 	FormatSearchResults(tasks []models.Task, term string) error
 }
@@ -33,11 +33,38 @@ func (f *JSONFormatter) FormatTasks(tasks []models.Task) error {
 	return f.output(result)
 }
 
+// TodayJSON is the today view split into Today and This Evening
+type TodayJSON struct {
+	Today   []models.TaskJSON `json:"today"`
+	Evening []models.TaskJSON `json:"evening"`
+}
+
+// FormatToday formats the today view as JSON, with This Evening separate
+func (f *JSONFormatter) FormatToday(tasks []models.Task) error {
+	result := TodayJSON{Today: []models.TaskJSON{}, Evening: []models.TaskJSON{}}
+	for _, t := range tasks {
+		if t.Evening {
+			result.Evening = append(result.Evening, t.ToJSON())
+		} else {
+			result.Today = append(result.Today, t.ToJSON())
+		}
+	}
+	return f.output(result)
+}
+
 // FormatTask formats a single task as JSON
 func (f *JSONFormatter) FormatTask(task *models.Task) error {
 	return f.output(task.ToJSON())
 }
 
+// FormatChecklist formats a task's checklist items as JSON
+func (f *JSONFormatter) FormatChecklist(items []models.ChecklistItem) error {
+	if items == nil {
+		items = []models.ChecklistItem{}
+	}
+	return f.output(items)
+}
+
 // FormatProjects formats projects as JSON
 func (f *JSONFormatter) FormatProjects(projects []models.Project) error {
 	result := make([]models.ProjectJSON, len(projects))
@@ -47,23 +74,38 @@ func (f *JSONFormatter) FormatProjects(projects []models.Project) error {
 	return f.output(result)
 }
 
-// FormatProject formats a single project with tasks as JSON
-func (f *JSONFormatter) FormatProject(project *models.Project, tasks []models.Task) error {
+// FormatProject formats a single project with headings and tasks as JSON.
+// Tasks stay one list; heading_uuid says which heading each is under.
+func (f *JSONFormatter) FormatProject(project *models.Project, headings []models.Heading, tasks []models.Task) error {
 	taskResults := make([]models.TaskJSON, len(tasks))
 	for i, t := range tasks {
 		taskResults[i] = t.ToJSON()
 	}
 
+	if headings == nil {
+		headings = []models.Heading{}
+	}
+
 	result := struct {
 		models.ProjectJSON
-		Tasks []models.TaskJSON `json:"tasks"`
+		Headings []models.Heading  `json:"headings"`
+		Tasks    []models.TaskJSON `json:"tasks"`
 	}{
 		ProjectJSON: project.ToJSON(),
+		Headings:    headings,
 		Tasks:       taskResults,
 	}
 	return f.output(result)
 }
 
+// FormatHeadings formats headings as JSON
+func (f *JSONFormatter) FormatHeadings(headings []models.Heading) error {
+	if headings == nil {
+		headings = []models.Heading{}
+	}
+	return f.output(headings)
+}
+
 // FormatAreas formats areas as JSON
 func (f *JSONFormatter) FormatAreas(areas []models.Area) error {
 	return f.output(areas)
@@ -102,6 +144,11 @@ func (f *JSONFormatter) FormatTags(tags []models.Tag) error {
 	return f.output(result)
 }
 
+// FormatTagTree formats nested tags as JSON, children under each parent
+func (f *JSONFormatter) FormatTagTree(roots []models.Tag) error {
+	return f.FormatTags(roots)
+}
+
 // FormatSearchResults formats search results as JSON
 func (f *JSONFormatter) FormatSearchResults(tasks []models.Task, term string) error {
 	result := make([]models.TaskJSON, len(tasks))
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"thingies/internal/models"
@@ -20,9 +21,19 @@ var (
 	dim         = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
 )
 
+// Highlight marks a row of a task list that just changed
+type Highlight int
+
+const (
+	HighlightNone      Highlight = iota
+	HighlightAdded               // the task just appeared in the list
+	HighlightCompleted           // the task was just completed or canceled
+)
+
 // TableFormatter formats output as tables
 type TableFormatter struct {
-	noColor bool
+	noColor    bool
+	highlights map[string]Highlight // by task UUID; nil outside watch mode
 }
 
 // NewTableFormatter creates a new TableFormatter
@@ -30,6 +41,23 @@ func NewTableFormatter(noColor bool) *TableFormatter {
 	return &TableFormatter{noColor: noColor}
 }
 
+// SetHighlights marks task rows by UUID in the task lists that follow. Once
+// set, even an empty map, every row gets a two-column marker gutter so that
+// rows line up whether marked or not. nil turns the gutter off.
+func (f *TableFormatter) SetHighlights(highlights map[string]Highlight) {
+	f.highlights = highlights
+}
+
+// FormatStatus prints a status line, in red if failed
+func (f *TableFormatter) FormatStatus(status string, failed bool) error {
+	if failed {
+		fmt.Println(f.style(red, status))
+	} else {
+		fmt.Println(f.style(dim, status))
+	}
+	return nil
+}
+
 func (f *TableFormatter) style(s lipgloss.Style, text string) string {
 	if f.noColor {
 		return text
@@ -45,51 +73,111 @@ func (f *TableFormatter) FormatTasks(tasks []models.Task) error {
 	}
 
 	for _, task := range tasks {
-		// Show short ID (first 8 chars of UUID)
-		shortID := task.UUID
-		if len(shortID) > 8 {
-			shortID = shortID[:8]
-		}
+		fmt.Println(f.taskLine(task))
+	}
 
-		status := models.TaskStatus(task.Status).Icon()
-		if task.IsRepeating {
-			status += " 🔁"
-		}
+	fmt.Println(f.style(dim, fmt.Sprintf("\n%d task(s)", len(tasks))))
+	return nil
+}
 
-		// Build context parts
-		var context []string
-		// Show Area > Project > Heading hierarchy
-		var hierarchy []string
-		if task.AreaName.Valid && task.AreaName.String != "" {
-			hierarchy = append(hierarchy, f.style(magenta, task.AreaName.String))
-		}
-		if task.ProjectName.Valid && task.ProjectName.String != "" {
-			hierarchy = append(hierarchy, f.style(blue, task.ProjectName.String))
-		}
-		if task.HeadingName.Valid && task.HeadingName.String != "" {
-			hierarchy = append(hierarchy, f.style(cyan, task.HeadingName.String))
-		}
-		if len(hierarchy) > 0 {
-			context = append(context, strings.Join(hierarchy, f.style(dim, " > ")))
-		}
-		if task.Deadline.Valid {
-			context = append(context, f.style(red, "due ")+f.style(red, task.Deadline.Time.Format("2006-01-02")))
-		}
-		if task.Tags.Valid && task.Tags.String != "" {
-			context = append(context, f.style(yellow, task.Tags.String))
+// FormatToday formats the today view, with This Evening in its own section
+func (f *TableFormatter) FormatToday(tasks []models.Task) error {
+	var today, evening []models.Task
+	for _, task := range tasks {
+		if task.Evening {
+			evening = append(evening, task)
+		} else {
+			today = append(today, task)
 		}
+	}
+	if len(evening) == 0 {
+		return f.FormatTasks(tasks)
+	}
 
-		line := fmt.Sprintf("%s %s %s", f.style(dim, shortID), f.style(green, status), f.style(cyan, task.Title))
-		if len(context) > 0 {
-			line += " " + f.style(dim, "(") + strings.Join(context, f.style(dim, ", ")) + f.style(dim, ")")
+	if len(today) > 0 {
+		fmt.Println(f.style(headerStyle, "Today"))
+		for _, task := range today {
+			fmt.Println(f.taskLine(task))
 		}
-		fmt.Println(line)
+		fmt.Println()
+	}
+	fmt.Println(f.style(headerStyle, "This Evening"))
+	for _, task := range evening {
+		fmt.Println(f.taskLine(task))
 	}
 
 	fmt.Println(f.style(dim, fmt.Sprintf("\n%d task(s)", len(tasks))))
 	return nil
 }
 
+// taskLine renders one task of a task list
+func (f *TableFormatter) taskLine(task models.Task) string {
+	// Show short ID (first 8 chars of UUID)
+	shortID := task.UUID
+	if len(shortID) > 8 {
+		shortID = shortID[:8]
+	}
+
+	status := models.TaskStatus(task.Status).Icon()
+	if task.IsRepeating {
+		status += " 🔁"
+	}
+
+	// Build context parts
+	var context []string
+	// Show Area > Project > Heading hierarchy
+	var hierarchy []string
+	if task.AreaName.Valid && task.AreaName.String != "" {
+		hierarchy = append(hierarchy, f.style(magenta, task.AreaName.String))
+	}
+	if task.ProjectName.Valid && task.ProjectName.String != "" {
+		hierarchy = append(hierarchy, f.style(blue, task.ProjectName.String))
+	}
+	if task.HeadingName.Valid && task.HeadingName.String != "" {
+		hierarchy = append(hierarchy, f.style(cyan, task.HeadingName.String))
+	}
+	if len(hierarchy) > 0 {
+		context = append(context, strings.Join(hierarchy, f.style(dim, " > ")))
+	}
+	if task.Deadline.Valid {
+		context = append(context, f.style(red, "due ")+f.style(red, task.Deadline.Time.Format("2006-01-02")))
+	}
+	if task.Tags.Valid && task.Tags.String != "" {
+		context = append(context, f.style(yellow, task.Tags.String))
+	}
+	if progress := models.ChecklistProgress(task.ChecklistDone, task.ChecklistTotal); progress != "" {
+		context = append(context, f.style(green, "☑ "+progress))
+	}
+
+	title := f.style(cyan, task.Title)
+	switch f.highlights[task.UUID] {
+	case HighlightAdded:
+		title = f.style(cyan.Reverse(true), task.Title)
+	case HighlightCompleted:
+		title = f.style(dim.Strikethrough(true), task.Title)
+	}
+
+	line := fmt.Sprintf("%s %s %s", f.style(dim, shortID), f.style(green, status), title)
+	if len(context) > 0 {
+		line += " " + f.style(dim, "(") + strings.Join(context, f.style(dim, ", ")) + f.style(dim, ")")
+	}
+	if f.highlights != nil {
+		line = f.gutter(task.UUID) + line
+	}
+	return line
+}
+
+// gutter returns the marker column for a task row in watch mode
+func (f *TableFormatter) gutter(uuid string) string {
+	switch f.highlights[uuid] {
+	case HighlightAdded:
+		return f.style(green.Bold(true), "+") + " "
+	case HighlightCompleted:
+		return f.style(green.Bold(true), "✓") + " "
+	}
+	return "  "
+}
+
 // FormatUpcoming formats upcoming tasks with scheduled dates
 func (f *TableFormatter) FormatUpcoming(tasks []models.Task) error {
 	if len(tasks) == 0 {
@@ -117,6 +205,11 @@ func (f *TableFormatter) FormatUpcoming(tasks []models.Task) error {
 
 		// Build context parts
 		var context []string
+		if task.Projected {
+			// Not in Things yet; computed from the repeating task's rule
+			status = "◌ 🔁"
+			context = append(context, f.style(dim, "projected"))
+		}
 		// Show Area > Project > Heading hierarchy
 		var hierarchy []string
 		if task.AreaName.Valid && task.AreaName.String != "" {
@@ -137,6 +230,9 @@ func (f *TableFormatter) FormatUpcoming(tasks []models.Task) error {
 		if task.Tags.Valid && task.Tags.String != "" {
 			context = append(context, f.style(yellow, task.Tags.String))
 		}
+		if progress := models.ChecklistProgress(task.ChecklistDone, task.ChecklistTotal); progress != "" {
+			context = append(context, f.style(green, "☑ "+progress))
+		}
 
 		var line string
 		if scheduled != "" {
@@ -206,6 +302,120 @@ func (f *TableFormatter) FormatLogbook(tasks []models.Task) error {
 	return nil
 }
 
+// FormatTrash formats trashed items with the date they were trashed and the
+// container they came from
+func (f *TableFormatter) FormatTrash(items []models.TrashItem) error {
+	if len(items) == 0 {
+		fmt.Println(f.style(yellow, "Trash is empty"))
+		return nil
+	}
+
+	for _, item := range items {
+		shortID := item.UUID
+		if len(shortID) > 8 {
+			shortID = shortID[:8]
+		}
+
+		trashed := "          "
+		if item.Trashed.Valid {
+			trashed = item.Trashed.Time.Format("2006-01-02")
+		}
+
+		var hierarchy []string
+		if item.AreaName.Valid && item.AreaName.String != "" {
+			hierarchy = append(hierarchy, f.style(magenta, item.AreaName.String))
+		}
+		if item.ProjectName.Valid && item.ProjectName.String != "" {
+			project := item.ProjectName.String
+			if item.ProjectTrashed {
+				project += " 🗑"
+			}
+			hierarchy = append(hierarchy, f.style(blue, project))
+		}
+		if item.HeadingName.Valid && item.HeadingName.String != "" {
+			hierarchy = append(hierarchy, f.style(cyan, item.HeadingName.String))
+		}
+		if len(hierarchy) == 0 && item.List() != "" {
+			hierarchy = append(hierarchy, f.style(yellow, item.List()))
+		}
+
+		title := f.style(cyan, item.Title)
+		if item.Type != models.TypeTask {
+			title = f.style(headerStyle, item.Title)
+		}
+		line := fmt.Sprintf("%s %s %s %s",
+			f.style(red, trashed),
+			f.style(dim, shortID),
+			f.style(dim, fmt.Sprintf("%-7s", strings.ToLower(item.Type.String()))),
+			title)
+		if len(hierarchy) > 0 {
+			line += " " + f.style(dim, "(") + strings.Join(hierarchy, f.style(dim, " > ")) + f.style(dim, ")")
+		}
+		fmt.Println(line)
+	}
+
+	fmt.Println(f.style(dim, fmt.Sprintf("\n%d trashed item(s)", len(items))))
+	return nil
+}
+
+// FormatChanges formats a change feed: created items marked +, updated ~,
+// and trashed -, followed by the cursor to pass to the next call
+func (f *TableFormatter) FormatChanges(changes *models.Changes) error {
+	line := func(mark string, markStyle lipgloss.Style, kind, uuid, title string) {
+		shortID := uuid
+		if len(shortID) > 8 {
+			shortID = shortID[:8]
+		}
+		fmt.Printf("%s %s %s %s\n", f.style(markStyle, mark), f.style(dim, shortID), f.style(dim, fmt.Sprintf("%-7s", kind)), title)
+	}
+	sets := []struct {
+		mark  string
+		style lipgloss.Style
+		set   models.ChangeSet
+	}{
+		{"+", green, changes.Created},
+		{"~", yellow, changes.Updated},
+	}
+	for _, s := range sets {
+		for _, p := range s.set.Projects {
+			line(s.mark, s.style, "project", p.UUID, f.style(headerStyle, p.Title))
+		}
+		for _, h := range s.set.Headings {
+			line(s.mark, s.style, "heading", h.UUID, f.style(blue, h.Title))
+		}
+		for _, t := range s.set.Tasks {
+			line(s.mark, s.style, "task", t.UUID, t.Status.Icon()+" "+f.style(cyan, t.Title))
+		}
+	}
+	for _, uuid := range changes.Deleted.Projects {
+		line("-", red, "project", uuid, "")
+	}
+	for _, uuid := range changes.Deleted.Headings {
+		line("-", red, "heading", uuid, "")
+	}
+	for _, uuid := range changes.Deleted.Tasks {
+		line("-", red, "task", uuid, "")
+	}
+	if changes.Areas != nil {
+		fmt.Println(f.style(magenta, fmt.Sprintf("area list changed (%d areas)", len(changes.Areas))))
+	}
+	if changes.Tags != nil {
+		fmt.Println(f.style(magenta, fmt.Sprintf("tag list changed (%d tags)", len(changes.Tags))))
+	}
+
+	count := changes.Created.Len() + changes.Updated.Len() + changes.Deleted.Len()
+	summary := fmt.Sprintf("%d change(s)", count)
+	if !changes.Since.IsZero() {
+		summary += " since " + changes.Since.Local().Format("2006-01-02 15:04:05")
+	}
+	if count > 0 || changes.Areas != nil || changes.Tags != nil {
+		fmt.Println()
+	}
+	fmt.Println(f.style(dim, summary))
+	fmt.Println(f.style(dim, "Cursor: ") + changes.Cursor)
+	return nil
+}
+
 // FormatTask formats a single task with full details
 func (f *TableFormatter) FormatTask(task *models.Task) error {
 	fmt.Println(f.style(headerStyle, "Task Details"))
@@ -251,7 +461,23 @@ func (f *TableFormatter) FormatTask(task *models.Task) error {
 		fmt.Printf("%s: %s\n", f.style(dim, "Tags"), f.style(yellow, task.Tags.String))
 	}
 
-	if task.IsRepeating {
+	if len(task.ChecklistItems) > 0 {
+		fmt.Printf("%s: %s\n", f.style(dim, "Checklist"), models.ChecklistProgress(task.ChecklistDone, task.ChecklistTotal))
+		f.checklistLines(task.ChecklistItems)
+	}
+
+	if task.Recurrence != nil {
+		fmt.Printf("%s: %s 🔁\n", f.style(dim, "Repeats"), task.Recurrence.Describe())
+		if !task.Recurrence.AfterCompletion {
+			var dates []string
+			for _, day := range task.Recurrence.Next(time.Now(), 3) {
+				dates = append(dates, day.Format("2006-01-02"))
+			}
+			if len(dates) > 0 {
+				fmt.Printf("%s: %s\n", f.style(dim, "Next"), strings.Join(dates, ", "))
+			}
+		}
+	} else if task.IsRepeating {
 		fmt.Printf("%s: %s\n", f.style(dim, "Repeating"), "Yes 🔁")
 	}
 
@@ -266,6 +492,35 @@ func (f *TableFormatter) FormatTask(task *models.Task) error {
 	return nil
 }
 
+// FormatChecklist formats a task's checklist with the 1-based positions the
+// checklist commands accept
+func (f *TableFormatter) FormatChecklist(items []models.ChecklistItem) error {
+	if len(items) == 0 {
+		fmt.Println(f.style(yellow, "No checklist items"))
+		return nil
+	}
+	f.checklistLines(items)
+	return nil
+}
+
+// checklistLines prints one numbered line per checklist item
+func (f *TableFormatter) checklistLines(items []models.ChecklistItem) {
+	for i, item := range items {
+		box := "☐"
+		switch {
+		case item.Completed:
+			box = "☑"
+		case item.Canceled:
+			box = "☒"
+		}
+		title := f.style(cyan, item.Title)
+		if item.Done() {
+			title = f.style(dim, item.Title)
+		}
+		fmt.Printf("  %s %s %s\n", f.style(dim, fmt.Sprintf("%2d.", i+1)), f.style(green, box), title)
+	}
+}
+
 // FormatProjects formats a list of projects
 func (f *TableFormatter) FormatProjects(projects []models.Project) error {
 	if len(projects) == 0 {
@@ -303,8 +558,9 @@ func (f *TableFormatter) FormatProjects(projects []models.Project) error {
 	return nil
 }
 
-// FormatProject formats a single project with its tasks
-func (f *TableFormatter) FormatProject(project *models.Project, tasks []models.Task) error {
+// FormatProject formats a single project with its tasks grouped under their
+// headings, tasks outside any heading first
+func (f *TableFormatter) FormatProject(project *models.Project, headings []models.Heading, tasks []models.Task) error {
 	fmt.Println(f.style(headerStyle, "Project: "+project.Title))
 	fmt.Println(strings.Repeat("─", 40))
 
@@ -326,12 +582,66 @@ func (f *TableFormatter) FormatProject(project *models.Project, tasks []models.T
 		fmt.Printf("%s:\n%s\n", f.style(dim, "Notes"), project.Notes.String)
 	}
 
-	if len(tasks) > 0 {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	byHeading := make(map[string][]models.Task)
+	for _, task := range tasks {
+		heading := task.HeadingUUID.String
+		// The project and heading are given by the section already
+		task.ProjectName.Valid = false
+		task.HeadingName.Valid = false
+		byHeading[heading] = append(byHeading[heading], task)
+	}
+
+	fmt.Println()
+	fmt.Println(f.style(headerStyle, "Tasks"))
+	for _, task := range byHeading[""] {
+		fmt.Println(f.taskLine(task))
+	}
+	for _, h := range headings {
+		// Archived headings only show up with the completed tasks under them
+		if h.Archived && len(byHeading[h.UUID]) == 0 {
+			continue
+		}
+		title := h.Title
+		if h.Archived {
+			title += " (archived)"
+		}
 		fmt.Println()
-		fmt.Println(f.style(headerStyle, "Tasks"))
-		return f.FormatTasks(tasks)
+		fmt.Println(f.style(blue, title))
+		for _, task := range byHeading[h.UUID] {
+			fmt.Println(f.taskLine(task))
+		}
+	}
+
+	fmt.Println(f.style(dim, fmt.Sprintf("\n%d task(s)", len(tasks))))
+	return nil
+}
+
+// FormatHeadings formats the headings of a project
+func (f *TableFormatter) FormatHeadings(headings []models.Heading) error {
+	if len(headings) == 0 {
+		fmt.Println(f.style(yellow, "No headings found"))
+		return nil
+	}
+
+	for _, h := range headings {
+		// Show short ID (first 8 chars of UUID)
+		shortID := h.UUID
+		if len(shortID) > 8 {
+			shortID = shortID[:8]
+		}
+
+		line := fmt.Sprintf("%s %s", f.style(dim, shortID), f.style(cyan, h.Title))
+		if h.Archived {
+			line += " " + f.style(dim, "(") + f.style(yellow, "Archived") + f.style(dim, ")")
+		}
+		fmt.Println(line)
 	}
 
+	fmt.Println(f.style(dim, fmt.Sprintf("\n%d heading(s)", len(headings))))
 	return nil
 }
 
@@ -396,29 +706,67 @@ func (f *TableFormatter) FormatTags(tags []models.Tag) error {
 	}
 
 	for _, tag := range tags {
-		// Show short ID (first 8 chars of UUID)
-		shortID := tag.UUID
-		if len(shortID) > 8 {
-			shortID = shortID[:8]
-		}
+		fmt.Println(f.tagLine(tag, ""))
+	}
 
-		var context []string
-		if tag.Shortcut.Valid && tag.Shortcut.String != "" {
-			context = append(context, f.style(yellow, tag.Shortcut.String))
-		}
-		context = append(context, f.style(green, fmt.Sprintf("%d tasks", tag.TaskCount)))
+	fmt.Println(f.style(dim, fmt.Sprintf("\n%d tag(s)", len(tags))))
+	return nil
+}
 
-		line := fmt.Sprintf("%s %s %s",
-			f.style(dim, shortID),
-			f.style(cyan, tag.Title),
-			f.style(dim, "("+strings.Join(context, ", ")+")"))
-		fmt.Println(line)
+// FormatTagTree formats tags as an indented tree under their parents
+func (f *TableFormatter) FormatTagTree(roots []models.Tag) error {
+	if len(roots) == 0 {
+		fmt.Println(f.style(yellow, "No tags found"))
+		return nil
 	}
 
-	fmt.Println(f.style(dim, fmt.Sprintf("\n%d tag(s)", len(tags))))
+	count := 0
+	var walk func(tags []models.Tag, indent string)
+	walk = func(tags []models.Tag, indent string) {
+		for i, tag := range tags {
+			branch, next := "├─ ", "│  "
+			if i == len(tags)-1 {
+				branch, next = "└─ ", "   "
+			}
+			fmt.Println(f.tagLine(tag, indent+branch))
+			count++
+			walk(tag.Children, indent+next)
+		}
+	}
+	for _, root := range roots {
+		fmt.Println(f.tagLine(root, ""))
+		count++
+		walk(root.Children, "")
+	}
+
+	fmt.Println(f.style(dim, fmt.Sprintf("\n%d tag(s)", count)))
 	return nil
 }
 
+// tagLine renders one tag, with prefix drawn between the ID and the title
+func (f *TableFormatter) tagLine(tag models.Tag, prefix string) string {
+	// Show short ID (first 8 chars of UUID)
+	shortID := tag.UUID
+	if len(shortID) > 8 {
+		shortID = shortID[:8]
+	}
+
+	var context []string
+	if tag.Shortcut.Valid && tag.Shortcut.String != "" {
+		context = append(context, f.style(yellow, tag.Shortcut.String))
+	}
+	context = append(context, f.style(green, fmt.Sprintf("%d tasks", tag.TaskCount)))
+
+	if prefix != "" {
+		prefix = f.style(dim, prefix)
+	}
+	return fmt.Sprintf("%s %s%s %s",
+		f.style(dim, shortID),
+		prefix,
+		f.style(cyan, tag.Title),
+		f.style(dim, "("+strings.Join(context, ", ")+")"))
+}
+
 // FormatSearchResults formats search results
 func (f *TableFormatter) FormatSearchResults(tasks []models.Task, term string) error {
 	if len(tasks) == 0 {
@@ -0,0 +1,332 @@
+package sandbox
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ref is an AppleScript object reference such as `to do id "..."`
+type ref struct {
+	class string // "to do", "project", "area", "tag"
+	uuid  string
+}
+
+// refPattern matches either a literal object reference or a variable name
+const refPattern = `(?:(to do|project|area|tag) id "([^"]+)"|(\w+))`
+
+var (
+	bindRe        = regexp.MustCompile(`^set (\w+) to (to do|project|area|tag) id "([^"]+)"$`)
+	makeRe        = regexp.MustCompile(`^set (\w+) to make new (area|tag) with properties \{name:("(?:[^"\\]|\\.)*")(?:, parent tag:(\w+))?\}$`)
+	returnIDRe    = regexp.MustCompile(`^return id of (\w+)$`)
+	deleteRe      = regexp.MustCompile(`^delete ` + refPattern + `$`)
+	statusRe      = regexp.MustCompile(`^set status of ` + refPattern + ` to (completed|canceled|open)$`)
+	moveListRe    = regexp.MustCompile(`^move ` + refPattern + ` to list "([^"]+)"$`)
+	setTextRe     = regexp.MustCompile(`^set (name|notes|tag names|keyboard shortcut) of ` + refPattern + ` to ("(?:[^"\\]|\\.)*")$`)
+	setDueRe      = regexp.MustCompile(`^set due date of ` + refPattern + ` to date "([^"]+)"$`)
+	setParentRe   = regexp.MustCompile(`^set (area|project) of ` + refPattern + ` to (area|project) id "([^"]+)"$`)
+	parentTagRe   = regexp.MustCompile(`^set parent tag of ` + refPattern + ` to (?:tag id "([^"]+)"|missing value)$`)
+	deleteOpenAll = "every to do whose status is open"
+)
+
+// RunAppleScript interprets the AppleScript thingies generates and applies it
+// to the sandbox database. Errors mimic the messages osascript prints.
+func (b *Backend) RunAppleScript(script string) (string, error) {
+	var output string
+	err := b.write(func(w *writer) error {
+		if strings.Contains(script, deleteOpenAll) {
+			res, err := w.exec(`UPDATE TMTask SET trashed = 1, userModificationDate = ? WHERE type = 0 AND status = 0 AND trashed = 0`, w.timestamp())
+			if err != nil {
+				return err
+			}
+			n, _ := res.RowsAffected()
+			output = strconv.FormatInt(n, 10)
+			return nil
+		}
+
+		vars := make(map[string]ref)
+		for _, line := range strings.Split(script, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || line == "end tell" || strings.HasPrefix(line, "tell application ") {
+				continue
+			}
+			out, err := w.statement(line, vars)
+			if err != nil {
+				return err
+			}
+			if out != "" {
+				output = out
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return output, nil
+}
+
+// statement applies a single AppleScript statement
+func (w *writer) statement(line string, vars map[string]ref) (string, error) {
+	if m := bindRe.FindStringSubmatch(line); m != nil {
+		r := ref{class: m[2], uuid: m[3]}
+		if err := w.checkRef(r); err != nil {
+			return "", err
+		}
+		vars[m[1]] = r
+		return "", nil
+	}
+
+	if m := makeRe.FindStringSubmatch(line); m != nil {
+		name, err := strconv.Unquote(m[3])
+		if err != nil {
+			return "", syntaxError(line)
+		}
+		var uuid string
+		if m[2] == "area" {
+			uuid, err = w.insertArea(name)
+		} else {
+			parent := ""
+			if m[4] != "" {
+				p, ok := vars[m[4]]
+				if !ok {
+					return "", undefinedVariable(m[4])
+				}
+				parent = p.uuid
+			}
+			uuid, err = w.insertTag(name, parent)
+		}
+		if err != nil {
+			return "", err
+		}
+		vars[m[1]] = ref{class: m[2], uuid: uuid}
+		return "", nil
+	}
+
+	if line == "empty trash" {
+		return "", w.emptyTrash()
+	}
+
+	if m := returnIDRe.FindStringSubmatch(line); m != nil {
+		r, ok := vars[m[1]]
+		if !ok {
+			return "", undefinedVariable(m[1])
+		}
+		return r.uuid, nil
+	}
+
+	if m := deleteRe.FindStringSubmatch(line); m != nil {
+		r, err := w.resolve(m[1], m[2], m[3], vars)
+		if err != nil {
+			return "", err
+		}
+		return "", w.deleteRef(r)
+	}
+
+	if m := statusRe.FindStringSubmatch(line); m != nil {
+		r, err := w.resolve(m[1], m[2], m[3], vars)
+		if err != nil {
+			return "", err
+		}
+		status := map[string]int{"open": 0, "canceled": 2, "completed": 3}[m[4]]
+		return "", w.setStatus(r.uuid, status)
+	}
+
+	if m := moveListRe.FindStringSubmatch(line); m != nil {
+		r, err := w.resolve(m[1], m[2], m[3], vars)
+		if err != nil {
+			return "", err
+		}
+		return "", w.moveToList(r, m[4])
+	}
+
+	if m := setTextRe.FindStringSubmatch(line); m != nil {
+		r, err := w.resolve(m[2], m[3], m[4], vars)
+		if err != nil {
+			return "", err
+		}
+		value, err := strconv.Unquote(m[5])
+		if err != nil {
+			return "", syntaxError(line)
+		}
+		return "", w.setText(r, m[1], value)
+	}
+
+	if m := setDueRe.FindStringSubmatch(line); m != nil {
+		r, err := w.resolve(m[1], m[2], m[3], vars)
+		if err != nil {
+			return "", err
+		}
+		return "", w.setDeadline(r.uuid, m[4])
+	}
+
+	if m := setParentRe.FindStringSubmatch(line); m != nil {
+		r, err := w.resolve(m[2], m[3], m[4], vars)
+		if err != nil {
+			return "", err
+		}
+		target := ref{class: m[5], uuid: m[6]}
+		if m[1] != m[5] {
+			return "", syntaxError(line)
+		}
+		if err := w.checkRef(target); err != nil {
+			return "", err
+		}
+		return "", w.setParent(r, target)
+	}
+
+	if m := parentTagRe.FindStringSubmatch(line); m != nil {
+		r, err := w.resolve(m[1], m[2], m[3], vars)
+		if err != nil {
+			return "", err
+		}
+		if r.class != "tag" {
+			return "", syntaxError(line)
+		}
+		if m[4] != "" {
+			if err := w.checkRef(ref{class: "tag", uuid: m[4]}); err != nil {
+				return "", err
+			}
+		}
+		_, err = w.exec(`UPDATE TMTag SET parent = ? WHERE uuid = ?`, nullIfEmpty(m[4]), r.uuid)
+		return "", err
+	}
+
+	return "", fmt.Errorf("applescript error: sandbox does not understand statement: %s", line)
+}
+
+// resolve turns the captured parts of refPattern into a checked reference
+func (w *writer) resolve(class, uuid, variable string, vars map[string]ref) (ref, error) {
+	if variable != "" {
+		r, ok := vars[variable]
+		if !ok {
+			return ref{}, undefinedVariable(variable)
+		}
+		return r, nil
+	}
+	r := ref{class: class, uuid: uuid}
+	return r, w.checkRef(r)
+}
+
+// checkRef returns Things' "Can't get" error when the referenced object is missing
+func (w *writer) checkRef(r ref) error {
+	var exists bool
+	var err error
+	switch r.class {
+	case "to do":
+		exists, err = w.taskExists(r.uuid, -1)
+	case "project":
+		exists, err = w.taskExists(r.uuid, 1)
+	case "area":
+		exists, err = w.rowExists("TMArea", r.uuid)
+	case "tag":
+		exists, err = w.rowExists("TMTag", r.uuid)
+	}
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf(`applescript error: Things3 got an error: Can't get %s id "%s". (-1728)`, r.class, r.uuid)
+	}
+	return nil
+}
+
+// deleteRef trashes tasks and projects and removes areas and tags
+func (w *writer) deleteRef(r ref) error {
+	switch r.class {
+	case "area":
+		if _, err := w.exec(`UPDATE TMTask SET trashed = 1, userModificationDate = ? WHERE area = ?`, w.timestamp(), r.uuid); err != nil {
+			return err
+		}
+		if _, err := w.exec(`DELETE FROM TMAreaTag WHERE areas = ?`, r.uuid); err != nil {
+			return err
+		}
+		_, err := w.exec(`DELETE FROM TMArea WHERE uuid = ?`, r.uuid)
+		return err
+	case "tag":
+		if _, err := w.exec(`DELETE FROM TMTaskTag WHERE tags = ?`, r.uuid); err != nil {
+			return err
+		}
+		if _, err := w.exec(`DELETE FROM TMAreaTag WHERE tags = ?`, r.uuid); err != nil {
+			return err
+		}
+		if _, err := w.exec(`UPDATE TMTag SET parent = NULL WHERE parent = ?`, r.uuid); err != nil {
+			return err
+		}
+		_, err := w.exec(`DELETE FROM TMTag WHERE uuid = ?`, r.uuid)
+		return err
+	default:
+		return w.trash(r.uuid)
+	}
+}
+
+// moveToList handles `move ... to list "<name>"` for the built-in lists
+func (w *writer) moveToList(r ref, list string) error {
+	switch list {
+	case "Today", "Tomorrow", "Anytime", "Someday", "Inbox":
+		// Moving a trashed item to a list takes it out of the trash
+		if err := w.untrash(r.uuid); err != nil {
+			return err
+		}
+		// The Inbox holds only unfiled tasks
+		if list == "Inbox" {
+			if _, err := w.exec(`UPDATE TMTask SET area = NULL, project = NULL, heading = NULL WHERE uuid = ?`, r.uuid); err != nil {
+				return err
+			}
+		}
+		return w.schedule(r.uuid, strings.ToLower(list))
+	case "Trash":
+		return w.trash(r.uuid)
+	default:
+		return fmt.Errorf(`applescript error: Things3 got an error: Can't get list "%s". (-1728)`, list)
+	}
+}
+
+// setText handles name, notes, tag names, and keyboard shortcut assignments
+func (w *writer) setText(r ref, property, value string) error {
+	switch {
+	case property == "name" && r.class == "area":
+		_, err := w.exec(`UPDATE TMArea SET title = ? WHERE uuid = ?`, value, r.uuid)
+		return err
+	case property == "name" && r.class == "tag":
+		_, err := w.exec(`UPDATE TMTag SET title = ? WHERE uuid = ?`, value, r.uuid)
+		return err
+	case property == "keyboard shortcut" && r.class == "tag":
+		_, err := w.exec(`UPDATE TMTag SET shortcut = ? WHERE uuid = ?`, nullIfEmpty(value), r.uuid)
+		return err
+	case property == "keyboard shortcut":
+		return fmt.Errorf("applescript error: Things3 got an error: Can't set keyboard shortcut of %s id \"%s\". (-10006)", r.class, r.uuid)
+	case property == "name":
+		_, err := w.exec(`UPDATE TMTask SET title = ?, userModificationDate = ? WHERE uuid = ?`, value, w.timestamp(), r.uuid)
+		return err
+	case property == "notes":
+		_, err := w.exec(`UPDATE TMTask SET notes = ?, userModificationDate = ? WHERE uuid = ?`, value, w.timestamp(), r.uuid)
+		return err
+	default:
+		return w.setTags(r.uuid, value, true, true)
+	}
+}
+
+// setParent moves a task into an area or project
+func (w *writer) setParent(r, target ref) error {
+	column := "area"
+	if target.class == "project" {
+		column = "project"
+	}
+	// Leaving the inbox makes a task available in Anytime, as in Things
+	_, err := w.exec(fmt.Sprintf(`
+		UPDATE TMTask SET area = NULL, project = NULL, heading = NULL, %s = ?,
+			start = CASE WHEN start = 0 THEN 1 ELSE start END,
+			userModificationDate = ?
+		WHERE uuid = ?`, column), target.uuid, w.timestamp(), r.uuid)
+	return err
+}
+
+func undefinedVariable(name string) error {
+	return fmt.Errorf("applescript error: The variable %s is not defined. (-2753)", name)
+}
+
+func syntaxError(line string) error {
+	return fmt.Errorf("applescript error: syntax error in statement: %s (-2741)", line)
+}
@@ -0,0 +1,258 @@
+package sandbox
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"thingies/internal/things"
+)
+
+// runJSON handles things:///json: creating to-dos and projects (with their
+// headings, to-dos, and checklists) and updating to-dos and projects. Moving
+// an item with an update is not supported.
+func (w *writer) runJSON(q url.Values) error {
+	var items []things.JSONItem
+	dec := json.NewDecoder(bytes.NewReader([]byte(q.Get("data"))))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&items); err != nil {
+		return fmt.Errorf("failed to open URL: invalid json data: %w", err)
+	}
+	if err := things.ValidateJSON(items); err != nil {
+		return fmt.Errorf("failed to open URL: %w", err)
+	}
+	if things.JSONNeedsAuth(items) {
+		if err := w.checkAuthToken(q.Get("auth-token")); err != nil {
+			return err
+		}
+	}
+
+	for _, item := range items {
+		var err error
+		switch {
+		case item.Operation == things.JSONUpdate:
+			err = w.jsonUpdate(item)
+		case item.Type == things.JSONProject:
+			err = w.jsonCreateProject(item)
+		default:
+			_, err = w.jsonCreateToDo(item, newTask{})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonCreateToDo creates a to-do. parent places it inside a project being
+// created; otherwise its list and heading attributes do.
+func (w *writer) jsonCreateToDo(item things.JSONItem, parent newTask) (string, error) {
+	a := item.Attributes
+	t := newTask{Type: 0, Title: a.Title, Notes: a.Notes, Project: parent.Project, Heading: parent.Heading}
+	if parent.Project == "" && parent.Heading == "" {
+		if err := w.jsonPlacement(&t, a); err != nil {
+			return "", err
+		}
+	}
+	// Items filed into a project or area skip the inbox
+	if t.Project != "" || t.Area != "" || t.Heading != "" {
+		t.Start = 1
+	}
+
+	uuid, err := w.insertTask(t)
+	if err != nil {
+		return "", err
+	}
+	if len(a.ChecklistItems) > 0 {
+		if err := w.replaceChecklist(uuid, jsonChecklist(a.ChecklistItems)); err != nil {
+			return "", err
+		}
+	}
+	return uuid, w.jsonCommon(uuid, a)
+}
+
+// jsonPlacement resolves a to-do's list, list-id, heading, and heading-id
+func (w *writer) jsonPlacement(t *newTask, a things.JSONAttributes) error {
+	switch {
+	case a.ListID != "":
+		if isProject, err := w.taskExists(a.ListID, 1); err != nil {
+			return err
+		} else if isProject {
+			t.Project = a.ListID
+		} else if isArea, err := w.rowExists("TMArea", a.ListID); err != nil {
+			return err
+		} else if isArea {
+			t.Area = a.ListID
+		}
+	case a.List != "":
+		project, area, err := w.listByTitle(a.List)
+		if err != nil {
+			return err
+		}
+		t.Project, t.Area = project, area
+	}
+	if t.Project == "" {
+		return nil
+	}
+
+	heading := a.HeadingID
+	if heading != "" {
+		var project string
+		err := w.tx.QueryRow(`SELECT COALESCE(project, '') FROM TMTask WHERE uuid = ? AND type = 2`, heading).Scan(&project)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("sandbox read failed: %w", err)
+		}
+		// Things ignores a heading that is not in the list
+		if project != t.Project {
+			heading = ""
+		}
+	} else if a.Heading != "" {
+		var err error
+		if heading, err = w.headingByTitle(t.Project, a.Heading); err != nil {
+			return err
+		}
+	}
+	if heading != "" {
+		// As in Things, a task under a heading leaves its project column empty
+		t.Heading, t.Project = heading, ""
+	}
+	return nil
+}
+
+// jsonCreateProject creates a project with its to-dos and headings. To-dos
+// that follow a heading in items go under it.
+func (w *writer) jsonCreateProject(item things.JSONItem) error {
+	a := item.Attributes
+	t := newTask{Type: 1, Title: a.Title, Notes: a.Notes, Start: 1}
+	switch {
+	case a.AreaID != "":
+		if exists, err := w.rowExists("TMArea", a.AreaID); err != nil {
+			return err
+		} else if exists {
+			t.Area = a.AreaID
+		}
+	case a.Area != "":
+		uuid, err := w.areaByTitle(a.Area)
+		if err != nil {
+			return err
+		}
+		t.Area = uuid
+	}
+
+	uuid, err := w.insertTask(t)
+	if err != nil {
+		return err
+	}
+	if err := w.jsonProjectItems(uuid, a.Items); err != nil {
+		return err
+	}
+	return w.jsonCommon(uuid, a)
+}
+
+// jsonProjectItems appends to-dos and headings to a project
+func (w *writer) jsonProjectItems(projectUUID string, items []things.JSONItem) error {
+	parent := newTask{Project: projectUUID}
+	for _, child := range items {
+		if child.Type == things.JSONHeading {
+			heading, err := w.insertTask(newTask{Type: 2, Title: child.Attributes.Title, Start: 1, Project: projectUUID})
+			if err != nil {
+				return err
+			}
+			if child.Attributes.Archived {
+				if err := w.setStatus(heading, 3); err != nil {
+					return err
+				}
+			}
+			parent = newTask{Heading: heading}
+			continue
+		}
+		if _, err := w.jsonCreateToDo(child, parent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonUpdate applies an update to an existing to-do or project
+func (w *writer) jsonUpdate(item things.JSONItem) error {
+	taskType := 0
+	if item.Type == things.JSONProject {
+		taskType = 1
+	}
+	exists, err := w.taskExists(item.ID, taskType)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("failed to open URL: no %s with id %s", item.Type, item.ID)
+	}
+
+	a := item.Attributes
+	if a.List != "" || a.ListID != "" || a.Heading != "" || a.HeadingID != "" || a.Area != "" || a.AreaID != "" {
+		return fmt.Errorf("failed to open URL: sandbox does not support moving items with json updates")
+	}
+	if a.Title != "" {
+		if _, err := w.exec(`UPDATE TMTask SET title = ? WHERE uuid = ?`, a.Title, item.ID); err != nil {
+			return err
+		}
+	}
+	if a.Notes != "" {
+		if _, err := w.exec(`UPDATE TMTask SET notes = ? WHERE uuid = ?`, a.Notes, item.ID); err != nil {
+			return err
+		}
+	}
+	if a.ChecklistItems != nil {
+		if err := w.replaceChecklist(item.ID, jsonChecklist(a.ChecklistItems)); err != nil {
+			return err
+		}
+	}
+	return w.jsonCommon(item.ID, a)
+}
+
+// jsonCommon applies the attributes shared by to-dos and projects
+func (w *writer) jsonCommon(uuid string, a things.JSONAttributes) error {
+	if a.When != "" {
+		if err := w.schedule(uuid, a.When); err != nil {
+			return err
+		}
+	}
+	if a.Deadline != "" {
+		if err := w.setDeadline(uuid, a.Deadline); err != nil {
+			return err
+		}
+	}
+	if len(a.Tags) > 0 {
+		if err := w.setTags(uuid, strings.Join(a.Tags, ","), true, false); err != nil {
+			return err
+		}
+	}
+	if a.Completed {
+		if err := w.setStatus(uuid, 3); err != nil {
+			return err
+		}
+	}
+	if a.Canceled {
+		if err := w.setStatus(uuid, 2); err != nil {
+			return err
+		}
+	}
+	return w.touch(uuid)
+}
+
+// jsonChecklist converts checklist-item objects to checklist entries
+func jsonChecklist(items []things.JSONItem) []checklistEntry {
+	entries := make([]checklistEntry, len(items))
+	for i, c := range items {
+		entries[i] = checklistEntry{title: c.Attributes.Title}
+		switch {
+		case c.Attributes.Completed:
+			entries[i].status = 3
+		case c.Attributes.Canceled:
+			entries[i].status = 2
+		}
+	}
+	return entries
+}
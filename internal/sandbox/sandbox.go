@@ -0,0 +1,449 @@
+// Package sandbox simulates the Things app against a writable copy of its
+// database. Backend implements things.Backend by interpreting the AppleScript
+// and things:/// URLs that thingies issues and applying them as SQL writes, so
+// the CLI and server can run end-to-end on machines without Things installed.
+// Reads through db.ThingsDB observe the changes.
+package sandbox
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+	"thingies/internal/db"
+)
+
+// Backend applies Things operations directly to a SQLite database file
+type Backend struct {
+	conn *sql.DB
+	path string
+	now  func() time.Time
+}
+
+// Open opens a read-write connection to a Things-schema database.
+// Never point this at the live Things database: the app does not expect
+// other writers and will overwrite or corrupt the changes.
+func Open(dbPath string) (*Backend, error) {
+	if dbPath == "" {
+		return nil, fmt.Errorf("sandbox database path is required")
+	}
+
+	connStr := fmt.Sprintf("file:%s?mode=rw&_pragma=busy_timeout(5000)", dbPath)
+	conn, err := sql.Open("sqlite", connStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sandbox database: %w", err)
+	}
+	// A single connection serializes writes and avoids SQLITE_BUSY between them
+	conn.SetMaxOpenConns(1)
+
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to sandbox database: %w", err)
+	}
+
+	var tables int
+	err = conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name IN ('TMTask', 'TMArea', 'TMTag', 'TMTaskTag', 'TMChecklistItem')`).Scan(&tables)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to inspect sandbox database: %w", err)
+	}
+	if tables != 5 {
+		conn.Close()
+		return nil, fmt.Errorf("%s does not look like a Things database (missing TMTask/TMArea/TMTag tables)", dbPath)
+	}
+
+	return &Backend{conn: conn, path: dbPath, now: time.Now}, nil
+}
+
+// Close closes the database connection
+func (b *Backend) Close() error {
+	if b.conn != nil {
+		return b.conn.Close()
+	}
+	return nil
+}
+
+// Path returns the database path
+func (b *Backend) Path() string {
+	return b.path
+}
+
+// write runs fn inside a transaction and refreshes Things' cached counters
+func (b *Backend) write(fn func(w *writer) error) error {
+	tx, err := b.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin sandbox transaction: %w", err)
+	}
+
+	w := &writer{tx: tx, now: b.now()}
+	if err := fn(w); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := w.recount(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// writer applies individual changes within a transaction
+type writer struct {
+	tx  *sql.Tx
+	now time.Time
+}
+
+// timestamp returns the current time as a Things Unix timestamp
+func (w *writer) timestamp() float64 {
+	return float64(w.now.Unix())
+}
+
+// today returns today's date at midnight in the local timezone
+func (w *writer) today() time.Time {
+	y, m, d := w.now.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, w.now.Location())
+}
+
+// exec runs a statement, wrapping any error with context
+func (w *writer) exec(query string, args ...interface{}) (sql.Result, error) {
+	res, err := w.tx.Exec(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sandbox write failed: %w", err)
+	}
+	return res, nil
+}
+
+// nextIndex returns an index that sorts after every existing row in table
+func (w *writer) nextIndex(table string) (int, error) {
+	var idx sql.NullInt64
+	if err := w.tx.QueryRow(fmt.Sprintf(`SELECT MAX("index") FROM %s`, table)).Scan(&idx); err != nil {
+		return 0, fmt.Errorf("sandbox write failed: %w", err)
+	}
+	return int(idx.Int64) + 1, nil
+}
+
+// touch bumps the modification date of a task row
+func (w *writer) touch(uuid string) error {
+	_, err := w.exec(`UPDATE TMTask SET userModificationDate = ? WHERE uuid = ?`, w.timestamp(), uuid)
+	return err
+}
+
+// taskExists reports whether a TMTask row with the given type exists.
+// A negative taskType matches any type.
+func (w *writer) taskExists(uuid string, taskType int) (bool, error) {
+	query := `SELECT COUNT(*) FROM TMTask WHERE uuid = ?`
+	args := []interface{}{uuid}
+	if taskType >= 0 {
+		query += ` AND type = ?`
+		args = append(args, taskType)
+	}
+	var n int
+	if err := w.tx.QueryRow(query, args...).Scan(&n); err != nil {
+		return false, fmt.Errorf("sandbox read failed: %w", err)
+	}
+	return n > 0, nil
+}
+
+// rowExists reports whether a row with the given uuid exists in table
+func (w *writer) rowExists(table, uuid string) (bool, error) {
+	var n int
+	if err := w.tx.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE uuid = ?`, table), uuid).Scan(&n); err != nil {
+		return false, fmt.Errorf("sandbox read failed: %w", err)
+	}
+	return n > 0, nil
+}
+
+// schedule applies a Things "when" value to a task or project
+func (w *writer) schedule(uuid, when string) error {
+	today := w.today()
+
+	var start, bucket int
+	var startDate interface{}
+	switch strings.ToLower(when) {
+	case "today":
+		start, startDate = 1, db.DateToPackedInt(today)
+	case "evening":
+		start, startDate, bucket = 1, db.DateToPackedInt(today), 1
+	case "tomorrow":
+		start, startDate = 2, db.DateToPackedInt(today.AddDate(0, 0, 1))
+	case "anytime":
+		start, startDate = 1, nil
+	case "someday":
+		start, startDate = 2, nil
+	case "inbox":
+		start, startDate = 0, nil
+	default:
+		date, err := parseDate(when)
+		if err != nil {
+			return fmt.Errorf("invalid when value '%s'", when)
+		}
+		start, startDate = 2, db.DateToPackedInt(date)
+		if !date.After(today) {
+			start = 1
+		}
+	}
+
+	_, err := w.exec(`UPDATE TMTask SET start = ?, startDate = ?, startBucket = ?, userModificationDate = ? WHERE uuid = ?`,
+		start, startDate, bucket, w.timestamp(), uuid)
+	return err
+}
+
+// setDeadline sets a task deadline from a YYYY-MM-DD string
+func (w *writer) setDeadline(uuid, deadline string) error {
+	date, err := parseDate(deadline)
+	if err != nil {
+		return fmt.Errorf("invalid deadline '%s'", deadline)
+	}
+	_, err = w.exec(`UPDATE TMTask SET deadline = ?, userModificationDate = ? WHERE uuid = ?`,
+		db.DateToPackedInt(date), w.timestamp(), uuid)
+	return err
+}
+
+// setStatus sets a task or project status, stamping stopDate for closed items
+func (w *writer) setStatus(uuid string, status int) error {
+	var stopDate interface{}
+	if status != 0 {
+		stopDate = w.timestamp()
+	}
+	_, err := w.exec(`UPDATE TMTask SET status = ?, stopDate = ?, userModificationDate = ? WHERE uuid = ?`,
+		status, stopDate, w.timestamp(), uuid)
+	return err
+}
+
+// trash moves a task, project, or heading to the trash
+func (w *writer) trash(uuid string) error {
+	_, err := w.exec(`UPDATE TMTask SET trashed = 1, userModificationDate = ? WHERE uuid = ?`, w.timestamp(), uuid)
+	return err
+}
+
+// untrash takes a task, project, or heading out of the trash
+func (w *writer) untrash(uuid string) error {
+	_, err := w.exec(`UPDATE TMTask SET trashed = 0, userModificationDate = ? WHERE uuid = ? AND trashed = 1`, w.timestamp(), uuid)
+	return err
+}
+
+// emptyTrash permanently deletes trashed rows, the tasks and headings of
+// trashed projects and headings, and their checklist items and tag links
+func (w *writer) emptyTrash() error {
+	const doomed = `WITH RECURSIVE doomed(uuid) AS (
+		SELECT uuid FROM TMTask WHERE trashed = 1
+		UNION
+		SELECT t.uuid FROM TMTask t JOIN doomed d ON t.project = d.uuid OR t.heading = d.uuid
+	) `
+	for _, stmt := range []string{
+		`DELETE FROM TMChecklistItem WHERE task IN (SELECT uuid FROM doomed)`,
+		`DELETE FROM TMTaskTag WHERE tasks IN (SELECT uuid FROM doomed)`,
+		`DELETE FROM TMTask WHERE uuid IN (SELECT uuid FROM doomed)`,
+	} {
+		if _, err := w.exec(doomed + stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setTags replaces or extends the tags on a task. Unknown tag names are
+// created when create is set (AppleScript) and ignored otherwise (URL scheme).
+func (w *writer) setTags(uuid, names string, replace, create bool) error {
+	if replace {
+		if _, err := w.exec(`DELETE FROM TMTaskTag WHERE tasks = ?`, uuid); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range splitList(names, ",") {
+		tagUUID, err := w.tagByTitle(name)
+		if err != nil {
+			return err
+		}
+		if tagUUID == "" {
+			if !create {
+				continue
+			}
+			if tagUUID, err = w.insertTag(name, ""); err != nil {
+				return err
+			}
+		}
+		if _, err := w.exec(`DELETE FROM TMTaskTag WHERE tasks = ? AND tags = ?`, uuid, tagUUID); err != nil {
+			return err
+		}
+		if _, err := w.exec(`INSERT INTO TMTaskTag (tasks, tags) VALUES (?, ?)`, uuid, tagUUID); err != nil {
+			return err
+		}
+	}
+	return w.touch(uuid)
+}
+
+// tagByTitle returns the UUID of the tag with the given title, or "" if none
+func (w *writer) tagByTitle(title string) (string, error) {
+	var uuid string
+	err := w.tx.QueryRow(`SELECT uuid FROM TMTag WHERE title = ? LIMIT 1`, title).Scan(&uuid)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("sandbox read failed: %w", err)
+	}
+	return uuid, nil
+}
+
+// insertTag creates a tag and returns its UUID
+func (w *writer) insertTag(title, parent string) (string, error) {
+	idx, err := w.nextIndex("TMTag")
+	if err != nil {
+		return "", err
+	}
+	uuid := newUUID()
+	_, err = w.exec(`INSERT INTO TMTag (uuid, title, parent, "index") VALUES (?, ?, ?, ?)`,
+		uuid, title, nullIfEmpty(parent), idx)
+	return uuid, err
+}
+
+// insertArea creates an area and returns its UUID
+func (w *writer) insertArea(title string) (string, error) {
+	idx, err := w.nextIndex("TMArea")
+	if err != nil {
+		return "", err
+	}
+	uuid := newUUID()
+	_, err = w.exec(`INSERT INTO TMArea (uuid, title, visible, "index") VALUES (?, ?, NULL, ?)`, uuid, title, idx)
+	return uuid, err
+}
+
+// newTask describes a TMTask row to insert
+type newTask struct {
+	Type    int
+	Title   string
+	Notes   string
+	Start   int
+	Area    string
+	Project string
+	Heading string
+}
+
+// insertTask creates a task, project, or heading row and returns its UUID
+func (w *writer) insertTask(t newTask) (string, error) {
+	idx, err := w.nextIndex("TMTask")
+	if err != nil {
+		return "", err
+	}
+	uuid := newUUID()
+	_, err = w.exec(`
+		INSERT INTO TMTask (uuid, type, title, notes, status, trashed, start, creationDate, userModificationDate, "index", area, project, heading)
+		VALUES (?, ?, ?, ?, 0, 0, ?, ?, ?, ?, ?, ?, ?)`,
+		uuid, t.Type, t.Title, t.Notes, t.Start, w.timestamp(), w.timestamp(), idx,
+		nullIfEmpty(t.Area), nullIfEmpty(t.Project), nullIfEmpty(t.Heading))
+	return uuid, err
+}
+
+// insertChecklistItems appends checklist items to a task
+func (w *writer) insertChecklistItems(taskUUID string, titles []string) error {
+	var idx sql.NullInt64
+	if err := w.tx.QueryRow(`SELECT MAX("index") FROM TMChecklistItem WHERE task = ?`, taskUUID).Scan(&idx); err != nil {
+		return fmt.Errorf("sandbox read failed: %w", err)
+	}
+	next := int(idx.Int64) + 1
+	if !idx.Valid {
+		next = 0
+	}
+	for _, title := range titles {
+		_, err := w.exec(`INSERT INTO TMChecklistItem (uuid, title, status, "index", task, creationDate, userModificationDate) VALUES (?, ?, 0, ?, ?, ?, ?)`,
+			newUUID(), title, next, taskUUID, w.timestamp(), w.timestamp())
+		if err != nil {
+			return err
+		}
+		next++
+	}
+	return nil
+}
+
+// checklistEntry is a checklist item to write, with its TMChecklistItem status
+type checklistEntry struct {
+	title  string
+	status int
+}
+
+// replaceChecklist replaces a task's checklist. As in Things, every item
+// gets a new UUID.
+func (w *writer) replaceChecklist(taskUUID string, items []checklistEntry) error {
+	if _, err := w.exec(`DELETE FROM TMChecklistItem WHERE task = ?`, taskUUID); err != nil {
+		return err
+	}
+	for i, item := range items {
+		var stopDate interface{}
+		if item.status != 0 {
+			stopDate = w.timestamp()
+		}
+		_, err := w.exec(`INSERT INTO TMChecklistItem (uuid, title, status, stopDate, "index", task, creationDate, userModificationDate) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			newUUID(), item.title, item.status, stopDate, i, taskUUID, w.timestamp(), w.timestamp())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prependChecklistItems inserts checklist items at the top of a task's checklist
+func (w *writer) prependChecklistItems(taskUUID string, titles []string) error {
+	if _, err := w.exec(`UPDATE TMChecklistItem SET "index" = "index" + ? WHERE task = ?`, len(titles), taskUUID); err != nil {
+		return err
+	}
+	for i, title := range titles {
+		_, err := w.exec(`INSERT INTO TMChecklistItem (uuid, title, status, "index", task, creationDate, userModificationDate) VALUES (?, ?, 0, ?, ?, ?, ?)`,
+			newUUID(), title, i, taskUUID, w.timestamp(), w.timestamp())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recount refreshes the counters Things caches on projects and tasks
+func (w *writer) recount() error {
+	_, err := w.exec(db.Recount)
+	return err
+}
+
+// parseDate parses a YYYY-MM-DD date in the local timezone
+func parseDate(s string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
+
+// splitList splits s on sep, trimming whitespace and dropping empty entries
+func splitList(s, sep string) []string {
+	var out []string
+	for _, part := range strings.Split(s, sep) {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// nullIfEmpty maps "" to SQL NULL
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// newUUID returns a random 22-character base62 identifier like Things uses
+func newUUID() string {
+	var sb strings.Builder
+	max := big.NewInt(int64(len(base62)))
+	for i := 0; i < 22; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(fmt.Sprintf("sandbox: failed to generate uuid: %v", err))
+		}
+		sb.WriteByte(base62[n.Int64()])
+	}
+	return sb.String()
+}
@@ -0,0 +1,399 @@
+package sandbox
+
+import (
+	"strings"
+	"testing"
+
+	"thingies/internal/db"
+	"thingies/internal/db/dbtest"
+	"thingies/internal/things"
+)
+
+// newSandbox creates a Things-schema database with one area, one project with
+// a heading, and one tag, and returns a client writing to it plus a reader.
+func newSandbox(t *testing.T) (*things.Client, *db.ThingsDB) {
+	t.Helper()
+	b := dbtest.New(t).AuthToken("secret")
+	work := b.Area("Work").WithUUID("AreaWork00000000000000")
+	launch := b.Project("Launch").WithUUID("ProjLaunch000000000000").InArea(work)
+	b.Heading(launch, "Prep").WithUUID("HeadPrep00000000000000")
+	b.Tag("urgent").WithUUID("TagUrgent0000000000000")
+
+	backend, err := Open(b.Path())
+	if err != nil {
+		t.Fatalf("sandbox.Open: %v", err)
+	}
+	t.Cleanup(func() { backend.Close() })
+
+	return things.NewClient(backend), b.Open()
+}
+
+// findTask returns the first incomplete task with the given title
+func findTask(t *testing.T, reader *db.ThingsDB, title string) string {
+	t.Helper()
+	tasks, err := reader.Search(title, false, true)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	for _, task := range tasks {
+		if task.Title == title {
+			return task.UUID
+		}
+	}
+	t.Fatalf("task %q not found", title)
+	return ""
+}
+
+func TestAddURLCreatesTaskUnderHeading(t *testing.T) {
+	client, reader := newSandbox(t)
+
+	url := things.BuildAddURL(things.AddParams{
+		Title:          "Write press release",
+		Notes:          "Draft first",
+		Tags:           "urgent, unknown",
+		List:           "Launch",
+		Heading:        "Prep",
+		ChecklistItems: []string{"Outline", "Draft"},
+	})
+	if err := client.OpenURL(url); err != nil {
+		t.Fatalf("OpenURL: %v", err)
+	}
+
+	tasks, err := reader.GetProjectTasks("ProjLaunch000000000000", false)
+	if err != nil {
+		t.Fatalf("GetProjectTasks: %v", err)
+	}
+	if len(tasks) != 1 {
+		t.Fatalf("expected 1 project task, got %d", len(tasks))
+	}
+	task := tasks[0]
+	if task.HeadingName.String != "Prep" || task.AreaName.String != "Work" {
+		t.Errorf("expected Work > Launch > Prep, got area=%q heading=%q", task.AreaName.String, task.HeadingName.String)
+	}
+	if task.Tags.String != "urgent" {
+		t.Errorf("expected unknown tags to be ignored, got %q", task.Tags.String)
+	}
+
+	items, err := reader.GetTaskChecklistItems(task.UUID)
+	if err != nil {
+		t.Fatalf("GetTaskChecklistItems: %v", err)
+	}
+	if len(items) != 2 || items[0].Title != "Outline" || items[1].Title != "Draft" {
+		t.Errorf("unexpected checklist items: %+v", items)
+	}
+
+	project, err := reader.GetProject("ProjLaunch000000000000")
+	if err != nil {
+		t.Fatalf("GetProject: %v", err)
+	}
+	if project.OpenTasks != 1 || project.TotalTasks != 1 {
+		t.Errorf("expected project counts 1/1, got %d/%d", project.OpenTasks, project.TotalTasks)
+	}
+}
+
+func TestAddProjectURLCreatesToDos(t *testing.T) {
+	client, reader := newSandbox(t)
+
+	url := things.BuildAddProjectURL(things.AddProjectParams{
+		Title: "Q1 Review",
+		Area:  "Work",
+		ToDos: []string{"Gather metrics", "Draft report"},
+	})
+	if err := client.OpenURL(url); err != nil {
+		t.Fatalf("OpenURL: %v", err)
+	}
+
+	uuid, err := reader.GetProjectUUIDByName("Q1 Review")
+	if err != nil {
+		t.Fatalf("GetProjectUUIDByName: %v", err)
+	}
+	project, err := reader.GetProject(uuid)
+	if err != nil {
+		t.Fatalf("GetProject: %v", err)
+	}
+	if project.AreaName.String != "Work" || project.TotalTasks != 2 {
+		t.Errorf("unexpected project: area=%q total=%d", project.AreaName.String, project.TotalTasks)
+	}
+}
+
+func TestAppleScriptLifecycle(t *testing.T) {
+	client, reader := newSandbox(t)
+
+	if err := client.OpenURL(things.BuildAddURL(things.AddParams{Title: "Inbox item"})); err != nil {
+		t.Fatalf("OpenURL: %v", err)
+	}
+	uuid := findTask(t, reader, "Inbox item")
+
+	inbox, err := reader.GetInboxTasks()
+	if err != nil || len(inbox) != 1 {
+		t.Fatalf("expected 1 inbox task, got %d (%v)", len(inbox), err)
+	}
+
+	err = client.UpdateTask(things.TaskUpdateParams{
+		UUID:     uuid,
+		Name:     "Renamed",
+		Notes:    "with \"quotes\"",
+		When:     "today",
+		TagNames: "urgent, fresh",
+	})
+	if err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+
+	today, err := reader.ListTasks(db.TaskFilter{Today: true})
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	if len(today) != 1 || today[0].Title != "Renamed" {
+		t.Fatalf("expected renamed task in Today, got %+v", today)
+	}
+	if today[0].Notes.String != `with "quotes"` {
+		t.Errorf("unexpected notes: %q", today[0].Notes.String)
+	}
+	if today[0].Tags.String != "urgent, fresh" && today[0].Tags.String != "fresh, urgent" {
+		t.Errorf("expected AppleScript to create missing tags, got %q", today[0].Tags.String)
+	}
+
+	if err := client.MoveTaskToProject(uuid, "ProjLaunch000000000000"); err != nil {
+		t.Fatalf("MoveTaskToProject: %v", err)
+	}
+	if err := client.CompleteTask(uuid); err != nil {
+		t.Fatalf("CompleteTask: %v", err)
+	}
+	task, err := reader.GetTask(uuid)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if task.ProjectName.String != "Launch" || task.Status.String() != "completed" || !task.Completed.Valid {
+		t.Errorf("expected completed task in Launch, got project=%q status=%s", task.ProjectName.String, task.Status)
+	}
+
+	if err := client.DeleteTask(uuid); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	if _, err := reader.GetTask(uuid); err != nil {
+		t.Fatalf("GetTask after delete: %v", err)
+	}
+	logbook, _ := reader.GetLogbook(10)
+	if len(logbook) != 0 {
+		t.Errorf("trashed task should leave the logbook, got %d", len(logbook))
+	}
+}
+
+func TestAreaAndTagScripts(t *testing.T) {
+	client, reader := newSandbox(t)
+
+	areaUUID, err := client.CreateArea("Home")
+	if err != nil {
+		t.Fatalf("CreateArea: %v", err)
+	}
+	if _, err := reader.GetArea(areaUUID); err != nil {
+		t.Fatalf("created area not readable: %v", err)
+	}
+	if err := client.UpdateArea(areaUUID, "House"); err != nil {
+		t.Fatalf("UpdateArea: %v", err)
+	}
+	area, _ := reader.GetArea(areaUUID)
+	if area.Title != "House" {
+		t.Errorf("expected renamed area, got %q", area.Title)
+	}
+
+	tagUUID, err := client.CreateTag("today-ish", "TagUrgent0000000000000")
+	if err != nil {
+		t.Fatalf("CreateTag: %v", err)
+	}
+	var parent string
+	reader.Conn().QueryRow(`SELECT parent FROM TMTag WHERE uuid = ?`, tagUUID).Scan(&parent)
+	if parent != "TagUrgent0000000000000" {
+		t.Errorf("expected parent tag to be set, got %q", parent)
+	}
+
+	if err := client.DeleteTag(tagUUID); err != nil {
+		t.Fatalf("DeleteTag: %v", err)
+	}
+	if _, err := reader.GetTag(tagUUID); err == nil {
+		t.Error("expected deleted tag to be gone")
+	}
+
+	if err := client.DeleteArea(areaUUID); err != nil {
+		t.Fatalf("DeleteArea: %v", err)
+	}
+	if _, err := reader.GetArea(areaUUID); err == nil {
+		t.Error("expected deleted area to be gone")
+	}
+}
+
+func TestUpdateURLRequiresAuthToken(t *testing.T) {
+	client, reader := newSandbox(t)
+
+	if err := client.OpenURL(things.BuildAddURL(things.AddParams{Title: "Dated"})); err != nil {
+		t.Fatalf("OpenURL: %v", err)
+	}
+	uuid := findTask(t, reader, "Dated")
+
+	err := client.UpdateTask(things.TaskUpdateParams{UUID: uuid, When: "2030-01-02", AuthToken: "wrong"})
+	if err == nil || !strings.Contains(err.Error(), "auth-token") {
+		t.Fatalf("expected auth-token error, got %v", err)
+	}
+
+	err = client.UpdateTask(things.TaskUpdateParams{UUID: uuid, When: "2030-01-02", AuthToken: "secret"})
+	if err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	upcoming, err := reader.GetUpcomingTasks()
+	if err != nil {
+		t.Fatalf("GetUpcomingTasks: %v", err)
+	}
+	if len(upcoming) != 1 || upcoming[0].Scheduled.Time.Format("2006-01-02") != "2030-01-02" {
+		t.Errorf("expected task scheduled for 2030-01-02 in Upcoming, got %+v", upcoming)
+	}
+}
+
+func TestUpdateEvening(t *testing.T) {
+	client, reader := newSandbox(t)
+
+	if err := client.OpenURL(things.BuildAddURL(things.AddParams{Title: "Dinner", When: "today"})); err != nil {
+		t.Fatalf("OpenURL: %v", err)
+	}
+	uuid := findTask(t, reader, "Dinner")
+
+	if err := client.UpdateTask(things.TaskUpdateParams{UUID: uuid, When: "evening", AuthToken: "secret"}); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	task, err := reader.GetTask(uuid)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if !task.Evening {
+		t.Error("expected task in This Evening")
+	}
+
+	if err := client.UpdateTask(things.TaskUpdateParams{UUID: uuid, When: "today", AuthToken: "secret"}); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	if task, _ = reader.GetTask(uuid); task.Evening {
+		t.Error("expected today to move the task out of This Evening")
+	}
+}
+
+func TestMissingObjectMimicsAppleScriptError(t *testing.T) {
+	client, _ := newSandbox(t)
+
+	err := client.CompleteTask("DoesNotExist0000000000")
+	if err == nil || !strings.Contains(err.Error(), `Can't get to do id "DoesNotExist0000000000"`) {
+		t.Fatalf("expected Can't get error, got %v", err)
+	}
+}
+
+func TestRestoreAndEmptyTrash(t *testing.T) {
+	client, reader := newSandbox(t)
+
+	for _, title := range []string{"Keep", "Drop"} {
+		url := things.BuildAddURL(things.AddParams{Title: title, List: "Launch"})
+		if err := client.OpenURL(url); err != nil {
+			t.Fatalf("OpenURL: %v", err)
+		}
+	}
+	keep := findTask(t, reader, "Keep")
+	drop := findTask(t, reader, "Drop")
+	for _, uuid := range []string{keep, drop} {
+		if err := client.DeleteTask(uuid); err != nil {
+			t.Fatalf("DeleteTask: %v", err)
+		}
+	}
+
+	trash, err := reader.ListTrash()
+	if err != nil || len(trash) != 2 {
+		t.Fatalf("expected 2 trashed items, got %d (%v)", len(trash), err)
+	}
+	item, err := reader.GetTrashItem(keep)
+	if err != nil {
+		t.Fatalf("GetTrashItem: %v", err)
+	}
+	if item.ProjectName.String != "Launch" || item.AreaName.String != "Work" {
+		t.Errorf("expected original container Work > Launch, got %q > %q", item.AreaName.String, item.ProjectName.String)
+	}
+
+	err = client.RestoreItem(things.RestoreParams{UUID: keep, List: item.List(), Project: item.ProjectUUID.String})
+	if err != nil {
+		t.Fatalf("RestoreItem: %v", err)
+	}
+	tasks, err := reader.GetProjectTasks("ProjLaunch000000000000", false)
+	if err != nil || len(tasks) != 1 || tasks[0].UUID != keep {
+		t.Fatalf("expected the restored task back in Launch, got %+v (%v)", tasks, err)
+	}
+
+	if err := client.EmptyTrash(); err != nil {
+		t.Fatalf("EmptyTrash: %v", err)
+	}
+	if trash, _ := reader.ListTrash(); len(trash) != 0 {
+		t.Errorf("expected an empty trash, got %d items", len(trash))
+	}
+	if _, err := reader.GetTask(drop); err == nil {
+		t.Errorf("expected the emptied task to be gone")
+	}
+	if _, err := reader.GetTask(keep); err != nil {
+		t.Errorf("restored task should survive emptying the trash: %v", err)
+	}
+}
+
+func TestMoveTask(t *testing.T) {
+	client, reader := newSandbox(t)
+
+	if err := client.OpenURL(things.BuildAddURL(things.AddParams{Title: "Wanderer"})); err != nil {
+		t.Fatalf("OpenURL: %v", err)
+	}
+	uuid := findTask(t, reader, "Wanderer")
+
+	if err := client.MoveTaskToHeading(uuid, "ProjLaunch000000000000", "HeadPrep00000000000000", "secret"); err != nil {
+		t.Fatalf("MoveTaskToHeading: %v", err)
+	}
+	task, err := reader.GetTask(uuid)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if task.ProjectName.String != "Launch" || task.HeadingName.String != "Prep" {
+		t.Errorf("expected Launch > Prep, got project=%q heading=%q", task.ProjectName.String, task.HeadingName.String)
+	}
+
+	if err := client.MoveTaskToArea(uuid, "AreaWork00000000000000"); err != nil {
+		t.Fatalf("MoveTaskToArea: %v", err)
+	}
+	if task, _ := reader.GetTask(uuid); task.AreaName.String != "Work" || task.HeadingName.Valid || task.ProjectName.Valid {
+		t.Errorf("expected the task directly in Work, got %+v", task)
+	}
+
+	if err := client.MoveTaskToInbox(uuid); err != nil {
+		t.Fatalf("MoveTaskToInbox: %v", err)
+	}
+	inbox, err := reader.GetInboxTasks()
+	if err != nil || len(inbox) != 1 || inbox[0].UUID != uuid || inbox[0].AreaName.Valid {
+		t.Errorf("expected the task alone in the Inbox, got %+v (%v)", inbox, err)
+	}
+}
+
+// TestJSONProjectUpdateRejectsItems checks that the sandbox refuses headings
+// in a project update, which Things ignores, instead of adding them
+func TestJSONProjectUpdateRejectsItems(t *testing.T) {
+	client, reader := newSandbox(t)
+
+	url := things.BuildJSONURL("secret", things.JSONItem{
+		Type:      things.JSONProject,
+		Operation: things.JSONUpdate,
+		ID:        "ProjLaunch000000000000",
+		Attributes: things.JSONAttributes{Items: []things.JSONItem{
+			{Type: things.JSONHeading, Attributes: things.JSONAttributes{Title: "Wrap up"}},
+		}},
+	})
+	if err := client.OpenURL(url); err == nil || !strings.Contains(err.Error(), "items can only be given when creating a project") {
+		t.Fatalf("expected the update to be refused, got %v", err)
+	}
+
+	headings, err := reader.GetProjectHeadings("ProjLaunch000000000000")
+	if err != nil {
+		t.Fatalf("GetProjectHeadings: %v", err)
+	}
+	if len(headings) != 1 {
+		t.Errorf("expected only the existing heading, got %+v", headings)
+	}
+}
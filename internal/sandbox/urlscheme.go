@@ -0,0 +1,283 @@
+package sandbox
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// OpenURL interprets a things:/// URL and applies it to the sandbox database
+func (b *Backend) OpenURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("failed to open URL: %w", err)
+	}
+	if u.Scheme != "things" {
+		return fmt.Errorf("failed to open URL: sandbox only handles things:/// URLs, got %s", rawURL)
+	}
+
+	q := u.Query()
+	return b.write(func(w *writer) error {
+		switch u.Path {
+		case "/add":
+			_, err := w.addTask(q)
+			return err
+		case "/add-project":
+			return w.addProject(q)
+		case "/update":
+			return w.updateTask(q)
+		case "/json":
+			return w.runJSON(q)
+		default:
+			return fmt.Errorf("failed to open URL: sandbox does not support things://%s", u.Path)
+		}
+	})
+}
+
+// addTask handles things:///add
+func (w *writer) addTask(q url.Values) (string, error) {
+	t := newTask{Type: 0, Title: q.Get("title"), Notes: q.Get("notes")}
+
+	if list := q.Get("list"); list != "" {
+		project, area, err := w.listByTitle(list)
+		if err != nil {
+			return "", err
+		}
+		t.Project, t.Area = project, area
+		if heading := q.Get("heading"); heading != "" && project != "" {
+			if t.Heading, err = w.headingByTitle(project, heading); err != nil {
+				return "", err
+			}
+			if t.Heading != "" {
+				t.Project = ""
+			}
+		}
+	}
+	// Items filed into a project or area skip the inbox
+	if t.Project != "" || t.Area != "" || t.Heading != "" {
+		t.Start = 1
+	}
+
+	uuid, err := w.insertTask(t)
+	if err != nil {
+		return "", err
+	}
+	if items := q.Get("checklist-items"); items != "" {
+		if err := w.insertChecklistItems(uuid, splitList(items, "\n")); err != nil {
+			return "", err
+		}
+	}
+	return uuid, w.applyCommon(uuid, q)
+}
+
+// addProject handles things:///add-project
+func (w *writer) addProject(q url.Values) error {
+	t := newTask{Type: 1, Title: q.Get("title"), Notes: q.Get("notes"), Start: 1}
+	if area := q.Get("area"); area != "" {
+		uuid, err := w.areaByTitle(area)
+		if err != nil {
+			return err
+		}
+		t.Area = uuid
+	}
+
+	uuid, err := w.insertTask(t)
+	if err != nil {
+		return err
+	}
+
+	for _, title := range splitList(q.Get("to-dos"), "\n") {
+		if _, err := w.insertTask(newTask{Type: 0, Title: title, Start: 1, Project: uuid}); err != nil {
+			return err
+		}
+	}
+	return w.applyCommon(uuid, q)
+}
+
+// updateTask handles things:///update, which requires the auth token
+func (w *writer) updateTask(q url.Values) error {
+	uuid := q.Get("id")
+	if uuid == "" {
+		return fmt.Errorf("failed to open URL: update requires an id")
+	}
+	if err := w.checkAuthToken(q.Get("auth-token")); err != nil {
+		return err
+	}
+	exists, err := w.taskExists(uuid, -1)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("failed to open URL: no item with id %s", uuid)
+	}
+
+	if title := q.Get("title"); title != "" {
+		if _, err := w.exec(`UPDATE TMTask SET title = ? WHERE uuid = ?`, title, uuid); err != nil {
+			return err
+		}
+	}
+	if q.Has("notes") {
+		if _, err := w.exec(`UPDATE TMTask SET notes = ? WHERE uuid = ?`, q.Get("notes"), uuid); err != nil {
+			return err
+		}
+	}
+	if prepend := q.Get("prepend-notes"); prepend != "" {
+		if _, err := w.exec(`UPDATE TMTask SET notes = ? || COALESCE(notes, '') WHERE uuid = ?`, prepend, uuid); err != nil {
+			return err
+		}
+	}
+	if appendNotes := q.Get("append-notes"); appendNotes != "" {
+		if _, err := w.exec(`UPDATE TMTask SET notes = COALESCE(notes, '') || ? WHERE uuid = ?`, appendNotes, uuid); err != nil {
+			return err
+		}
+	}
+	if addTags := q.Get("add-tags"); addTags != "" {
+		if err := w.setTags(uuid, addTags, false, false); err != nil {
+			return err
+		}
+	}
+	if listID := q.Get("list-id"); listID != "" {
+		if err := w.moveToListID(uuid, listID, q.Get("heading-id")); err != nil {
+			return err
+		}
+	}
+	if q.Has("checklist-items") {
+		var items []checklistEntry
+		for _, title := range splitList(q.Get("checklist-items"), "\n") {
+			items = append(items, checklistEntry{title: title})
+		}
+		if err := w.replaceChecklist(uuid, items); err != nil {
+			return err
+		}
+	}
+	if items := q.Get("prepend-checklist-items"); items != "" {
+		if err := w.prependChecklistItems(uuid, splitList(items, "\n")); err != nil {
+			return err
+		}
+	}
+	if items := q.Get("append-checklist-items"); items != "" {
+		if err := w.insertChecklistItems(uuid, splitList(items, "\n")); err != nil {
+			return err
+		}
+	}
+	return w.applyCommon(uuid, q)
+}
+
+// applyCommon applies the parameters shared by add, add-project, and update
+func (w *writer) applyCommon(uuid string, q url.Values) error {
+	if when := q.Get("when"); when != "" {
+		if err := w.schedule(uuid, when); err != nil {
+			return err
+		}
+	}
+	if deadline := q.Get("deadline"); deadline != "" {
+		if err := w.setDeadline(uuid, deadline); err != nil {
+			return err
+		}
+	}
+	if q.Has("tags") {
+		if err := w.setTags(uuid, q.Get("tags"), true, false); err != nil {
+			return err
+		}
+	}
+	if q.Get("completed") == "true" {
+		if err := w.setStatus(uuid, 3); err != nil {
+			return err
+		}
+	}
+	if q.Get("canceled") == "true" {
+		if err := w.setStatus(uuid, 2); err != nil {
+			return err
+		}
+	}
+	return w.touch(uuid)
+}
+
+// moveToListID files a task into the project or area with the given UUID,
+// under headingID when it names a heading of that project
+func (w *writer) moveToListID(uuid, listID, headingID string) error {
+	if isProject, err := w.taskExists(listID, 1); err != nil {
+		return err
+	} else if isProject {
+		if headingID != "" {
+			var project sql.NullString
+			err := w.tx.QueryRow(`SELECT project FROM TMTask WHERE uuid = ? AND type = 2`, headingID).Scan(&project)
+			if err != nil && err != sql.ErrNoRows {
+				return fmt.Errorf("sandbox read failed: %w", err)
+			}
+			if project.String == listID {
+				// As in Things, a task under a heading leaves its project column empty
+				_, err := w.exec(`
+					UPDATE TMTask SET area = NULL, project = NULL, heading = ?,
+						start = CASE WHEN start = 0 THEN 1 ELSE start END,
+						userModificationDate = ?
+					WHERE uuid = ?`, headingID, w.timestamp(), uuid)
+				return err
+			}
+		}
+		return w.setParent(ref{class: "to do", uuid: uuid}, ref{class: "project", uuid: listID})
+	}
+	if isArea, err := w.rowExists("TMArea", listID); err != nil {
+		return err
+	} else if isArea {
+		return w.setParent(ref{class: "to do", uuid: uuid}, ref{class: "area", uuid: listID})
+	}
+	return fmt.Errorf("failed to open URL: no project or area with id %s", listID)
+}
+
+// checkAuthToken validates the token against TMSettings when one is configured
+func (w *writer) checkAuthToken(token string) error {
+	var expected sql.NullString
+	err := w.tx.QueryRow(`SELECT uriSchemeAuthenticationToken FROM TMSettings LIMIT 1`).Scan(&expected)
+	if err == sql.ErrNoRows || !expected.Valid || expected.String == "" {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("sandbox read failed: %w", err)
+	}
+	if token != expected.String {
+		return fmt.Errorf("failed to open URL: invalid auth-token")
+	}
+	return nil
+}
+
+// listByTitle resolves the add URL's list parameter to a project or area UUID
+func (w *writer) listByTitle(title string) (project, area string, err error) {
+	err = w.tx.QueryRow(`SELECT uuid FROM TMTask WHERE type = 1 AND trashed = 0 AND title = ? ORDER BY "index" LIMIT 1`, title).Scan(&project)
+	if err == nil {
+		return project, "", nil
+	}
+	if err != sql.ErrNoRows {
+		return "", "", fmt.Errorf("sandbox read failed: %w", err)
+	}
+	area, err = w.areaByTitle(title)
+	return "", area, err
+}
+
+// areaByTitle returns the UUID of the area with the given title, or "" if none
+func (w *writer) areaByTitle(title string) (string, error) {
+	var uuid string
+	err := w.tx.QueryRow(`SELECT uuid FROM TMArea WHERE title = ? ORDER BY "index" LIMIT 1`, title).Scan(&uuid)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("sandbox read failed: %w", err)
+	}
+	return uuid, nil
+}
+
+// headingByTitle returns the UUID of a heading within a project, or "" if none
+func (w *writer) headingByTitle(projectUUID, title string) (string, error) {
+	var uuid string
+	err := w.tx.QueryRow(`SELECT uuid FROM TMTask WHERE type = 2 AND trashed = 0 AND project = ? AND title = ? LIMIT 1`,
+		projectUUID, strings.TrimSpace(title)).Scan(&uuid)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("sandbox read failed: %w", err)
+	}
+	return uuid, nil
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"thingies/internal/db"
+	"thingies/internal/models"
+)
+
+// AreaRequest is the request body for creating or renaming an area
+type AreaRequest struct {
+	Title string `json:"title"`
+}
+
+// decodeAreaRequest decodes an AreaRequest, writing the error response and
+// returning false if the body is invalid or has no title
+func decodeAreaRequest(w http.ResponseWriter, r *http.Request) (AreaRequest, bool) {
+	var req AreaRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return req, false
+	}
+	if req.Title == "" {
+		writeError(w, http.StatusBadRequest, "title is required")
+		return req, false
+	}
+	return req, true
+}
+
+// handleCreateArea handles POST /areas and returns the new area. If it has
+// not reached the database within Config.CreateTimeout, it answers 202 with
+// the UUID AppleScript reported and the requested title.
+func (s *Server) handleCreateArea(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeAreaRequest(w, r)
+	if !ok {
+		return
+	}
+
+	uuid, err := s.things.CreateArea(req.Title)
+	if err != nil {
+		writeError(w, statusFor(err), "failed to create area: "+err.Error())
+		return
+	}
+
+	var area *models.Area
+	err = s.readBack(w, r, func() (err error) {
+		area, err = s.db.GetArea(uuid)
+		return err
+	})
+	if errors.Is(err, db.ErrNotFound) {
+		writeJSON(w, http.StatusAccepted, models.Area{UUID: uuid, Title: req.Title})
+		return
+	}
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, area)
+}
+
+// handleUpdateArea handles PATCH /areas/{uuid} and returns the renamed area
+func (s *Server) handleUpdateArea(w http.ResponseWriter, r *http.Request) {
+	uuid, err := s.db.ResolveAreaUUID(r.PathValue("uuid"))
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+
+	req, ok := decodeAreaRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.things.UpdateArea(uuid, req.Title); err != nil {
+		writeError(w, statusFor(err), "failed to update area: "+err.Error())
+		return
+	}
+
+	area, err := s.db.GetArea(uuid)
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, area)
+}
+
+// handleDeleteArea handles DELETE /areas/{uuid}
+func (s *Server) handleDeleteArea(w http.ResponseWriter, r *http.Request) {
+	uuid, err := s.db.ResolveAreaUUID(r.PathValue("uuid"))
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+
+	if err := s.things.DeleteArea(uuid); err != nil {
+		writeError(w, statusFor(err), "failed to delete area: "+err.Error())
+		return
+	}
+
+	writeSuccess(w, "area deleted")
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/models"
+	"thingies/internal/sandbox"
+	"thingies/internal/things"
+)
+
+// TestAreaCRUD creates, renames, and deletes an area against a sandbox
+// database, checking that writes return the area as read back
+func TestAreaCRUD(t *testing.T) {
+	b := dbtest.New(t)
+	b.Area("Work")
+	backend, err := sandbox.Open(b.Path())
+	if err != nil {
+		t.Fatalf("sandbox.Open: %v", err)
+	}
+	defer backend.Close()
+	s := New(Config{}, b.Open(), backend)
+
+	do := func(method, path, body string, want int) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, req)
+		if w.Code != want {
+			t.Fatalf("%s %s: expected %d, got %d; body: %s", method, path, want, w.Code, w.Body.String())
+		}
+		return w
+	}
+
+	var area models.Area
+	w := do(http.MethodPost, "/areas", `{"title": "Garden"}`, http.StatusOK)
+	if err := json.Unmarshal(w.Body.Bytes(), &area); err != nil {
+		t.Fatal(err)
+	}
+	if area.UUID == "" || area.Title != "Garden" {
+		t.Fatalf("expected the new area, got %+v", area)
+	}
+
+	w = do(http.MethodPatch, "/areas/"+area.UUID, `{"title": "Yard"}`, http.StatusOK)
+	if err := json.Unmarshal(w.Body.Bytes(), &area); err != nil {
+		t.Fatal(err)
+	}
+	if area.Title != "Yard" {
+		t.Errorf("expected the renamed area, got %+v", area)
+	}
+
+	do(http.MethodPost, "/areas", `{}`, http.StatusBadRequest)
+	do(http.MethodPost, "/areas", `{"title": "x", "color": "red"}`, http.StatusBadRequest)
+	do(http.MethodPatch, "/areas/Nope", `{"title": "x"}`, http.StatusNotFound)
+
+	do(http.MethodDelete, "/areas/"+area.UUID, "", http.StatusOK)
+	do(http.MethodGet, "/areas/"+area.UUID, "", http.StatusNotFound)
+}
+
+// TestCreateAreaReadBack checks that POST /areas waits for a row that
+// reaches the database after AppleScript answers, and answers 202 with the
+// reported UUID if it never does
+func TestCreateAreaReadBack(t *testing.T) {
+	b := dbtest.New(t)
+	conn, err := sql.Open("sqlite", b.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// Things keeps its database in WAL mode, so reads never wait on its writes
+	if _, err := conn.Exec(`PRAGMA journal_mode = WAL`); err != nil {
+		t.Fatal(err)
+	}
+	rec := things.NewRecordingBackend()
+	s := New(Config{CreateTimeout: 2 * time.Second}, b.Open(), rec)
+
+	rec.Output = "Late000000000000000000"
+	written := make(chan error, 1)
+	time.AfterFunc(200*time.Millisecond, func() {
+		_, err := conn.Exec(`INSERT INTO TMArea (uuid, title, visible, "index") VALUES ('Late000000000000000000', 'Garden', NULL, 1)`)
+		written <- err
+	})
+
+	w := serve(s, http.MethodPost, "/areas", "", `{"title": "Garden"}`)
+	if err := <-written; err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"uuid":"Late000000000000000000"`) {
+		t.Fatalf("expected the area once it appeared, got %d: %s", w.Code, w.Body.String())
+	}
+
+	s = New(Config{CreateTimeout: 150 * time.Millisecond}, b.Open(), rec)
+	rec.Output = "Lost000000000000000000"
+	w = serve(s, http.MethodPost, "/areas", "", `{"title": "Shed"}`)
+	var area models.Area
+	if err := json.Unmarshal(w.Body.Bytes(), &area); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusAccepted || area.UUID != "Lost000000000000000000" || area.Title != "Shed" {
+		t.Errorf("expected 202 with the reported UUID and title, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestAreaIncludeCompleted checks that the area routes take include-completed
+// like the project routes, and still take the include_completed they had
+func TestAreaIncludeCompleted(t *testing.T) {
+	b := dbtest.New(t)
+	work := b.Area("Work").WithUUID("Work000000000000000000")
+	b.Task("open").InArea(work)
+	b.Task("done").InArea(work).Completed()
+	b.Project("shipped").InArea(work).Completed()
+	s := New(Config{}, b.Open(), things.NewRecordingBackend())
+
+	for _, tt := range []struct {
+		query string
+		tasks int
+	}{
+		{"", 1},
+		{"?include-completed=true", 2},
+		{"?include_completed=true", 2},
+	} {
+		var tasks, projects []json.RawMessage
+		for path, into := range map[string]*[]json.RawMessage{"tasks": &tasks, "projects": &projects} {
+			w := serve(s, http.MethodGet, "/areas/Work/"+path+tt.query, "", "")
+			if err := json.Unmarshal(w.Body.Bytes(), into); err != nil {
+				t.Fatalf("%s%s: %d %s", path, tt.query, w.Code, w.Body.String())
+			}
+		}
+		if len(tasks) != tt.tasks || len(projects) != tt.tasks-1 {
+			t.Errorf("%q: expected %d tasks and %d projects, got %d and %d", tt.query, tt.tasks, tt.tasks-1, len(tasks), len(projects))
+		}
+	}
+}
@@ -0,0 +1,401 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Scope is what an API key may do. Each scope includes the ones before it.
+type Scope string
+
+const (
+	// ScopeReadOnly allows GET requests
+	ScopeReadOnly Scope = "read-only"
+	// ScopeWrite also allows creating, updating, completing, and deleting
+	// tasks, projects, and headings
+	ScopeWrite Scope = "write"
+	// ScopeAdmin also allows changes to areas and tags
+	ScopeAdmin Scope = "admin"
+)
+
+// level orders scopes so that a higher level includes the lower ones
+func (s Scope) level() int {
+	switch s {
+	case ScopeReadOnly:
+		return 1
+	case ScopeWrite:
+		return 2
+	case ScopeAdmin:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// APIKey is one entry in the auth config file
+type APIKey struct {
+	Name  string   `json:"name"`            // shown in logs, never the key itself
+	Key   string   `json:"key"`             // bearer token
+	Scope Scope    `json:"scope"`           // read-only, write, or admin
+	Areas []string `json:"areas,omitempty"` // optional allow-list of area names or UUIDs
+
+	hash [sha256.Size]byte
+}
+
+// AuthConfig is the file passed to `thingies serve --auth-config`:
+//
+//	{"keys": [
+//	  {"name": "phone", "key": "...", "scope": "read-only", "areas": ["Work"]},
+//	  {"name": "laptop", "key": "...", "scope": "admin"}
+//	]}
+type AuthConfig struct {
+	Keys []APIKey `json:"keys"`
+}
+
+// LoadAuthConfig reads and validates an auth config file
+func LoadAuthConfig(path string) (*AuthConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth config: %w", err)
+	}
+
+	var cfg AuthConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid auth config %s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid auth config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// validate checks every key and precomputes its hash
+func (c *AuthConfig) validate() error {
+	if len(c.Keys) == 0 {
+		return fmt.Errorf("no keys defined")
+	}
+	seen := make(map[[sha256.Size]byte]string)
+	for i := range c.Keys {
+		k := &c.Keys[i]
+		if k.Name == "" {
+			k.Name = fmt.Sprintf("key-%d", i+1)
+		}
+		if len(k.Key) < 16 {
+			return fmt.Errorf("key %q: key must be at least 16 characters", k.Name)
+		}
+		if k.Scope.level() == 0 {
+			return fmt.Errorf("key %q: unknown scope %q (want read-only, write, or admin)", k.Name, k.Scope)
+		}
+		k.hash = sha256.Sum256([]byte(k.Key))
+		if other, ok := seen[k.hash]; ok {
+			return fmt.Errorf("keys %q and %q are identical", other, k.Name)
+		}
+		seen[k.hash] = k.Name
+	}
+	return nil
+}
+
+// authenticate returns the key matching an Authorization header, if any
+func (c *AuthConfig) authenticate(header string) (*APIKey, bool) {
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || token == "" {
+		return nil, false
+	}
+	hash := sha256.Sum256([]byte(strings.TrimSpace(token)))
+
+	var match *APIKey
+	for i := range c.Keys {
+		if subtle.ConstantTimeCompare(hash[:], c.Keys[i].hash[:]) == 1 {
+			match = &c.Keys[i]
+		}
+	}
+	return match, match != nil
+}
+
+// requiredScope returns the scope a request needs: reads need read-only,
+// area and tag changes and the webhook routes, which show endpoint URLs,
+// need admin, every other change needs write
+func requiredScope(r *http.Request) Scope {
+	if strings.HasPrefix(r.URL.Path, "/webhooks") {
+		return ScopeAdmin
+	}
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return ScopeReadOnly
+	}
+	if strings.HasPrefix(r.URL.Path, "/areas") || strings.HasPrefix(r.URL.Path, "/tags") {
+		return ScopeAdmin
+	}
+	return ScopeWrite
+}
+
+// publicPaths are served without an API key: the health check and the API
+// description, which holds no data
+var publicPaths = map[string]bool{
+	"/health":       true,
+	"/openapi.json": true,
+	"/docs":         true,
+}
+
+// maxAreaCheckBody bounds the request bodies of area-restricted keys, which
+// the area check buffers to find a create's or move's destination
+const maxAreaCheckBody = 1 << 20
+
+// authMiddleware rejects requests without a valid bearer token of sufficient
+// scope. It is a no-op when the server has no auth config. publicPaths and
+// CORS preflight requests are always allowed.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	if s.config.Auth == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if publicPaths[r.URL.Path] || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, ok := s.config.Auth.authenticate(r.Header.Get("Authorization"))
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="thingies"`)
+			s.deny(w, r, nil, http.StatusUnauthorized, "missing or invalid API key")
+			return
+		}
+
+		if need := requiredScope(r); key.Scope.level() < need.level() {
+			s.deny(w, r, key, http.StatusForbidden, fmt.Sprintf("key scope %s does not allow this request (needs %s)", key.Scope, need))
+			return
+		}
+
+		if len(key.Areas) > 0 {
+			// The area check reads the body before any handler limit applies
+			r.Body = http.MaxBytesReader(w, r.Body, maxAreaCheckBody)
+			if status, reason := s.checkAreas(key, r); reason != "" {
+				s.deny(w, r, key, status, reason)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// deny logs a rejected request and writes the error response
+func (s *Server) deny(w http.ResponseWriter, r *http.Request, key *APIKey, status int, reason string) {
+	name := "-"
+	if key != nil {
+		name = key.Name
+	}
+	log.Printf("auth: denied %s %s from %s (key %s): %s", r.Method, r.URL.Path, r.RemoteAddr, name, reason)
+	writeError(w, status, reason)
+}
+
+// checkAreas enforces a key's area allow-list. Area-restricted keys may only
+// use routes that name a specific item ({uuid} in the pattern) and creates
+// whose destination can be resolved; everything else is denied, because
+// collection views would leak items from other areas. A move needs both the
+// item and its destination to be allowed. Returns the status and reason of
+// a denial, or "" if allowed. It fails closed: a target that cannot be
+// resolved or a body that cannot be read is denied with the error's status.
+func (s *Server) checkAreas(key *APIKey, r *http.Request) (int, string) {
+	_, pattern := s.mux.Handler(r)
+	if pattern == "" {
+		return 0, "" // unknown route, let the mux answer 404/405
+	}
+
+	var area string
+	var err error
+	switch pattern {
+	case "POST /tasks":
+		area, err = s.createTaskArea(r)
+	case "POST /projects":
+		area, err = s.createProjectArea(r)
+	case "POST /tasks/{uuid}/move":
+		uuid, _ := pathValue(pattern, r.URL.Path, "uuid")
+		area, err = s.itemArea(pattern, uuid)
+		if err == nil && s.areaAllowed(key, area) {
+			area, err = s.moveTargetArea(r)
+		}
+	case "POST /projects/{uuid}/move":
+		uuid, _ := pathValue(pattern, r.URL.Path, "uuid")
+		area, err = s.itemArea(pattern, uuid)
+		if err == nil && s.areaAllowed(key, area) {
+			area, err = s.projectMoveArea(r)
+		}
+	default:
+		uuid, ok := pathValue(pattern, r.URL.Path, "uuid")
+		if !ok {
+			return http.StatusForbidden, fmt.Sprintf("key %s is restricted to areas and cannot use %s", key.Name, pattern)
+		}
+		area, err = s.itemArea(pattern, uuid)
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge, fmt.Sprintf("request body is larger than %d bytes", tooLarge.Limit)
+	}
+	if errors.Is(err, errInvalidBody) {
+		return http.StatusBadRequest, err.Error()
+	}
+	if err != nil {
+		return statusFor(err), err.Error()
+	}
+
+	if !s.areaAllowed(key, area) {
+		return http.StatusForbidden, "item is not in any area allowed for this key"
+	}
+	return 0, ""
+}
+
+// areaAllowed reports whether area is in the key's allow-list. Items outside
+// any area ("") are never allowed.
+func (s *Server) areaAllowed(key *APIKey, area string) bool {
+	if area == "" {
+		return false
+	}
+	for _, allowed := range key.Areas {
+		resolved, err := s.db.ResolveAreaID(allowed)
+		if err == nil && resolved == area {
+			return true
+		}
+	}
+	return false
+}
+
+// itemArea resolves the {uuid} of a route to the area its item lives in
+func (s *Server) itemArea(pattern, uuid string) (string, error) {
+	path := pattern[strings.Index(pattern, " ")+1:]
+	switch {
+	case strings.HasPrefix(path, "/areas/"):
+		return s.db.ResolveAreaUUID(uuid)
+	case strings.HasPrefix(path, "/tags/"):
+		return "", nil // tags are shared by every area
+	case strings.HasPrefix(path, "/projects/"):
+		uuid, err := s.db.ResolveProjectUUID(uuid)
+		if err != nil {
+			return "", err
+		}
+		return s.db.GetItemArea(uuid)
+	case strings.HasPrefix(path, "/headings/"):
+		uuid, err := s.db.ResolveHeadingUUID(uuid)
+		if err != nil {
+			return "", err
+		}
+		return s.db.GetItemArea(uuid)
+	case strings.HasPrefix(path, "/trash/"):
+		uuid, err := s.db.ResolveTrashUUID(uuid)
+		if err != nil {
+			return "", err
+		}
+		return s.db.GetItemArea(uuid)
+	default:
+		uuid, err := s.db.ResolveTaskUUID(uuid)
+		if err != nil {
+			return "", err
+		}
+		return s.db.GetItemArea(uuid)
+	}
+}
+
+// createTaskArea returns the area a POST /tasks body files the task into
+func (s *Server) createTaskArea(r *http.Request) (string, error) {
+	var req TaskCreateRequest
+	if err := peekJSON(r, &req); err != nil {
+		return "", err
+	}
+	if req.List == "" {
+		return "", nil // Inbox
+	}
+	if project, err := s.db.ResolveProjectID(req.List); err == nil {
+		return s.db.GetItemArea(project)
+	}
+	// An unknown list would land the task in the Inbox, which no key may target
+	area, _ := s.db.ResolveAreaID(req.List)
+	return area, nil
+}
+
+// createProjectArea returns the area a POST /projects body files the project into
+func (s *Server) createProjectArea(r *http.Request) (string, error) {
+	var req ProjectCreateRequest
+	if err := peekJSON(r, &req); err != nil {
+		return "", err
+	}
+	if req.Area == "" {
+		return "", nil
+	}
+	area, _ := s.db.ResolveAreaID(req.Area)
+	return area, nil
+}
+
+// moveTargetArea returns the area a POST /tasks/{uuid}/move body moves the
+// task into; "" for the Inbox
+func (s *Server) moveTargetArea(r *http.Request) (string, error) {
+	var req TaskMoveRequest
+	if err := peekJSON(r, &req); err != nil {
+		return "", err
+	}
+	target, err := s.db.ResolveMoveTarget(req.Inbox, req.Project, req.Area, req.Heading)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case target.Area != "":
+		return target.Area, nil
+	case target.Project != "":
+		return s.db.GetItemArea(target.Project)
+	default:
+		return "", nil
+	}
+}
+
+// projectMoveArea returns the area a POST /projects/{uuid}/move body moves
+// the project into
+func (s *Server) projectMoveArea(r *http.Request) (string, error) {
+	var req ProjectMoveRequest
+	if err := peekJSON(r, &req); err != nil {
+		return "", err
+	}
+	return s.db.ResolveAreaID(req.Area)
+}
+
+// errInvalidBody marks a body the area check could not decode
+var errInvalidBody = errors.New("invalid request body")
+
+// peekJSON decodes the request body into v and restores it for the handler
+func peekJSON(r *http.Request, v interface{}) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidBody, err)
+	}
+	return nil
+}
+
+// pathValue extracts a {name} wildcard from path using the route pattern,
+// which the middleware needs because the mux has not populated PathValue yet
+func pathValue(pattern, path, name string) (string, bool) {
+	if i := strings.Index(pattern, " "); i >= 0 {
+		pattern = pattern[i+1:]
+	}
+	want := "{" + name + "}"
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range patternParts {
+		if part == want && i < len(pathParts) {
+			return pathParts[i], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,241 @@
+package server
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/things"
+)
+
+const (
+	readKey  = "read-key-0123456789"
+	writeKey = "write-key-0123456789"
+	adminKey = "admin-key-0123456789"
+	workKey  = "work-key-0123456789"
+)
+
+// newAuthServer returns a server with one key per scope plus a write key
+// restricted to the Work area, backed by a small fixture database.
+func newAuthServer(t *testing.T) (*Server, *things.RecordingBackend) {
+	t.Helper()
+	b := dbtest.New(t)
+	work := b.Area("Work")
+	home := b.Area("Home")
+	launch := b.Project("Launch").InArea(work).WithUUID("Launch0000000000000000")
+	b.Task("work task").WithUUID("WorkTask00000000000000").InArea(work)
+	b.Task("launch task").WithUUID("LaunchTask000000000000").UnderHeading(b.Heading(launch, "Prep"))
+	b.Task("home task").WithUUID("HomeTask00000000000000").InArea(home)
+	b.Task("inbox task").WithUUID("InboxTask0000000000000")
+	b.Task("trashed work task").WithUUID("TrashedWork00000000000").InArea(work).Trashed()
+	b.Task("trashed home task").WithUUID("TrashedHome00000000000").InArea(home).Trashed()
+
+	auth := &AuthConfig{Keys: []APIKey{
+		{Name: "reader", Key: readKey, Scope: ScopeReadOnly},
+		{Name: "writer", Key: writeKey, Scope: ScopeWrite},
+		{Name: "admin", Key: adminKey, Scope: ScopeAdmin},
+		{Name: "work", Key: workKey, Scope: ScopeWrite, Areas: []string{"Work"}},
+	}}
+	if err := auth.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	rec := things.NewRecordingBackend()
+	return New(Config{Auth: auth}, b.Open(), rec), rec
+}
+
+func serve(s *Server, method, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthScopes(t *testing.T) {
+	s, _ := newAuthServer(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		want   int
+	}{
+		{"health is public", http.MethodGet, "/health", "", http.StatusOK},
+		{"preflight is public", http.MethodOptions, "/tasks", "", http.StatusOK},
+		{"missing key", http.MethodGet, "/today", "", http.StatusUnauthorized},
+		{"unknown key", http.MethodGet, "/today", "not-a-real-key-at-all", http.StatusUnauthorized},
+		{"read-only can read", http.MethodGet, "/today", readKey, http.StatusOK},
+		{"read-only cannot write", http.MethodPost, "/tasks/WorkTask/complete", readKey, http.StatusForbidden},
+		{"write can write", http.MethodPost, "/tasks/WorkTask/complete", writeKey, http.StatusOK},
+		{"admin can write", http.MethodDelete, "/tasks/HomeTask", adminKey, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, tt.method, tt.path, tt.key, "")
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d; body: %s", tt.want, w.Code, w.Body.String())
+			}
+			if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("expected WWW-Authenticate header on 401")
+			}
+		})
+	}
+}
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         Scope
+	}{
+		{http.MethodGet, "/areas", ScopeReadOnly},
+		{http.MethodPost, "/tasks", ScopeWrite},
+		{http.MethodDelete, "/headings/abc", ScopeWrite},
+		{http.MethodPost, "/areas", ScopeAdmin},
+		{http.MethodDelete, "/tags/abc", ScopeAdmin},
+		{http.MethodGet, "/webhooks/deliveries", ScopeAdmin},
+	}
+	for _, tt := range tests {
+		if got := requiredScope(httptest.NewRequest(tt.method, tt.path, nil)); got != tt.want {
+			t.Errorf("%s %s: got %s, want %s", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAuthAreaAllowList(t *testing.T) {
+	s, rec := newAuthServer(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"task in area", http.MethodGet, "/tasks/WorkTask", "", http.StatusOK},
+		{"task under heading of project in area", http.MethodPost, "/tasks/LaunchTask/complete", "", http.StatusOK},
+		{"project in area", http.MethodGet, "/projects/Launch/tasks", "", http.StatusOK},
+		{"task in other area", http.MethodGet, "/tasks/HomeTask", "", http.StatusForbidden},
+		{"inbox task", http.MethodDelete, "/tasks/InboxTask", "", http.StatusForbidden},
+		{"collection view", http.MethodGet, "/today", "", http.StatusForbidden},
+		{"search", http.MethodGet, "/tasks/search?q=task", "", http.StatusForbidden},
+		{"unknown task", http.MethodGet, "/tasks/Nope", "", http.StatusNotFound},
+		{"create in project in area", http.MethodPost, "/tasks", `{"title": "x", "list": "Launch"}`, http.StatusOK},
+		{"create in area", http.MethodPost, "/tasks", `{"title": "x", "list": "Work"}`, http.StatusOK},
+		{"create in other area", http.MethodPost, "/tasks", `{"title": "x", "list": "Home"}`, http.StatusForbidden},
+		{"create in inbox", http.MethodPost, "/tasks", `{"title": "x"}`, http.StatusForbidden},
+		{"create in unknown list", http.MethodPost, "/tasks", `{"title": "x", "list": "Nowhere"}`, http.StatusForbidden},
+		{"create project in area", http.MethodPost, "/projects", `{"title": "x", "area": "Work"}`, http.StatusOK},
+		{"create project outside areas", http.MethodPost, "/projects", `{"title": "x"}`, http.StatusForbidden},
+		{"move within area", http.MethodPost, "/tasks/WorkTask/move", `{"project": "Launch"}`, http.StatusOK},
+		{"move out of area", http.MethodPost, "/tasks/WorkTask/move", `{"area": "Home"}`, http.StatusForbidden},
+		{"move to inbox", http.MethodPost, "/tasks/WorkTask/move", `{"inbox": true}`, http.StatusForbidden},
+		{"move into area", http.MethodPost, "/tasks/HomeTask/move", `{"area": "Work"}`, http.StatusForbidden},
+		{"complete project in area", http.MethodPost, "/projects/Launch/complete", "", http.StatusOK},
+		{"move project out of area", http.MethodPost, "/projects/Launch/move", `{"area": "Home"}`, http.StatusForbidden},
+		{"trash view", http.MethodGet, "/trash", "", http.StatusForbidden},
+		{"restore from area", http.MethodPost, "/trash/TrashedWork/restore", "", http.StatusOK},
+		{"restore from other area", http.MethodPost, "/trash/TrashedHome/restore", "", http.StatusForbidden},
+
+		// Anything the check cannot resolve is denied, never passed on
+		{"create with malformed body", http.MethodPost, "/tasks", `{"title": "x", "list": `, http.StatusBadRequest},
+		{"create project with malformed body", http.MethodPost, "/projects", `{"area": 1}`, http.StatusBadRequest},
+		{"move unknown task", http.MethodPost, "/tasks/Nope/move", `{"area": "Work"}`, http.StatusNotFound},
+		{"move with malformed body", http.MethodPost, "/tasks/WorkTask/move", `not json`, http.StatusBadRequest},
+		{"move to unknown project", http.MethodPost, "/tasks/WorkTask/move", `{"project": "Nowhere"}`, http.StatusNotFound},
+		{"move with two destinations", http.MethodPost, "/tasks/WorkTask/move", `{"area": "Work", "inbox": true}`, http.StatusBadRequest},
+		{"move unknown project", http.MethodPost, "/projects/Nope/move", `{"area": "Work"}`, http.StatusNotFound},
+		{"move project to unknown area", http.MethodPost, "/projects/Launch/move", `{"area": "Nowhere"}`, http.StatusNotFound},
+		{"move project with malformed body", http.MethodPost, "/projects/Launch/move", `{"area": [`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, tt.method, tt.path, workKey, tt.body)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d; body: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// The area check buffers the body, so it is bounded
+	big := `{"title": "x", "list": "Work", "notes": "` + strings.Repeat("x", maxAreaCheckBody) + `"}`
+	if w := serve(s, http.MethodPost, "/tasks", workKey, big); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body: expected 413, got %d", w.Code)
+	}
+
+	// The handler must still see the body the middleware peeked at
+	for _, u := range rec.URLs() {
+		if strings.Contains(u, "list=Home") || strings.Contains(u, "list=Nowhere") {
+			t.Errorf("denied create reached the backend: %s", u)
+		}
+	}
+	if len(rec.URLs()) != 3 {
+		t.Errorf("expected 3 allowed creates, got %v", rec.URLs())
+	}
+}
+
+func TestAuthLogsDenials(t *testing.T) {
+	s, _ := newAuthServer(t)
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	serve(s, http.MethodPost, "/tasks/WorkTask/cancel", readKey, "")
+
+	out := buf.String()
+	if !strings.Contains(out, "auth: denied POST /tasks/WorkTask/cancel") || !strings.Contains(out, "key reader") {
+		t.Errorf("expected denial to be logged with key name, got %q", out)
+	}
+	if strings.Contains(out, readKey) {
+		t.Error("log must not contain the key itself")
+	}
+}
+
+func TestLoadAuthConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"valid", `{"keys": [{"name": "a", "key": "0123456789abcdef", "scope": "write", "areas": ["Work"]}]}`, ""},
+		{"no keys", `{"keys": []}`, "no keys defined"},
+		{"short key", `{"keys": [{"key": "short", "scope": "admin"}]}`, "at least 16 characters"},
+		{"bad scope", `{"keys": [{"key": "0123456789abcdef", "scope": "root"}]}`, "unknown scope"},
+		{"duplicate", `{"keys": [{"key": "0123456789abcdef", "scope": "admin"}, {"key": "0123456789abcdef", "scope": "write"}]}`, "identical"},
+		{"unknown field", `{"keys": [{"key": "0123456789abcdef", "scope": "admin", "area": "Work"}]}`, "unknown field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "keys.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := LoadAuthConfig(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if _, ok := cfg.authenticate("Bearer 0123456789abcdef"); !ok {
+					t.Error("expected loaded key to authenticate")
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"thingies/internal/things"
+)
+
+// TestCreateTaskUsesBackend verifies that POST /tasks hands the add URL to the
+// injected backend instead of shelling out to `open`.
+func TestCreateTaskUsesBackend(t *testing.T) {
+	rec := things.NewRecordingBackend()
+	s := New(Config{}, nil, rec)
+
+	body := `{"title": "Buy milk", "when": "today", "tags": "errands"}`
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	s.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+
+	urls := rec.URLs()
+	if len(urls) != 1 {
+		t.Fatalf("expected 1 URL, got %v", urls)
+	}
+	want := "things:///add?tags=errands&title=Buy%20milk&when=today"
+	if urls[0] != want {
+		t.Errorf("expected URL %q, got %q", want, urls[0])
+	}
+}
+
+// TestCreateProjectUsesBackend verifies that POST /projects hands the
+// add-project URL to the injected backend.
+func TestCreateProjectUsesBackend(t *testing.T) {
+	rec := things.NewRecordingBackend()
+	s := New(Config{}, nil, rec)
+
+	body := `{"title": "Q1 Review", "area": "Work", "todos": ["Gather metrics", "Draft report"]}`
+	req := httptest.NewRequest(http.MethodPost, "/projects", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	s.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+
+	urls := rec.URLs()
+	if len(urls) != 1 || !strings.HasPrefix(urls[0], "things:///add-project?") {
+		t.Fatalf("expected one add-project URL, got %v", urls)
+	}
+	if !strings.Contains(urls[0], "to-dos=Gather%20metrics%0ADraft%20report") {
+		t.Errorf("expected newline-joined to-dos in URL, got %s", urls[0])
+	}
+}
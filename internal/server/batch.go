@@ -0,0 +1,48 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"thingies/internal/things"
+)
+
+// BatchCreateRequest is the request body for POST /batch/create: to-dos and
+// projects in the things:///json format, with nested headings, to-dos, and
+// checklist items
+type BatchCreateRequest struct {
+	Items []things.JSONItem `json:"items"`
+}
+
+// handleBatchCreate handles POST /batch/create. The items go to Things in as
+// few things:///json URLs as fit, usually one. RunJSON paces requests that
+// follow each other within 10 seconds, so a request may wait that long.
+func (s *Server) handleBatchCreate(w http.ResponseWriter, r *http.Request) {
+	var req BatchCreateRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if things.JSONNeedsAuth(req.Items) {
+		writeError(w, http.StatusBadRequest, "batch/create only creates; update items with PATCH /tasks/{uuid} or /projects/{uuid}")
+		return
+	}
+	// Things takes this many per 10 seconds; more would keep the request
+	// waiting past the server's write timeout
+	if n := things.CountJSONItems(req.Items); n > things.MaxJSONItems {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("batch/create takes at most %d items per request, got %d", things.MaxJSONItems, n))
+		return
+	}
+
+	extendWriteDeadline(w, things.JSONRateWindow)
+	urls, err := s.things.RunJSON(r.Context(), req.Items, "")
+	if err != nil {
+		writeError(w, statusFor(err), "failed to create items: "+err.Error())
+		return
+	}
+
+	writeSuccess(w, fmt.Sprintf("%d item(s) sent to Things in %d URL(s)", things.CountJSONItems(req.Items), urls))
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/sandbox"
+	"thingies/internal/things"
+)
+
+// TestBatchCreate creates a project with headings, to-dos, and a checklist
+// through POST /batch/create against a sandbox database and reads it back
+func TestBatchCreate(t *testing.T) {
+	b := dbtest.New(t)
+	b.Area("Work")
+	backend, err := sandbox.Open(b.Path())
+	if err != nil {
+		t.Fatalf("sandbox.Open: %v", err)
+	}
+	defer backend.Close()
+	thingsDB := b.Open()
+	s := New(Config{}, thingsDB, backend)
+
+	do := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/batch/create", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(`{"items": [
+		{"type": "project", "attributes": {"title": "Launch", "area": "Work", "items": [
+			{"type": "to-do", "attributes": {"title": "Kickoff"}},
+			{"type": "heading", "attributes": {"title": "Prep"}},
+			{"type": "to-do", "attributes": {"title": "Pack", "checklist-items": [
+				{"type": "checklist-item", "attributes": {"title": "Passport"}},
+				{"type": "checklist-item", "attributes": {"title": "Charger", "completed": true}}]}}]}},
+		{"type": "to-do", "attributes": {"title": "Follow up", "list": "Launch", "heading": "Prep"}}]}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "5 item(s) sent to Things in 1 URL(s)") {
+		t.Fatalf("expected 5 items in 1 URL, got %d: %s", w.Code, w.Body.String())
+	}
+
+	project, err := thingsDB.ResolveProjectID("Launch")
+	if err != nil {
+		t.Fatalf("ResolveProjectID: %v", err)
+	}
+	tasks, err := thingsDB.GetProjectTasks(project, false)
+	if err != nil {
+		t.Fatalf("GetProjectTasks: %v", err)
+	}
+	var got []string
+	for _, task := range tasks {
+		got = append(got, task.Title+"@"+task.HeadingName.String+"/"+task.AreaName.String)
+		if task.Title == "Pack" && (task.ChecklistTotal != 2 || task.ChecklistDone != 1) {
+			t.Errorf("expected Pack's checklist at 1/2, got %d/%d", task.ChecklistDone, task.ChecklistTotal)
+		}
+	}
+	if strings.Join(got, " ") != "Kickoff@/Work Pack@Prep/Work Follow up@Prep/Work" {
+		t.Errorf("unexpected project tasks %v", got)
+	}
+
+	for _, tt := range []struct {
+		body string
+		want int
+	}{
+		{`{"items": []}`, http.StatusBadRequest},
+		{`{"items": [{"type": "heading", "attributes": {"title": "Loose"}}]}`, http.StatusBadRequest},
+		{`{"items": [{"type": "to-do", "operation": "update", "id": "x", "attributes": {}}]}`, http.StatusBadRequest},
+		{`{"items": [{"type": "to-do", "attributes": {"title": "x", "colour": "red"}}]}`, http.StatusBadRequest},
+		{`{"items": [` + strings.Repeat(`{"type": "to-do", "attributes": {"title": "x"}},`, things.MaxJSONItems) + `{"type": "to-do", "attributes": {"title": "x"}}]}`, http.StatusBadRequest},
+	} {
+		if w := do(tt.body); w.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d; body: %s", tt.body, tt.want, w.Code, w.Body.String())
+		}
+	}
+}
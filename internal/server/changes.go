@@ -0,0 +1,21 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+)
+
+// handleChanges handles GET /changes?since=<RFC3339|cursor>. Without since
+// it returns everything as created, for a first full sync.
+func (s *Server) handleChanges(w http.ResponseWriter, r *http.Request) {
+	// An unescaped "+" in a time zone offset arrives as a space; cursors and
+	// RFC3339 times never contain spaces
+	since := strings.ReplaceAll(r.URL.Query().Get("since"), " ", "+")
+
+	changes, err := s.db.Changes(since)
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, changes.ToJSON())
+}
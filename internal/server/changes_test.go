@@ -0,0 +1,65 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/models"
+	"thingies/internal/things"
+)
+
+func TestChanges(t *testing.T) {
+	hourAgo, dayAgo := time.Now().Add(-time.Hour), time.Now().Add(-24*time.Hour)
+	b := dbtest.New(t)
+	b.Task("Old").Created(dayAgo).Modified(dayAgo)
+	b.Task("New").WithUUID("New0000000000000000000").Created(hourAgo).Modified(hourAgo)
+	b.Task("Gone").WithUUID("Gone000000000000000000").Created(dayAgo).Modified(hourAgo).Trashed()
+	s := New(Config{}, b.Open(), things.NewRecordingBackend())
+
+	get := func(path string) (*httptest.ResponseRecorder, models.ChangesJSON) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, req)
+		var changes models.ChangesJSON
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &changes); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w, changes
+	}
+
+	since := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	w, changes := get("/changes?since=" + since)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	if len(changes.Created.Tasks) != 1 || changes.Created.Tasks[0].UUID != "New0000000000000000000" {
+		t.Errorf("expected New created, got %+v", changes.Created.Tasks)
+	}
+	if len(changes.Deleted.Tasks) != 1 || changes.Deleted.Tasks[0] != "Gone000000000000000000" {
+		t.Errorf("expected Gone deleted, got %+v", changes.Deleted)
+	}
+	if changes.Updated.Tasks == nil || changes.Updated.Projects == nil || changes.Deleted.Headings == nil {
+		t.Errorf("expected empty arrays rather than null, got %s", w.Body.String())
+	}
+	if changes.Cursor == "" || changes.Since != since {
+		t.Errorf("expected a cursor and since %s, got %q and %q", since, changes.Cursor, changes.Since)
+	}
+
+	w, next := get("/changes?since=" + changes.Cursor)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	if len(next.Created.Tasks)+len(next.Updated.Tasks)+len(next.Deleted.Tasks) != 0 || next.Areas != nil || next.Tags != nil {
+		t.Errorf("expected no changes since the cursor, got %s", w.Body.String())
+	}
+
+	if w, _ := get("/changes?since=not-a-cursor"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad cursor, got %d", w.Code)
+	}
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"thingies/internal/db"
+	"thingies/internal/models"
+)
+
+// ChecklistAddRequest is the request body for adding checklist items
+type ChecklistAddRequest struct {
+	Items []string `json:"items"`
+	Top   bool     `json:"top,omitempty"` // add at the top instead of the end
+}
+
+// ChecklistReorderRequest is the request body for reordering a checklist.
+// The named items move to the top in this order; the rest keep theirs.
+type ChecklistReorderRequest struct {
+	Order []string `json:"order"` // 1-based positions or titles
+}
+
+// resolveTask resolves the {uuid} path value of a task route, writing the
+// error response and returning "" if it does not name a task
+func (s *Server) resolveTask(w http.ResponseWriter, r *http.Request) string {
+	uuid := r.PathValue("uuid")
+	if uuid == "" {
+		writeError(w, http.StatusBadRequest, "task UUID is required")
+		return ""
+	}
+	resolved, err := s.db.ResolveTaskUUID(uuid)
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return ""
+	}
+	return resolved
+}
+
+// handleGetChecklist handles GET /tasks/{uuid}/checklist
+func (s *Server) handleGetChecklist(w http.ResponseWriter, r *http.Request) {
+	uuid := s.resolveTask(w, r)
+	if uuid == "" {
+		return
+	}
+
+	items, err := s.db.GetTaskChecklistItems(uuid)
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+	if items == nil {
+		items = []models.ChecklistItem{}
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// handleAddChecklistItems handles POST /tasks/{uuid}/checklist
+func (s *Server) handleAddChecklistItems(w http.ResponseWriter, r *http.Request) {
+	uuid := s.resolveTask(w, r)
+	if uuid == "" {
+		return
+	}
+
+	var req ChecklistAddRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if len(req.Items) == 0 {
+		writeError(w, http.StatusBadRequest, "items is required")
+		return
+	}
+
+	token, err := s.db.GetAuthToken()
+	if err != nil {
+		writeError(w, statusFor(err), "failed to get auth token: "+err.Error())
+		return
+	}
+	if err := s.things.AddChecklistItems(uuid, req.Items, req.Top, token); err != nil {
+		writeError(w, statusFor(err), "failed to add checklist items: "+err.Error())
+		return
+	}
+
+	writeSuccess(w, "checklist items added")
+}
+
+// handleCheckChecklistItem handles POST /tasks/{uuid}/checklist/{item}/check
+func (s *Server) handleCheckChecklistItem(w http.ResponseWriter, r *http.Request) {
+	s.editChecklist(w, r, []string{r.PathValue("item")}, "checklist item checked", func(items []models.ChecklistItem, refs []string) ([]models.ChecklistItem, error) {
+		return db.CheckChecklistItems(items, refs, true)
+	})
+}
+
+// handleUncheckChecklistItem handles POST /tasks/{uuid}/checklist/{item}/uncheck
+func (s *Server) handleUncheckChecklistItem(w http.ResponseWriter, r *http.Request) {
+	s.editChecklist(w, r, []string{r.PathValue("item")}, "checklist item unchecked", func(items []models.ChecklistItem, refs []string) ([]models.ChecklistItem, error) {
+		return db.CheckChecklistItems(items, refs, false)
+	})
+}
+
+// handleRemoveChecklistItem handles DELETE /tasks/{uuid}/checklist/{item}
+func (s *Server) handleRemoveChecklistItem(w http.ResponseWriter, r *http.Request) {
+	s.editChecklist(w, r, []string{r.PathValue("item")}, "checklist item removed", db.RemoveChecklistItems)
+}
+
+// handleReorderChecklist handles POST /tasks/{uuid}/checklist/reorder
+func (s *Server) handleReorderChecklist(w http.ResponseWriter, r *http.Request) {
+	var req ChecklistReorderRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if len(req.Order) == 0 {
+		writeError(w, http.StatusBadRequest, "order is required")
+		return
+	}
+
+	s.editChecklist(w, r, req.Order, "checklist reordered", db.ReorderChecklist)
+}
+
+// editChecklist loads the checklist of the route's task, applies edit with
+// the item references in refs, and writes the whole checklist back
+func (s *Server) editChecklist(w http.ResponseWriter, r *http.Request, refs []string, message string, edit func([]models.ChecklistItem, []string) ([]models.ChecklistItem, error)) {
+	uuid := s.resolveTask(w, r)
+	if uuid == "" {
+		return
+	}
+
+	items, err := s.db.GetTaskChecklistItems(uuid)
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+	edited, err := edit(items, refs)
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+
+	token, err := s.db.GetAuthToken()
+	if err != nil {
+		writeError(w, statusFor(err), "failed to get auth token: "+err.Error())
+		return
+	}
+	if err := s.things.SetChecklist(uuid, edited, token); err != nil {
+		writeError(w, statusFor(err), "failed to update checklist: "+err.Error())
+		return
+	}
+
+	writeSuccess(w, message)
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/models"
+	"thingies/internal/sandbox"
+)
+
+// TestChecklistRoutes edits a checklist through every route against a
+// sandbox database and checks the list read back after each step
+func TestChecklistRoutes(t *testing.T) {
+	b := dbtest.New(t).AuthToken("secret")
+	b.Task("Pack").WithUUID("Pack000000000000000000").Checklist("Passport", "Charger")
+	backend, err := sandbox.Open(b.Path())
+	if err != nil {
+		t.Fatalf("sandbox.Open: %v", err)
+	}
+	defer backend.Close()
+	s := New(Config{}, b.Open(), backend)
+
+	do := func(method, path, body string, want int) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, req)
+		if w.Code != want {
+			t.Fatalf("%s %s: expected %d, got %d; body: %s", method, path, want, w.Code, w.Body.String())
+		}
+	}
+	checklist := func() string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/tasks/Pack/checklist", nil)
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, req)
+		var items []models.ChecklistItem
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+			t.Fatalf("GET checklist: %v; body: %s", err, w.Body.String())
+		}
+		var parts []string
+		for _, item := range items {
+			box := "[ ]"
+			if item.Completed {
+				box = "[x]"
+			}
+			parts = append(parts, box+item.Title)
+		}
+		return strings.Join(parts, " ")
+	}
+
+	steps := []struct {
+		method, path, body string
+		want               string
+	}{
+		{http.MethodPost, "/tasks/Pack/checklist", `{"items": ["Socks", "Tickets"]}`, "[ ]Passport [ ]Charger [ ]Socks [ ]Tickets"},
+		{http.MethodPost, "/tasks/Pack/checklist", `{"items": ["Visa"], "top": true}`, "[ ]Visa [ ]Passport [ ]Charger [ ]Socks [ ]Tickets"},
+		{http.MethodPost, "/tasks/Pack/checklist/2/check", "", "[ ]Visa [x]Passport [ ]Charger [ ]Socks [ ]Tickets"},
+		{http.MethodPost, "/tasks/Pack/checklist/socks/check", "", "[ ]Visa [x]Passport [ ]Charger [x]Socks [ ]Tickets"},
+		{http.MethodPost, "/tasks/Pack/checklist/Passport/uncheck", "", "[ ]Visa [ ]Passport [ ]Charger [x]Socks [ ]Tickets"},
+		{http.MethodPost, "/tasks/Pack/checklist/reorder", `{"order": ["5", "Charger"]}`, "[ ]Tickets [ ]Charger [ ]Visa [ ]Passport [x]Socks"},
+		{http.MethodDelete, "/tasks/Pack/checklist/Visa", "", "[ ]Tickets [ ]Charger [ ]Passport [x]Socks"},
+	}
+	for _, step := range steps {
+		do(step.method, step.path, step.body, http.StatusOK)
+		if got := checklist(); got != step.want {
+			t.Fatalf("%s %s: expected %s, got %s", step.method, step.path, step.want, got)
+		}
+	}
+
+	do(http.MethodPost, "/tasks/Pack/checklist/9/check", "", http.StatusNotFound)
+	do(http.MethodPost, "/tasks/Pack/checklist", `{"items": []}`, http.StatusBadRequest)
+	do(http.MethodPost, "/tasks/Pack/checklist/reorder", `{"order": []}`, http.StatusBadRequest)
+	do(http.MethodGet, "/tasks/Nope/checklist", "", http.StatusNotFound)
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"thingies/internal/db"
+	"thingies/internal/things"
+)
+
+// statusFor maps typed db and things errors to an HTTP status code:
+// 400 malformed query, move target, things JSON, or change cursor, 404 not found, 409 ambiguous prefix or name, 422 malformed prefix,
+// 501 write Things has no command for, 503 Things unreachable or not
+// permitted, 504 created item never showed up in the database, and 500 for
+// everything else.
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, db.ErrInvalidQuery), errors.Is(err, db.ErrInvalidMove), errors.Is(err, things.ErrInvalidJSON),
+		errors.Is(err, db.ErrInvalidCursor):
+		return http.StatusBadRequest
+	case errors.Is(err, db.ErrNotFound), errors.Is(err, things.ErrObjectMissing):
+		return http.StatusNotFound
+	case errors.Is(err, db.ErrAmbiguous):
+		return http.StatusConflict
+	case errors.Is(err, db.ErrInvalidPrefix):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, things.ErrUnsupported):
+		return http.StatusNotImplemented
+	case errors.Is(err, things.ErrAppNotRunning), errors.Is(err, things.ErrPermissionDenied):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, db.ErrCreateTimeout):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
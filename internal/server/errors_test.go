@@ -0,0 +1,68 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/things"
+)
+
+// TestErrorStatusCodes checks that typed db and things errors reach clients
+// as distinct HTTP statuses with JSON bodies.
+func TestErrorStatusCodes(t *testing.T) {
+	b := dbtest.New(t)
+	b.Task("one").WithUUID("Dup1000000000000000000")
+	b.Task("two").WithUUID("Dup2000000000000000000")
+	b.Task("three").WithUUID("Run0000000000000000000")
+	b.Project("Launch").WithUUID("Prj0000000000000000000")
+	thingsDB := b.Open()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		backendErr string
+		want       int
+	}{
+		{"unknown task", http.MethodGet, "/tasks/Nope", "", http.StatusNotFound},
+		{"unknown full UUID", http.MethodGet, "/tasks/Zzz0000000000000000000", "", http.StatusNotFound},
+		{"ambiguous prefix", http.MethodGet, "/tasks/Dup", "", http.StatusConflict},
+		{"ambiguous prefix on write", http.MethodPost, "/tasks/Dup/complete", "", http.StatusConflict},
+		{"invalid prefix", http.MethodGet, "/tasks/Du-p", "", http.StatusUnprocessableEntity},
+		{"unknown project", http.MethodGet, "/projects/Nope", "", http.StatusNotFound},
+		{"unknown project tasks", http.MethodGet, "/projects/Nope/tasks", "", http.StatusNotFound},
+		{"invalid project prefix", http.MethodGet, "/projects/Pr-j/headings", "", http.StatusUnprocessableEntity},
+		{"malformed change cursor", http.MethodGet, "/changes?since=yesterday", "", http.StatusBadRequest},
+		{"object missing in Things", http.MethodPost, "/tasks/Run/complete",
+			`applescript error: Things3 got an error: Can't get to do id "Run0000000000000000000". (-1728)`, http.StatusNotFound},
+		{"Things not running", http.MethodPost, "/tasks/Run/complete",
+			`applescript error: Things3 got an error: Application isn't running. (-600)`, http.StatusServiceUnavailable},
+		{"automation not permitted", http.MethodDelete, "/tasks/Run",
+			`applescript error: Not authorized to send Apple events to Things3. (-1743)`, http.StatusServiceUnavailable},
+		{"other AppleScript failure", http.MethodPost, "/tasks/Run/cancel",
+			`applescript error: syntax error (-2741)`, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := things.NewRecordingBackend()
+			if tt.backendErr != "" {
+				rec.Err = errors.New(tt.backendErr)
+			}
+			s := New(Config{}, thingsDB, rec)
+
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d; body: %s", tt.want, w.Code, w.Body.String())
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("expected a JSON error, got %q: %s", ct, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"thingies/internal/watch"
+)
+
+// Defaults for Config.WatchInterval and Config.Heartbeat
+const (
+	defaultWatchInterval = time.Second
+	defaultHeartbeat     = 15 * time.Second
+)
+
+// eventRetry is the reconnect delay, in milliseconds, suggested to clients
+const eventRetry = 3000
+
+// events returns the change feed, starting it on first use
+func (s *Server) events() (*watch.Feed, error) {
+	s.feedMu.Lock()
+	defer s.feedMu.Unlock()
+	if s.feed != nil {
+		return s.feed, nil
+	}
+	select {
+	case <-s.done:
+		return nil, errors.New("server is shutting down")
+	default:
+	}
+
+	interval := s.config.WatchInterval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	feed := watch.NewFeed(s.db, interval)
+	if err := feed.Start(ctx); err != nil {
+		cancel()
+		return nil, err
+	}
+	s.feed, s.stopFeed = feed, cancel
+	return feed, nil
+}
+
+// closeEvents ends the event streams and stops the change feed. It runs on
+// Shutdown, which would otherwise wait for the streams forever.
+func (s *Server) closeEvents() {
+	s.feedMu.Lock()
+	defer s.feedMu.Unlock()
+	select {
+	case <-s.done:
+		return
+	default:
+		close(s.done)
+	}
+	if s.stopFeed != nil {
+		s.stopFeed()
+	}
+}
+
+// handleEvents handles GET /events, a Server-Sent Events stream of changes
+// to the database (query: types, a comma-separated list of event types or
+// kinds such as "task.completed,project"). A client reconnecting with
+// Last-Event-ID (or last-event-id in the query) first gets the events it
+// missed, or a reset event if they are no longer known.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	feed, err := s.events()
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, "failed to watch database: "+err.Error())
+		return
+	}
+	types := watch.ParseTypes(r.URL.Query().Get("types"))
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last-event-id")
+	}
+
+	sub := feed.Subscribe(lastID)
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+	// A stream outlives the server's write timeout
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", eventRetry)
+	if sub.Reset {
+		// Move the client's Last-Event-ID past the events it can no longer get
+		fmt.Fprintf(w, "id: %s\nevent: reset\ndata: %s\n\n", sub.LastID,
+			`{"type":"reset","reason":"events after Last-Event-ID are no longer available; re-read your data"}`)
+	}
+	for _, event := range sub.Backlog {
+		if types.Match(event.Type) {
+			writeEvent(w, event)
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := s.config.Heartbeat
+	if heartbeat <= 0 {
+		heartbeat = defaultHeartbeat
+	}
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if !types.Match(event.Type) {
+				continue
+			}
+			writeEvent(w, event)
+		case now := <-ticker.C:
+			fmt.Fprintf(w, ": heartbeat %s\n\n", now.UTC().Format(time.RFC3339))
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes one event in the text/event-stream format
+func writeEvent(w http.ResponseWriter, event watch.Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/sandbox"
+)
+
+// sseEvent is one event read from a text/event-stream
+type sseEvent struct {
+	id, event, data string
+}
+
+// readEvents reads events from an event stream into a channel, skipping
+// retry lines, until the body ends; heartbeat comments are sent as events
+// named ":heartbeat"
+func readEvents(t *testing.T, resp *http.Response) <-chan sseEvent {
+	t.Helper()
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		var e sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if e != (sseEvent{}) {
+					events <- e
+				}
+				e = sseEvent{}
+			case strings.HasPrefix(line, ": heartbeat"):
+				e.event = ":heartbeat"
+			case strings.HasPrefix(line, "id: "):
+				e.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				e.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				e.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	return events
+}
+
+// nextEvent returns the next event other than a heartbeat
+func nextEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				t.Fatal("event stream ended")
+			}
+			if e.event != ":heartbeat" {
+				return e
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for an event")
+		}
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	b := dbtest.New(t)
+	b.Task("Write copy").WithUUID("Copy000000000000000000").Anytime()
+	backend, err := sandbox.Open(b.Path())
+	if err != nil {
+		t.Fatalf("sandbox.Open: %v", err)
+	}
+	defer backend.Close()
+	s := New(Config{WatchInterval: 20 * time.Millisecond, Heartbeat: 50 * time.Millisecond}, b.Open(), backend)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+	defer s.closeEvents()
+
+	open := func(lastID, types string) (*http.Response, <-chan sseEvent) {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/events?types="+types, nil)
+		if lastID != "" {
+			req.Header.Set("Last-Event-ID", lastID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("expected a 200 event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+		return resp, readEvents(t, resp)
+	}
+
+	_, events := open("", "task")
+	_, heartbeats := open("", "project")
+
+	resp, err := http.Post(ts.URL+"/tasks/Copy/complete", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("complete: expected 200, got %d", resp.StatusCode)
+	}
+
+	completed := nextEvent(t, events)
+	if completed.event != "task.completed" || completed.id == "" {
+		t.Fatalf("expected a task.completed event with an id, got %+v", completed)
+	}
+	var payload struct {
+		Type string `json:"type"`
+		UUID string `json:"uuid"`
+		Item struct {
+			Status string `json:"status"`
+		} `json:"item"`
+	}
+	if err := json.Unmarshal([]byte(completed.data), &payload); err != nil {
+		t.Fatalf("bad event data %q: %v", completed.data, err)
+	}
+	if payload.Type != "task.completed" || payload.UUID != "Copy000000000000000000" || payload.Item.Status != "completed" {
+		t.Errorf("unexpected payload: %s", completed.data)
+	}
+
+	// The project-only stream gets heartbeats but not the task event
+	select {
+	case e := <-heartbeats:
+		if e.event != ":heartbeat" {
+			t.Errorf("expected only heartbeats on the project stream, got %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a heartbeat")
+	}
+
+	// Resuming from before the event replays it; a foreign ID resets
+	first := strings.TrimSuffix(completed.id, "1") + "0"
+	_, resumed := open(first, "")
+	if e := nextEvent(t, resumed); e.id != completed.id || e.event != "task.completed" {
+		t.Errorf("expected the missed event on resume, got %+v", e)
+	}
+	_, reset := open("someotherrun-7", "")
+	if e := nextEvent(t, reset); e.event != "reset" || e.id != completed.id {
+		t.Errorf("expected a reset carrying the newest id, got %+v", e)
+	}
+}
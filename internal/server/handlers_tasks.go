@@ -2,8 +2,8 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
-	"strings"
 
 	"thingies/internal/db"
 	"thingies/internal/models"
@@ -17,11 +17,23 @@ func (s *Server) handleListTasks(w http.ResponseWriter, r *http.Request) {
 		Status:        query.Get("status"),
 		Area:          query.Get("area"),
 		Project:       query.Get("project"),
-		Tag:           query.Get("tag"),
+		Tags:          query["tag"],
+		AnyTags:       query["any-tag"],
+		NotTags:       query["not-tag"],
+		Subtags:       query.Get("subtags") == "true",
 		Today:         query.Get("today") == "true",
 		IncludeFuture: query.Get("include-future") == "true",
 	}
 
+	if q := query.Get("q"); q != "" {
+		parsed, err := db.ParseQuery(q)
+		if err != nil {
+			s.writeError(w, statusFor(err), err.Error())
+			return
+		}
+		filter.Query = parsed
+	}
+
 	tasks, err := s.db.ListTasks(filter)
 	if err != nil {
 		s.writeError(w, http.StatusInternalServerError, err.Error())
@@ -41,17 +53,20 @@ func (s *Server) handleGetTask(w http.ResponseWriter, r *http.Request) {
 
 	resolved, err := s.db.ResolveTaskUUID(uuid)
 	if err != nil {
-		s.writeError(w, http.StatusNotFound, err.Error())
+		s.writeError(w, statusFor(err), err.Error())
 		return
 	}
 
 	task, err := s.db.GetTask(resolved)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			s.writeError(w, http.StatusNotFound, err.Error())
-			return
-		}
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+		s.writeError(w, statusFor(err), err.Error())
+		return
+	}
+
+	// A rule we can't decode hides the schedule, not the task
+	task.Recurrence, err = s.db.GetTaskRecurrence(resolved)
+	if err != nil && !errors.Is(err, db.ErrInvalidRecurrence) {
+		s.writeError(w, statusFor(err), err.Error())
 		return
 	}
 
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/models"
+	"thingies/internal/things"
+)
+
+func TestListTasksQuery(t *testing.T) {
+	b := dbtest.New(t)
+	work := b.Area("Work")
+	b.Task("write spec").InArea(work).Tags("urgent")
+	b.Task("book venue").InArea(work).Tags("urgent", "waiting")
+	b.Task("fix sink").Tags("urgent")
+	s := New(Config{}, b.Open(), things.NewRecordingBackend())
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks?area=Work&q="+url.QueryEscape("tag:urgent -tag:waiting"), nil)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	var tasks []models.TaskJSON
+	if err := json.Unmarshal(w.Body.Bytes(), &tasks); err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].Title != "write spec" {
+		t.Errorf("expected only 'write spec', got %+v", tasks)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/tasks?q="+url.QueryEscape("due<someday"), nil)
+	w = httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad query, got %d; body: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestListTasksTagParams(t *testing.T) {
+	b := dbtest.New(t)
+	b.Task("revise essay").Tags("homework")
+	b.Task("fix sink").Tags("home", "urgent")
+	b.Task("water plants").Tags("home")
+	b.Task("call bank").Tags("urgent", "waiting")
+	s := New(Config{}, b.Open(), things.NewRecordingBackend())
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"tag=home", []string{"fix sink", "water plants"}},
+		{"tag=home&tag=urgent", []string{"fix sink"}},
+		{"any-tag=homework&any-tag=waiting", []string{"revise essay", "call bank"}},
+		{"tag=urgent&not-tag=waiting", []string{"fix sink"}},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/tasks?"+tt.query, nil)
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, req)
+
+		var tasks []models.TaskJSON
+		if err := json.Unmarshal(w.Body.Bytes(), &tasks); err != nil {
+			t.Fatalf("%s: %v; body: %s", tt.query, err, w.Body.String())
+		}
+		var got []string
+		for _, task := range tasks {
+			got = append(got, task.Title)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestGetTaskIncludesRecurrence(t *testing.T) {
+	b := dbtest.New(t)
+	rule := []byte(`<plist version="1.0"><dict>
+		<key>fu</key><integer>16</integer><key>fa</key><integer>2</integer><key>tp</key><integer>1</integer>
+	</dict></plist>`)
+	template := b.Task("Water plants").RepeatingTemplate(rule, dbtest.DaysFromToday(2))
+	s := New(Config{}, b.Open(), things.NewRecordingBackend())
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks/"+template.UUID, nil)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	var task models.TaskJSON
+	if err := json.Unmarshal(w.Body.Bytes(), &task); err != nil {
+		t.Fatal(err)
+	}
+	if task.Recurrence == nil || task.Recurrence.Description != "every 2 days after completion" || !task.Recurrence.AfterCompletion {
+		t.Errorf("unexpected recurrence: %+v", task.Recurrence)
+	}
+}
@@ -61,9 +61,21 @@ func (s *Server) handleAnytime(w http.ResponseWriter, r *http.Request) {
 	writeJSONTasks(w, tasks)
 }
 
-// handleUpcoming returns upcoming scheduled tasks
+// handleUpcoming returns upcoming scheduled tasks, plus projected occurrences
+// of repeating tasks with ?horizon=
 func (s *Server) handleUpcoming(w http.ResponseWriter, r *http.Request) {
-	tasks, err := s.db.GetUpcomingTasks()
+	var tasks []models.Task
+	var err error
+	if horizon := r.URL.Query().Get("horizon"); horizon != "" {
+		until, perr := db.ParseHorizon(horizon)
+		if perr != nil {
+			writeJSONError(w, perr.Error(), http.StatusBadRequest)
+			return
+		}
+		tasks, err = s.db.GetUpcomingWithProjections(until)
+	} else {
+		tasks, err = s.db.GetUpcomingTasks()
+	}
 	if err != nil {
 		writeJSONError(w, err.Error(), http.StatusInternalServerError)
 		return
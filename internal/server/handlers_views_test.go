@@ -0,0 +1,52 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/models"
+	"thingies/internal/things"
+)
+
+func TestUpcomingHorizon(t *testing.T) {
+	b := dbtest.New(t)
+	weekly := []byte(`<plist version="1.0"><dict>
+		<key>fu</key><integer>256</integer><key>fa</key><integer>1</integer>
+	</dict></plist>`)
+	b.Task("Weekly review").RepeatingTemplate(weekly, dbtest.DaysFromToday(1))
+	s := New(Config{}, b.Open(), things.NewRecordingBackend())
+
+	get := func(path string) (int, []models.TaskJSON) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, req)
+		var tasks []models.TaskJSON
+		json.Unmarshal(w.Body.Bytes(), &tasks)
+		return w.Code, tasks
+	}
+
+	if code, tasks := get("/upcoming"); code != http.StatusOK || len(tasks) != 1 {
+		t.Fatalf("expected only the template without a horizon, got %d %+v", code, tasks)
+	}
+
+	code, tasks := get("/upcoming?horizon=30d")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	projected := 0
+	for _, task := range tasks {
+		if task.Projected {
+			projected++
+		}
+	}
+	if projected < 3 || projected > 5 || tasks[0].Projected {
+		t.Errorf("expected the template then 3-5 weekly projections, got %+v", tasks)
+	}
+
+	if code, _ := get("/upcoming?horizon=soon"); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad horizon, got %d", code)
+	}
+}
@@ -3,12 +3,10 @@ package server
 import (
 	"encoding/json"
 	"net/http"
-
-	"thingies/internal/things"
 )
 
-// headingUpdateRequest represents the body for PATCH /headings/:uuid
-type headingUpdateRequest struct {
+// HeadingUpdateRequest represents the body for PATCH /headings/:uuid
+type HeadingUpdateRequest struct {
 	Title string `json:"title"`
 }
 
@@ -22,13 +20,13 @@ func (s *Server) handleDeleteHeading(w http.ResponseWriter, r *http.Request) {
 
 	resolved, err := s.db.ResolveHeadingUUID(uuid)
 	if err != nil {
-		s.writeError(w, http.StatusNotFound, err.Error())
+		s.writeError(w, statusFor(err), err.Error())
 		return
 	}
 	uuid = resolved
 
-	if err := things.DeleteHeading(uuid); err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+	if err := s.things.DeleteHeading(uuid); err != nil {
+		s.writeError(w, statusFor(err), err.Error())
 		return
 	}
 
@@ -49,12 +47,12 @@ func (s *Server) handleUpdateHeading(w http.ResponseWriter, r *http.Request) {
 
 	resolved, err := s.db.ResolveHeadingUUID(uuid)
 	if err != nil {
-		s.writeError(w, http.StatusNotFound, err.Error())
+		s.writeError(w, statusFor(err), err.Error())
 		return
 	}
 	uuid = resolved
 
-	var req headingUpdateRequest
+	var req HeadingUpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		s.writeError(w, http.StatusBadRequest, "invalid request body")
 		return
@@ -65,8 +63,8 @@ func (s *Server) handleUpdateHeading(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := things.RenameHeading(uuid, req.Title); err != nil {
-		s.writeError(w, http.StatusInternalServerError, err.Error())
+	if err := s.things.RenameHeading(uuid, req.Title); err != nil {
+		s.writeError(w, statusFor(err), err.Error())
 		return
 	}
 
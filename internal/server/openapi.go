@@ -0,0 +1,555 @@
+package server
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"thingies/internal/models"
+	"thingies/internal/watch"
+	"thingies/internal/webhook"
+)
+
+// docsPage is the page served at GET /docs. It reads GET /openapi.json and
+// renders it, with no assets from elsewhere.
+//
+//go:embed docs.html
+var docsPage []byte
+
+// routeDoc describes one route for GET /openapi.json. Request and response
+// bodies are given as Go values and their types are turned into schemas, so
+// the document follows the structs the handlers actually decode and encode.
+type routeDoc struct {
+	summary     string
+	description string
+	params      []paramDoc    // query and header parameters; path parameters come from the pattern
+	body        interface{}   // request body, e.g. TaskCreateRequest{}
+	responses   []interface{} // 200 bodies; several mean one of them, depending on the configuration
+	contentType string        // of the 200 body, application/json if empty
+}
+
+// paramDoc describes a query or header parameter
+type paramDoc struct {
+	name        string
+	in          string // query or header
+	kind        string // string, boolean, or integer
+	repeated    bool
+	description string
+}
+
+func queryParam(name, description string) paramDoc {
+	return paramDoc{name: name, in: "query", kind: "string", description: description}
+}
+
+func boolParam(name, description string) paramDoc {
+	return paramDoc{name: name, in: "query", kind: "boolean", description: description}
+}
+
+func intParam(name, description string) paramDoc {
+	return paramDoc{name: name, in: "query", kind: "integer", description: description}
+}
+
+func repeatedParam(name, description string) paramDoc {
+	return paramDoc{name: name, in: "query", kind: "string", repeated: true, description: description}
+}
+
+// schema is a JSON Schema or another object of the OpenAPI document. A
+// schema in routeDoc.responses is used as it is.
+type schema map[string]interface{}
+
+var (
+	healthSchema = schema{"type": "object", "properties": schema{
+		"status": schema{"type": "string"},
+		"time":   schema{"type": "string", "format": "date-time"},
+	}}
+	snapshotSchema = schema{"type": "object", "properties": schema{
+		"snapshot": schema{"type": "string", "description": "hierarchical text of Today, Anytime, Upcoming, Someday, and Inbox"},
+	}}
+	headingStatusSchema = schema{"type": "object", "properties": schema{
+		"status": schema{"type": "string", "enum": []string{"updated", "deleted"}},
+		"uuid":   schema{"type": "string"},
+		"title":  schema{"type": "string"},
+	}}
+)
+
+// Shared parameters
+var (
+	includeCompletedParam = boolParam("include-completed", "Include completed and canceled items")
+	includeFutureParam    = boolParam("include-future", "Include tasks scheduled after today")
+)
+
+// routeDocs documents every route registerRoutes registers, by pattern.
+// TestOpenAPICoversRoutes fails for a route without an entry.
+var routeDocs = map[string]routeDoc{
+	"GET /health": {summary: "Health check", responses: []interface{}{healthSchema}},
+	"GET /openapi.json": {summary: "This OpenAPI document",
+		responses: []interface{}{schema{"type": "object"}}},
+	"GET /docs": {summary: "Browsable API documentation",
+		responses: []interface{}{schema{"type": "string"}}, contentType: "text/html"},
+
+	// Task read routes
+	"GET /tasks": {summary: "List tasks",
+		params: []paramDoc{
+			queryParam("status", "incomplete (the default), completed, canceled, or all"),
+			queryParam("area", "Area title, substring match"),
+			queryParam("project", "Project title, substring match"),
+			repeatedParam("tag", "Tag every task must have"),
+			repeatedParam("any-tag", "Tags of which a task needs at least one"),
+			repeatedParam("not-tag", "Tags a task must not have"),
+			boolParam("subtags", "Tag parameters also match nested tags"),
+			boolParam("today", "Only tasks in Today"),
+			includeFutureParam,
+			queryParam("q", "Query language expression, ANDed with the other filters"),
+		},
+		responses: []interface{}{[]models.TaskJSON{}}},
+	"GET /tasks/search": {summary: "Search task titles",
+		params: []paramDoc{
+			queryParam("q", "Text to find (required)"),
+			boolParam("in-notes", "Also search notes"),
+			includeFutureParam,
+		},
+		responses: []interface{}{[]models.TaskJSON{}}},
+	"GET /tasks/{uuid}": {summary: "Get a task",
+		responses: []interface{}{models.TaskJSON{}}},
+	"GET /tasks/{uuid}/checklist": {summary: "Get a task's checklist, in order",
+		responses: []interface{}{[]models.ChecklistItem{}}},
+
+	// Task write routes
+	"POST /tasks": {summary: "Create a task",
+		description: "Returns the task once it appears in the database, or an APIResponse if the server runs with --create-timeout 0.",
+		body:        TaskCreateRequest{}, responses: []interface{}{models.TaskJSON{}, APIResponse{}}},
+	"PATCH /tasks/{uuid}": {summary: "Update a task",
+		body: TaskUpdateRequest{}, responses: []interface{}{APIResponse{}}},
+	"POST /tasks/{uuid}/complete": {summary: "Complete a task", responses: []interface{}{APIResponse{}}},
+	"POST /tasks/{uuid}/cancel":   {summary: "Cancel a task", responses: []interface{}{APIResponse{}}},
+	"DELETE /tasks/{uuid}":        {summary: "Move a task to the trash", responses: []interface{}{APIResponse{}}},
+	"POST /tasks/{uuid}/move-to-today": {summary: "Move a task to Today",
+		responses: []interface{}{APIResponse{}}},
+	"POST /tasks/{uuid}/move-to-someday": {summary: "Move a task to Someday",
+		responses: []interface{}{APIResponse{}}},
+	"POST /tasks/{uuid}/move": {summary: "Move a task to a project, heading, area, or the Inbox",
+		description: "Exactly one destination: inbox, area, or project and/or heading.",
+		body:        TaskMoveRequest{}, responses: []interface{}{APIResponse{}}},
+	"POST /tasks/{uuid}/checklist": {summary: "Add checklist items",
+		body: ChecklistAddRequest{}, responses: []interface{}{APIResponse{}}},
+	"POST /tasks/{uuid}/checklist/reorder": {summary: "Move checklist items to the top",
+		body: ChecklistReorderRequest{}, responses: []interface{}{APIResponse{}}},
+	"POST /tasks/{uuid}/checklist/{item}/check": {summary: "Check a checklist item",
+		responses: []interface{}{APIResponse{}}},
+	"POST /tasks/{uuid}/checklist/{item}/uncheck": {summary: "Uncheck a checklist item",
+		responses: []interface{}{APIResponse{}}},
+	"DELETE /tasks/{uuid}/checklist/{item}": {summary: "Remove a checklist item",
+		responses: []interface{}{APIResponse{}}},
+
+	// View routes
+	"GET /today": {summary: "Today, then This Evening",
+		responses: []interface{}{[]models.TaskJSON{}}},
+	"GET /inbox":   {summary: "Inbox", responses: []interface{}{[]models.TaskJSON{}}},
+	"GET /anytime": {summary: "Anytime", responses: []interface{}{[]models.TaskJSON{}}},
+	"GET /upcoming": {summary: "Upcoming",
+		params: []paramDoc{
+			queryParam("horizon", "Also project repeating tasks up to 30d, 2w, 3m, 1y, or a YYYY-MM-DD date"),
+		},
+		responses: []interface{}{[]models.TaskJSON{}}},
+	"GET /someday": {summary: "Someday", responses: []interface{}{[]models.TaskJSON{}}},
+	"GET /logbook": {summary: "Completed tasks, most recent first",
+		params:    []paramDoc{intParam("limit", "Most tasks to return (default 50)")},
+		responses: []interface{}{[]models.TaskJSON{}}},
+	"GET /deadlines": {summary: "Tasks with a deadline in the coming days",
+		params:    []paramDoc{intParam("days", "How many days ahead (default 7)")},
+		responses: []interface{}{[]models.TaskJSON{}}},
+
+	// Project routes
+	"GET /projects": {summary: "List projects",
+		params: []paramDoc{includeCompletedParam}, responses: []interface{}{[]models.ProjectJSON{}}},
+	"GET /projects/{uuid}": {summary: "Get a project",
+		responses: []interface{}{models.ProjectJSON{}}},
+	"GET /projects/{uuid}/tasks": {summary: "Tasks in a project",
+		params: []paramDoc{includeCompletedParam}, responses: []interface{}{[]models.TaskJSON{}}},
+	"GET /projects/{uuid}/headings": {summary: "Headings in a project",
+		responses: []interface{}{[]models.Heading{}}},
+	"POST /projects": {summary: "Create a project",
+		description: "Returns the project once it appears in the database, or an APIResponse if the server runs with --create-timeout 0.",
+		body:        ProjectCreateRequest{}, responses: []interface{}{models.ProjectJSON{}, APIResponse{}}},
+	"PATCH /projects/{uuid}": {summary: "Update a project",
+		body: ProjectUpdateRequest{}, responses: []interface{}{APIResponse{}}},
+	"POST /projects/{uuid}/complete": {summary: "Complete a project", responses: []interface{}{APIResponse{}}},
+	"POST /projects/{uuid}/cancel":   {summary: "Cancel a project", responses: []interface{}{APIResponse{}}},
+	"DELETE /projects/{uuid}":        {summary: "Move a project to the trash", responses: []interface{}{APIResponse{}}},
+	"POST /projects/{uuid}/move": {summary: "Move a project to an area",
+		body: ProjectMoveRequest{}, responses: []interface{}{APIResponse{}}},
+
+	// Area routes
+	"GET /areas":        {summary: "List areas", responses: []interface{}{[]models.Area{}}},
+	"GET /areas/{uuid}": {summary: "Get an area", responses: []interface{}{models.Area{}}},
+	"GET /areas/{uuid}/tasks": {summary: "Tasks directly in an area, outside its projects",
+		params: []paramDoc{includeCompletedParam}, responses: []interface{}{[]models.Task{}}},
+	"GET /areas/{uuid}/projects": {summary: "Projects in an area",
+		params: []paramDoc{includeCompletedParam}, responses: []interface{}{[]models.Project{}}},
+	"POST /areas": {summary: "Create an area",
+		description: "Returns the area once it appears in the database. If it has not appeared within the create timeout, the status is 202 and only uuid and title are filled in.",
+		body:        AreaRequest{}, responses: []interface{}{models.Area{}}},
+	"PATCH /areas/{uuid}": {summary: "Rename an area",
+		body: AreaRequest{}, responses: []interface{}{models.Area{}}},
+	"DELETE /areas/{uuid}": {summary: "Delete an area with its tasks and projects",
+		responses: []interface{}{APIResponse{}}},
+
+	// Tag routes
+	"GET /tags": {summary: "List tags",
+		params:    []paramDoc{boolParam("tree", "Nest tags under their parents in children")},
+		responses: []interface{}{[]models.TagJSON{}}},
+	"GET /tags/{name}/tasks": {summary: "Tasks with a tag",
+		responses: []interface{}{[]models.Task{}}},
+	"POST /tags": {summary: "Create a tag",
+		description: "Returns the tag once it appears in the database. If it has not appeared within the create timeout, the status is 202 with the uuid and the requested fields.",
+		body:        TagCreateRequest{}, responses: []interface{}{models.TagJSON{}}},
+	"PATCH /tags/{uuid}": {summary: "Update a tag",
+		description: `An empty parent makes the tag top-level; an empty shortcut removes it.`,
+		body:        TagUpdateRequest{}, responses: []interface{}{models.TagJSON{}}},
+	"DELETE /tags/{uuid}": {summary: "Delete a tag; its children move to the top level",
+		responses: []interface{}{APIResponse{}}},
+
+	// Heading routes
+	"DELETE /headings/{uuid}": {summary: "Delete a heading", responses: []interface{}{headingStatusSchema}},
+	"PATCH /headings/{uuid}": {summary: "Rename a heading",
+		body: HeadingUpdateRequest{}, responses: []interface{}{headingStatusSchema}},
+
+	// Batch routes
+	"POST /batch/create": {summary: "Create to-dos and projects in the Things JSON format",
+		description: "At most 250 items, nested ones included. Things accepts 250 items per 10 seconds, so a request may wait up to 10 seconds after earlier ones.",
+		body:        BatchCreateRequest{}, responses: []interface{}{APIResponse{}}},
+
+	// Trash routes
+	"GET /trash": {summary: "Trashed items, most recently trashed first",
+		responses: []interface{}{[]models.TrashItemJSON{}}},
+	"POST /trash/{uuid}/restore": {summary: "Restore a trashed task or project (501 for a heading)",
+		responses: []interface{}{APIResponse{}}},
+
+	"GET /snapshot": {summary: "Everything as hierarchical text", responses: []interface{}{snapshotSchema}},
+
+	// Change feed routes
+	"GET /changes": {summary: "Items created, updated, or trashed since a time or cursor",
+		params: []paramDoc{
+			queryParam("since", "An RFC3339 time or the cursor of an earlier response; omit for a full sync"),
+		},
+		responses: []interface{}{models.ChangesJSON{}}},
+	"GET /events": {summary: "Server-Sent Events stream of changes",
+		description: "Each event's data is an Event. Idle streams get a heartbeat comment; reconnecting with Last-Event-ID replays missed events.",
+		params: []paramDoc{
+			queryParam("types", "Comma-separated event types or kinds, e.g. task.completed,project"),
+			{name: "Last-Event-ID", in: "header", kind: "string", description: "Resume after this event"},
+			queryParam("last-event-id", "Resume after this event, for clients that cannot set headers"),
+		},
+		responses: []interface{}{watch.Event{}}, contentType: "text/event-stream"},
+
+	// Webhook routes
+	"GET /webhooks": {summary: "Configured webhooks, without their secrets",
+		responses: []interface{}{[]WebhookJSON{}}},
+	"GET /webhooks/deliveries": {summary: "Recent webhook delivery attempts, newest first",
+		params: []paramDoc{
+			queryParam("hook", "Only this webhook's deliveries"),
+			intParam("limit", "Most entries to return (default 100)"),
+		},
+		responses: []interface{}{[]webhook.Delivery{}}},
+}
+
+// tagOf groups routes in the document by their first path segment, apart
+// from these
+var tagOf = map[string]string{
+	"health": "server", "openapi.json": "server", "docs": "server",
+	"today": "views", "inbox": "views", "anytime": "views", "upcoming": "views",
+	"someday": "views", "logbook": "views", "deadlines": "views", "snapshot": "views",
+	"events": "changes",
+}
+
+// pathParams describes the wildcards of route patterns
+var pathParams = map[string]string{
+	"uuid": "UUID or unique prefix",
+	"item": "1-based position or URL-encoded title of a checklist item",
+	"name": "Tag name, URL-encoded",
+}
+
+var wildcardPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// patternRecorder collects the patterns registerRoutes registers
+type patternRecorder []string
+
+func (p *patternRecorder) HandleFunc(pattern string, _ func(http.ResponseWriter, *http.Request)) {
+	*p = append(*p, pattern)
+}
+
+// buildOpenAPI returns the OpenAPI 3.1 document for the routes registerRoutes
+// registers, in order, failing for a route routeDocs does not describe
+func (s *Server) buildOpenAPI() (schema, error) {
+	var patterns patternRecorder
+	s.registerRoutes(&patterns)
+
+	b := &schemaBuilder{components: schema{}, names: make(map[reflect.Type]string)}
+	paths := schema{}
+	var tags []schema
+	seenTags := make(map[string]bool)
+	for _, pattern := range patterns {
+		doc, ok := routeDocs[pattern]
+		if !ok {
+			return nil, fmt.Errorf("route %s is not documented in routeDocs", pattern)
+		}
+		method, path, _ := strings.Cut(pattern, " ")
+
+		segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+		tag := segment
+		if t, ok := tagOf[segment]; ok {
+			tag = t
+		}
+		if !seenTags[tag] {
+			seenTags[tag] = true
+			tags = append(tags, schema{"name": tag})
+		}
+
+		op := schema{
+			"summary":     doc.summary,
+			"operationId": operationID(method, path),
+			"tags":        []string{tag},
+			"responses": schema{
+				"200":     b.response(doc),
+				"default": schema{"$ref": "#/components/responses/Error"},
+			},
+		}
+		if doc.description != "" {
+			op["description"] = doc.description
+		}
+		if params := parameters(path, doc.params); len(params) > 0 {
+			op["parameters"] = params
+		}
+		if doc.body != nil {
+			op["requestBody"] = schema{
+				"required": true,
+				"content":  schema{"application/json": schema{"schema": b.schemaFor(reflect.TypeOf(doc.body))}},
+			}
+		}
+		if publicPaths[path] {
+			op["security"] = []schema{}
+		}
+
+		item, _ := paths[path].(schema)
+		if item == nil {
+			item = schema{}
+			paths[path] = item
+		}
+		item[strings.ToLower(method)] = op
+	}
+
+	errorSchema := schema{"oneOf": []schema{
+		b.schemaFor(reflect.TypeOf(APIResponse{})),
+		{"type": "object", "required": []string{"error"}, "properties": schema{"error": schema{"type": "string"}}},
+	}}
+	doc := schema{
+		"openapi": "3.1.0",
+		"info": schema{
+			"title":       "thingies",
+			"version":     "1",
+			"description": "REST API for Things 3. Reads come from the Things database; writes go through Things itself.",
+		},
+		"servers": []schema{{"url": "/"}},
+		"tags":    tags,
+		"paths":   paths,
+		"components": schema{
+			"schemas": b.components,
+			"responses": schema{"Error": schema{
+				"description": "Error. Most routes answer with an APIResponse whose success is false, the task and view reads with {\"error\": ...}.",
+				"content":     schema{"application/json": schema{"schema": errorSchema}},
+			}},
+			"securitySchemes": schema{"bearer": schema{"type": "http", "scheme": "bearer"}},
+		},
+	}
+	if s.config.Auth != nil {
+		doc["security"] = []schema{{"bearer": []string{}}}
+	}
+	return doc, nil
+}
+
+// parameters returns the path parameters of path followed by params
+func parameters(path string, params []paramDoc) []schema {
+	var result []schema
+	for _, match := range wildcardPattern.FindAllStringSubmatch(path, -1) {
+		result = append(result, schema{
+			"name": match[1], "in": "path", "required": true,
+			"description": pathParams[match[1]],
+			"schema":      schema{"type": "string"},
+		})
+	}
+	for _, p := range params {
+		s := schema{"type": p.kind}
+		if p.repeated {
+			s = schema{"type": "array", "items": s}
+		}
+		result = append(result, schema{"name": p.name, "in": p.in, "description": p.description, "schema": s})
+	}
+	return result
+}
+
+// operationID derives an operation ID from a route, e.g. getTasksByUuidChecklist
+// for GET /tasks/{uuid}/checklist
+func operationID(method, path string) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(path, "/") {
+		if wildcard, ok := strings.CutPrefix(segment, "{"); ok {
+			sb.WriteString("By")
+			segment = strings.TrimSuffix(wildcard, "}")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '.' }) {
+			sb.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return sb.String()
+}
+
+// schemaBuilder turns Go types into JSON Schemas the way encoding/json
+// encodes them, collecting named structs as components
+type schemaBuilder struct {
+	components schema
+	names      map[reflect.Type]string
+}
+
+// response returns the 200 response of a route
+func (b *schemaBuilder) response(doc routeDoc) schema {
+	var bodies []schema
+	for _, body := range doc.responses {
+		if s, ok := body.(schema); ok {
+			bodies = append(bodies, s)
+		} else {
+			bodies = append(bodies, b.schemaFor(reflect.TypeOf(body)))
+		}
+	}
+	body := schema{"oneOf": bodies}
+	if len(bodies) == 1 {
+		body = bodies[0]
+	}
+	contentType := doc.contentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	return schema{"description": "OK", "content": schema{contentType: schema{"schema": body}}}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor returns the schema of t, a reference for named structs
+func (b *schemaBuilder) schemaFor(t reflect.Type) schema {
+	if t == timeType {
+		return schema{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return b.schemaFor(t.Elem())
+	case reflect.String:
+		return schema{"type": "string"}
+	case reflect.Bool:
+		return schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return schema{"type": "array", "items": b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return schema{"type": "object", "additionalProperties": b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		name, ok := b.names[t]
+		if !ok {
+			name = t.Name()
+			if _, taken := b.components[name]; taken {
+				name = strings.ReplaceAll(t.PkgPath(), "/", ".") + "." + name
+			}
+			// Register the name first, so recursive types refer to themselves
+			b.names[t] = name
+			b.components[name] = schema{}
+			b.components[name] = b.object(t)
+		}
+		return schema{"$ref": "#/components/schemas/" + name}
+	default:
+		return schema{} // interface{}: any value
+	}
+}
+
+// object returns the schema of a struct's JSON object. Fields without
+// omitempty or omitzero are always present and so required.
+func (b *schemaBuilder) object(t reflect.Type) schema {
+	properties := schema{}
+	var required []string
+	b.fields(t, properties, &required)
+	s := schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// fields adds the JSON fields of struct t, including those of embedded
+// structs, to properties
+func (b *schemaBuilder) fields(t reflect.Type, properties schema, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.fields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = b.schemaFor(field.Type)
+		if !strings.Contains(options, "omitempty") && !strings.Contains(options, "omitzero") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// handleOpenAPI handles GET /openapi.json
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	doc, err := s.openAPI()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(doc)
+}
+
+// handleDocs handles GET /docs
+func (s *Server) handleDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docsPage)
+}
+
+// marshalOpenAPI encodes the document for GET /openapi.json; New keeps the
+// first result
+func (s *Server) marshalOpenAPI() ([]byte, error) {
+	doc, err := s.buildOpenAPI()
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/things"
+)
+
+// TestOpenAPICoversRoutes checks that every registered route has an entry in
+// routeDocs, and every entry a route, so the document cannot fall behind
+func TestOpenAPICoversRoutes(t *testing.T) {
+	s := New(Config{}, dbtest.New(t).Open(), things.NewRecordingBackend())
+
+	var patterns patternRecorder
+	s.registerRoutes(&patterns)
+	for _, pattern := range patterns {
+		if doc, ok := routeDocs[pattern]; !ok {
+			t.Errorf("route %s has no entry in routeDocs", pattern)
+		} else if doc.summary == "" || len(doc.responses) == 0 {
+			t.Errorf("route %s needs a summary and a response", pattern)
+		}
+	}
+	for pattern := range routeDocs {
+		if !slices.Contains(patterns, pattern) {
+			t.Errorf("routeDocs documents %s, which is not registered", pattern)
+		}
+	}
+
+	if _, err := s.buildOpenAPI(); err != nil {
+		t.Fatalf("buildOpenAPI: %v", err)
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	s, _ := newAuthServer(t)
+
+	// The description is public, like /health
+	w := serve(s, http.MethodGet, "/openapi.json", "", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 without a key, got %d: %s", w.Code, w.Body.String())
+	}
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			Parameters []struct {
+				Name string `json:"name"`
+				In   string `json:"in"`
+			} `json:"parameters"`
+			Security []interface{} `json:"security"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]interface{} `json:"properties"`
+				Required   []string               `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+		Security []interface{} `json:"security"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("bad document: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.1") || len(doc.Security) != 1 {
+		t.Errorf("expected an OpenAPI 3.1 document requiring a key, got %q %v", doc.OpenAPI, doc.Security)
+	}
+
+	for _, name := range []string{"TaskCreateRequest", "TaskUpdateRequest", "ProjectCreateRequest", "TaskJSON", "ProjectJSON"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("expected a %s schema", name)
+		}
+	}
+	create := doc.Components.Schemas["TaskCreateRequest"]
+	if !slices.Equal(create.Required, []string{"title"}) || len(create.Properties) != 7 {
+		t.Errorf("expected TaskCreateRequest with 7 fields, title required, got %+v", create)
+	}
+
+	check := doc.Paths["/tasks/{uuid}/checklist/{item}"]["delete"]
+	if len(check.Parameters) != 2 || check.Parameters[0].Name != "uuid" || check.Parameters[1].In != "path" {
+		t.Errorf("expected the path parameters from the pattern, got %+v", check.Parameters)
+	}
+	if health := doc.Paths["/health"]["get"]; health.Security == nil || len(health.Security) != 0 {
+		t.Errorf("expected /health marked public, got %v", health.Security)
+	}
+
+	w = serve(s, http.MethodGet, "/docs", "", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "openapi.json") {
+		t.Errorf("expected the docs page, got %d", w.Code)
+	}
+}
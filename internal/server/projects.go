@@ -0,0 +1,171 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"thingies/internal/things"
+)
+
+// ProjectUpdateRequest is the request body for updating a project
+type ProjectUpdateRequest struct {
+	Title    string `json:"title,omitempty"`
+	Notes    string `json:"notes,omitempty"`
+	Deadline string `json:"deadline,omitempty"`
+	Tags     string `json:"tags,omitempty"`
+}
+
+// ProjectMoveRequest is the request body for moving a project to an area
+type ProjectMoveRequest struct {
+	Area string `json:"area"` // name, UUID, or prefix
+}
+
+// decodeProjectBody decodes a project request body into v, writing a 400 for
+// malformed JSON or unknown fields. Every project route uses it, so complete,
+// cancel, and delete, which take no fields, reject them too. An empty body
+// decodes as {}.
+func decodeProjectBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// resolveProject resolves the {uuid} path value of a project route, writing
+// the error response and returning "" if it does not name a project
+func (s *Server) resolveProject(w http.ResponseWriter, r *http.Request) string {
+	uuid := r.PathValue("uuid")
+	if uuid == "" {
+		writeError(w, http.StatusBadRequest, "project UUID is required")
+		return ""
+	}
+	resolved, err := s.db.ResolveProjectUUID(uuid)
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return ""
+	}
+	return resolved
+}
+
+// handleUpdateProject handles PATCH /projects/{uuid}
+func (s *Server) handleUpdateProject(w http.ResponseWriter, r *http.Request) {
+	uuid := s.resolveProject(w, r)
+	if uuid == "" {
+		return
+	}
+
+	var req ProjectUpdateRequest
+	if !decodeProjectBody(w, r, &req) {
+		return
+	}
+	if req == (ProjectUpdateRequest{}) {
+		writeError(w, http.StatusBadRequest, "no fields to update")
+		return
+	}
+
+	params := things.ProjectUpdateParams{
+		UUID:     uuid,
+		Name:     req.Title,
+		Notes:    req.Notes,
+		DueDate:  req.Deadline,
+		TagNames: req.Tags,
+	}
+	if err := s.things.UpdateProject(params); err != nil {
+		writeError(w, statusFor(err), "failed to update project: "+err.Error())
+		return
+	}
+
+	writeSuccess(w, "project updated")
+}
+
+// handleCompleteProject handles POST /projects/{uuid}/complete
+func (s *Server) handleCompleteProject(w http.ResponseWriter, r *http.Request) {
+	uuid := s.resolveProject(w, r)
+	if uuid == "" {
+		return
+	}
+
+	if !decodeProjectBody(w, r, &struct{}{}) {
+		return
+	}
+
+	if err := s.things.CompleteProject(uuid); err != nil {
+		writeError(w, statusFor(err), "failed to complete project: "+err.Error())
+		return
+	}
+
+	writeSuccess(w, "project completed")
+}
+
+// handleCancelProject handles POST /projects/{uuid}/cancel
+func (s *Server) handleCancelProject(w http.ResponseWriter, r *http.Request) {
+	uuid := s.resolveProject(w, r)
+	if uuid == "" {
+		return
+	}
+
+	if !decodeProjectBody(w, r, &struct{}{}) {
+		return
+	}
+
+	if err := s.things.CancelProject(uuid); err != nil {
+		writeError(w, statusFor(err), "failed to cancel project: "+err.Error())
+		return
+	}
+
+	writeSuccess(w, "project canceled")
+}
+
+// handleDeleteProject handles DELETE /projects/{uuid}
+func (s *Server) handleDeleteProject(w http.ResponseWriter, r *http.Request) {
+	uuid := s.resolveProject(w, r)
+	if uuid == "" {
+		return
+	}
+
+	if !decodeProjectBody(w, r, &struct{}{}) {
+		return
+	}
+
+	if err := s.things.DeleteProject(uuid); err != nil {
+		writeError(w, statusFor(err), "failed to delete project: "+err.Error())
+		return
+	}
+
+	writeSuccess(w, "project deleted")
+}
+
+// handleMoveProject handles POST /projects/{uuid}/move
+func (s *Server) handleMoveProject(w http.ResponseWriter, r *http.Request) {
+	uuid := s.resolveProject(w, r)
+	if uuid == "" {
+		return
+	}
+
+	var req ProjectMoveRequest
+	if !decodeProjectBody(w, r, &req) {
+		return
+	}
+	if req.Area == "" {
+		writeError(w, http.StatusBadRequest, "area is required")
+		return
+	}
+
+	area, err := s.db.ResolveAreaID(req.Area)
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+
+	if err := s.things.MoveProjectToArea(uuid, area); err != nil {
+		writeError(w, statusFor(err), "failed to move project: "+err.Error())
+		return
+	}
+
+	writeSuccess(w, "project moved")
+}
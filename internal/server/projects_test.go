@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/things"
+)
+
+func TestProjectLifecycleRoutes(t *testing.T) {
+	b := dbtest.New(t)
+	b.Area("Home").WithUUID("Home000000000000000000")
+	b.Project("Launch").WithUUID("Launch0000000000000000")
+	rec := things.NewRecordingBackend()
+	s := New(Config{}, b.Open(), rec)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+		script string // expected in the last AppleScript, if the request succeeds
+	}{
+		{"update", http.MethodPatch, "/projects/Launch", `{"title": "Relaunch", "deadline": "2026-03-15"}`, http.StatusOK, `set name of theProject to "Relaunch"`},
+		{"update without fields", http.MethodPatch, "/projects/Launch", `{}`, http.StatusBadRequest, ""},
+		{"update unknown field", http.MethodPatch, "/projects/Launch", `{"when": "today"}`, http.StatusBadRequest, ""},
+		{"complete", http.MethodPost, "/projects/Launch/complete", "", http.StatusOK, `set status of project id "Launch0000000000000000" to completed`},
+		{"cancel", http.MethodPost, "/projects/Launch/cancel", "", http.StatusOK, `set status of project id "Launch0000000000000000" to canceled`},
+		{"cancel empty object", http.MethodPost, "/projects/Launch/cancel", `{}`, http.StatusOK, `set status of project id "Launch0000000000000000" to canceled`},
+		{"cancel unknown field", http.MethodPost, "/projects/Launch/cancel", `{"reason": "dropped"}`, http.StatusBadRequest, ""},
+		{"update empty body", http.MethodPatch, "/projects/Launch", "", http.StatusBadRequest, ""},
+		{"move to area", http.MethodPost, "/projects/Launch/move", `{"area": "Home"}`, http.StatusOK, `set area of theProject to area id "Home000000000000000000"`},
+		{"move without area", http.MethodPost, "/projects/Launch/move", `{}`, http.StatusBadRequest, ""},
+		{"move to unknown area", http.MethodPost, "/projects/Launch/move", `{"area": "Nowhere"}`, http.StatusNotFound, ""},
+		{"delete", http.MethodDelete, "/projects/Launch", "", http.StatusOK, `delete project id "Launch0000000000000000"`},
+		{"delete unknown field", http.MethodDelete, "/projects/Launch", `{"force": true}`, http.StatusBadRequest, ""},
+		{"unknown project", http.MethodPost, "/projects/Nope/cancel", "", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(rec.Scripts())
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d; body: %s", tt.want, w.Code, w.Body.String())
+			}
+			scripts := rec.Scripts()
+			if tt.script == "" {
+				if len(scripts) != before {
+					t.Errorf("rejected request reached the backend: %v", scripts[before:])
+				}
+				return
+			}
+			if len(scripts) != before+1 || !strings.Contains(scripts[before], tt.script) {
+				t.Errorf("expected a script containing %q, got %v", tt.script, scripts[before:])
+			}
+			if !strings.Contains(w.Body.String(), `"success":true`) {
+				t.Errorf("expected a success envelope, got %s", w.Body.String())
+			}
+		})
+	}
+}
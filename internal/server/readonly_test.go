@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/things"
+)
+
+// TestReadOnlyRejectsEveryWriteRoute walks every registered non-GET route and
+// checks that --read-only answers 403 without reaching the backend
+func TestReadOnlyRejectsEveryWriteRoute(t *testing.T) {
+	b := dbtest.New(t)
+	b.Task("task").WithUUID("Task000000000000000000")
+	rec := things.NewRecordingBackend()
+	s := New(Config{ReadOnly: true}, b.Open(), rec)
+
+	var patterns patternRecorder
+	s.registerRoutes(&patterns)
+
+	writes := 0
+	for _, pattern := range patterns {
+		method, path, _ := strings.Cut(pattern, " ")
+		if method == http.MethodGet {
+			continue
+		}
+		writes++
+		for _, wildcard := range []string{"{uuid}", "{name}"} {
+			path = strings.ReplaceAll(path, wildcard, "Task000000000000000000")
+		}
+
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"title": "x"}`))
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %d", pattern, w.Code)
+		}
+	}
+	if writes == 0 {
+		t.Fatal("no write routes registered")
+	}
+	if calls := rec.Calls(); len(calls) != 0 {
+		t.Errorf("read-only server reached the backend: %v", calls)
+	}
+
+	// Reads still work
+	req := httptest.NewRequest(http.MethodGet, "/tasks/Task000000000000000000", nil)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("GET in read-only mode: expected 200, got %d", w.Code)
+	}
+}
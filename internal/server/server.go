@@ -8,54 +8,108 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"thingies/internal/db"
 	"thingies/internal/models"
+	"thingies/internal/things"
+	"thingies/internal/watch"
+	"thingies/internal/webhook"
 )
 
+// writeTimeout bounds how long a response may take to write. Handlers that
+// wait on Things for longer move their own deadline, see extendWriteDeadline.
+const writeTimeout = 15 * time.Second
+
 // Config holds server configuration
 type Config struct {
-	Host string
-	Port int
+	Host     string
+	Port     int
+	Auth     *AuthConfig // nil disables authentication
+	ReadOnly bool        // reject every write route with 403
+
+	// CreateTimeout is how long POST /tasks and POST /projects wait for the
+	// new row to appear in the database so they can return it. 0 skips the
+	// wait and answers {"success": true} as soon as the URL is opened. POST
+	// /areas and POST /tags wait as long for the row AppleScript created,
+	// answering 202 if it is not there.
+	CreateTimeout time.Duration
+
+	// WatchInterval is how often GET /events checks the database files for
+	// writes (default 1s). Heartbeat is how often an idle event stream gets
+	// a comment line so proxies and clients keep it open (default 15s).
+	WatchInterval time.Duration
+	Heartbeat     time.Duration
+
+	// Webhooks are POSTed the events of GET /events that match them; nil
+	// disables them
+	Webhooks *webhook.Config
 }
 
 // Server wraps an HTTP server with Things DB access
 type Server struct {
 	config     Config
 	httpServer *http.Server
+	mux        *http.ServeMux
 	db         *db.ThingsDB
+	things     *things.Client
+
+	// The change feed behind GET /events, started by the first request
+	feedMu   sync.Mutex
+	feed     *watch.Feed
+	stopFeed context.CancelFunc
+	done     chan struct{} // closed on Shutdown to end event streams
+
+	hooks *webhook.Dispatcher // set by startWebhooks
+
+	openAPI func() ([]byte, error) // the document for GET /openapi.json, built once
 }
 
-// New creates a new server instance
-func New(cfg Config, thingsDB *db.ThingsDB) *Server {
+// New creates a new server instance. All writes are issued through backend.
+func New(cfg Config, thingsDB *db.ThingsDB, backend things.Backend) *Server {
 	s := &Server{
 		config: cfg,
 		db:     thingsDB,
+		things: things.NewClient(backend),
+		done:   make(chan struct{}),
 	}
 
-	mux := http.NewServeMux()
-	s.registerRoutes(mux)
+	s.mux = http.NewServeMux()
+	s.registerRoutes(s.mux)
+	s.openAPI = sync.OnceValues(s.marshalOpenAPI)
 
 	s.httpServer = &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
-		Handler:      s.withMiddleware(mux),
+		Handler:      s.withMiddleware(s.mux),
 		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		WriteTimeout: writeTimeout,
 		IdleTimeout:  60 * time.Second,
 	}
+	s.httpServer.RegisterOnShutdown(s.closeEvents)
 
 	return s
 }
 
+// routeMux is the part of *http.ServeMux that registerRoutes uses, so tests
+// can enumerate the registered patterns
+type routeMux interface {
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
 // registerRoutes sets up the HTTP routes
-func (s *Server) registerRoutes(mux *http.ServeMux) {
+func (s *Server) registerRoutes(mux routeMux) {
 	mux.HandleFunc("GET /health", s.handleHealth)
 
+	// API description
+	mux.HandleFunc("GET /openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("GET /docs", s.handleDocs)
+
 	// Task read routes
 	mux.HandleFunc("GET /tasks", s.handleListTasks)
 	mux.HandleFunc("GET /tasks/search", s.handleSearchTasks)
 	mux.HandleFunc("GET /tasks/{uuid}", s.handleGetTask)
+	mux.HandleFunc("GET /tasks/{uuid}/checklist", s.handleGetChecklist)
 
 	// Task write routes
 	mux.HandleFunc("POST /tasks", s.handleCreateTask)
@@ -65,6 +119,12 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("DELETE /tasks/{uuid}", s.handleDeleteTask)
 	mux.HandleFunc("POST /tasks/{uuid}/move-to-today", s.handleMoveTaskToToday)
 	mux.HandleFunc("POST /tasks/{uuid}/move-to-someday", s.handleMoveTaskToSomeday)
+	mux.HandleFunc("POST /tasks/{uuid}/move", s.handleMoveTask)
+	mux.HandleFunc("POST /tasks/{uuid}/checklist", s.handleAddChecklistItems)
+	mux.HandleFunc("POST /tasks/{uuid}/checklist/reorder", s.handleReorderChecklist)
+	mux.HandleFunc("POST /tasks/{uuid}/checklist/{item}/check", s.handleCheckChecklistItem)
+	mux.HandleFunc("POST /tasks/{uuid}/checklist/{item}/uncheck", s.handleUncheckChecklistItem)
+	mux.HandleFunc("DELETE /tasks/{uuid}/checklist/{item}", s.handleRemoveChecklistItem)
 
 	// View routes
 	mux.HandleFunc("GET /today", s.handleToday)
@@ -81,28 +141,56 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /projects/{uuid}/tasks", s.handleGetProjectTasks)
 	mux.HandleFunc("GET /projects/{uuid}/headings", s.handleGetProjectHeadings)
 	mux.HandleFunc("POST /projects", s.handleCreateProject)
+	mux.HandleFunc("PATCH /projects/{uuid}", s.handleUpdateProject)
+	mux.HandleFunc("POST /projects/{uuid}/complete", s.handleCompleteProject)
+	mux.HandleFunc("POST /projects/{uuid}/cancel", s.handleCancelProject)
+	mux.HandleFunc("DELETE /projects/{uuid}", s.handleDeleteProject)
+	mux.HandleFunc("POST /projects/{uuid}/move", s.handleMoveProject)
 
 	// Area routes
 	mux.HandleFunc("GET /areas", s.handleListAreas)
 	mux.HandleFunc("GET /areas/{uuid}", s.handleGetArea)
 	mux.HandleFunc("GET /areas/{uuid}/tasks", s.handleGetAreaTasks)
 	mux.HandleFunc("GET /areas/{uuid}/projects", s.handleGetAreaProjects)
+	mux.HandleFunc("POST /areas", s.handleCreateArea)
+	mux.HandleFunc("PATCH /areas/{uuid}", s.handleUpdateArea)
+	mux.HandleFunc("DELETE /areas/{uuid}", s.handleDeleteArea)
 
 	// Tag routes
 	mux.HandleFunc("GET /tags", s.handleListTags)
 	mux.HandleFunc("GET /tags/{name}/tasks", s.handleGetTagTasks)
+	mux.HandleFunc("POST /tags", s.handleCreateTag)
+	mux.HandleFunc("PATCH /tags/{uuid}", s.handleUpdateTag)
+	mux.HandleFunc("DELETE /tags/{uuid}", s.handleDeleteTag)
 
 	// Heading routes
 	mux.HandleFunc("DELETE /headings/{uuid}", s.handleDeleteHeading)
 	mux.HandleFunc("PATCH /headings/{uuid}", s.handleUpdateHeading)
 
+	// Batch routes
+	mux.HandleFunc("POST /batch/create", s.handleBatchCreate)
+
+	// Trash routes
+	mux.HandleFunc("GET /trash", s.handleTrash)
+	mux.HandleFunc("POST /trash/{uuid}/restore", s.handleRestoreTrashItem)
+
 	// Snapshot route
 	mux.HandleFunc("GET /snapshot", s.handleSnapshot)
+
+	// Change feed routes
+	mux.HandleFunc("GET /changes", s.handleChanges)
+	mux.HandleFunc("GET /events", s.handleEvents)
+
+	// Webhook routes
+	mux.HandleFunc("GET /webhooks", s.handleListWebhooks)
+	mux.HandleFunc("GET /webhooks/deliveries", s.handleWebhookDeliveries)
 }
 
 // withMiddleware wraps the handler with middleware
 func (s *Server) withMiddleware(handler http.Handler) http.Handler {
 	// Apply middleware in reverse order (last applied runs first)
+	handler = s.readOnlyMiddleware(handler)
+	handler = s.authMiddleware(handler)
 	handler = s.corsMiddleware(handler)
 	handler = s.loggingMiddleware(handler)
 	return handler
@@ -122,6 +210,25 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// readOnlyMiddleware rejects requests to write routes with 403 when the
+// server runs with --read-only. Unregistered paths still get the mux's 404/405.
+func (s *Server) readOnlyMiddleware(next http.Handler) http.Handler {
+	if !s.config.ReadOnly {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if _, pattern := s.mux.Handler(r); pattern != "" {
+				writeError(w, http.StatusForbidden, "server is read-only")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // corsMiddleware adds CORS headers for local development
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -149,6 +256,12 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, which
+// the event stream needs for flushing
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // handleHealth handles the health check endpoint
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -163,6 +276,9 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 
 // Start starts the HTTP server
 func (s *Server) Start() error {
+	if err := s.startWebhooks(); err != nil {
+		return err
+	}
 	log.Printf("Starting server on %s", s.httpServer.Addr)
 	return s.httpServer.ListenAndServe()
 }
@@ -178,13 +294,16 @@ func (s *Server) DB() *db.ThingsDB {
 	return s.db
 }
 
+// Handler returns the server's HTTP handler with middleware applied
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
+
 // handleListProjects returns all projects
 func (s *Server) handleListProjects(w http.ResponseWriter, r *http.Request) {
-	includeCompleted := r.URL.Query().Get("include-completed") == "true"
-
-	projects, err := s.db.ListProjects(includeCompleted)
+	projects, err := s.db.ListProjects(includeCompleted(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.writeError(w, statusFor(err), err.Error())
 		return
 	}
 
@@ -198,27 +317,30 @@ func (s *Server) handleListProjects(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(result)
 }
 
+// includeCompleted reads ?include-completed=true. include_completed, which
+// the area routes used to take, is still accepted.
+func includeCompleted(r *http.Request) bool {
+	query := r.URL.Query()
+	return query.Get("include-completed") == "true" || query.Get("include_completed") == "true"
+}
+
 // handleGetProject returns a single project
 func (s *Server) handleGetProject(w http.ResponseWriter, r *http.Request) {
 	uuid := r.PathValue("uuid")
 	if uuid == "" {
-		http.Error(w, "uuid required", http.StatusBadRequest)
+		s.writeError(w, http.StatusBadRequest, "uuid required")
 		return
 	}
 
 	resolved, err := s.db.ResolveProjectUUID(uuid)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		s.writeError(w, statusFor(err), err.Error())
 		return
 	}
 
 	project, err := s.db.GetProject(resolved)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.writeError(w, statusFor(err), err.Error())
 		return
 	}
 
@@ -230,22 +352,20 @@ func (s *Server) handleGetProject(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleGetProjectTasks(w http.ResponseWriter, r *http.Request) {
 	uuid := r.PathValue("uuid")
 	if uuid == "" {
-		http.Error(w, "uuid required", http.StatusBadRequest)
+		s.writeError(w, http.StatusBadRequest, "uuid required")
 		return
 	}
 
 	resolved, err := s.db.ResolveProjectUUID(uuid)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		s.writeError(w, statusFor(err), err.Error())
 		return
 	}
 	uuid = resolved
 
-	includeCompleted := r.URL.Query().Get("include-completed") == "true"
-
-	tasks, err := s.db.GetProjectTasks(uuid, includeCompleted)
+	tasks, err := s.db.GetProjectTasks(uuid, includeCompleted(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.writeError(w, statusFor(err), err.Error())
 		return
 	}
 
@@ -263,20 +383,20 @@ func (s *Server) handleGetProjectTasks(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleGetProjectHeadings(w http.ResponseWriter, r *http.Request) {
 	uuid := r.PathValue("uuid")
 	if uuid == "" {
-		http.Error(w, "uuid required", http.StatusBadRequest)
+		s.writeError(w, http.StatusBadRequest, "uuid required")
 		return
 	}
 
 	resolved, err := s.db.ResolveProjectUUID(uuid)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		s.writeError(w, statusFor(err), err.Error())
 		return
 	}
 	uuid = resolved
 
 	headings, err := s.db.GetProjectHeadings(uuid)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.writeError(w, statusFor(err), err.Error())
 		return
 	}
 
@@ -567,17 +687,13 @@ func (s *Server) handleGetArea(w http.ResponseWriter, r *http.Request) {
 
 	resolved, err := s.db.ResolveAreaUUID(uuid)
 	if err != nil {
-		writeError(w, http.StatusNotFound, err.Error())
+		writeError(w, statusFor(err), err.Error())
 		return
 	}
 
 	area, err := s.db.GetArea(resolved)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			writeError(w, http.StatusNotFound, err.Error())
-			return
-		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, statusFor(err), err.Error())
 		return
 	}
 
@@ -594,7 +710,7 @@ func (s *Server) handleGetAreaTasks(w http.ResponseWriter, r *http.Request) {
 
 	resolved, err := s.db.ResolveAreaUUID(uuid)
 	if err != nil {
-		writeError(w, http.StatusNotFound, err.Error())
+		writeError(w, statusFor(err), err.Error())
 		return
 	}
 	uuid = resolved
@@ -602,16 +718,11 @@ func (s *Server) handleGetAreaTasks(w http.ResponseWriter, r *http.Request) {
 	// Check if area exists first
 	_, err = s.db.GetArea(uuid)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			writeError(w, http.StatusNotFound, err.Error())
-			return
-		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, statusFor(err), err.Error())
 		return
 	}
 
-	includeCompleted := r.URL.Query().Get("include_completed") == "true"
-	tasks, err := s.db.GetAreaTasks(uuid, includeCompleted)
+	tasks, err := s.db.GetAreaTasks(uuid, includeCompleted(r))
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -630,7 +741,7 @@ func (s *Server) handleGetAreaProjects(w http.ResponseWriter, r *http.Request) {
 
 	resolved, err := s.db.ResolveAreaUUID(uuid)
 	if err != nil {
-		writeError(w, http.StatusNotFound, err.Error())
+		writeError(w, statusFor(err), err.Error())
 		return
 	}
 	uuid = resolved
@@ -638,16 +749,11 @@ func (s *Server) handleGetAreaProjects(w http.ResponseWriter, r *http.Request) {
 	// Check if area exists first
 	_, err = s.db.GetArea(uuid)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			writeError(w, http.StatusNotFound, err.Error())
-			return
-		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, statusFor(err), err.Error())
 		return
 	}
 
-	includeCompleted := r.URL.Query().Get("include_completed") == "true"
-	projects, err := s.db.GetAreaProjects(uuid, includeCompleted)
+	projects, err := s.db.GetAreaProjects(uuid, includeCompleted(r))
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -656,9 +762,14 @@ func (s *Server) handleGetAreaProjects(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, projects)
 }
 
-// handleListTags returns all tags with usage counts
+// handleListTags returns all tags with usage counts, nested under their
+// parents with ?tree=true
 func (s *Server) handleListTags(w http.ResponseWriter, r *http.Request) {
-	tags, err := s.db.ListTags()
+	list := s.db.ListTags
+	if r.URL.Query().Get("tree") == "true" {
+		list = s.db.ListTagTree
+	}
+	tags, err := list()
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+
+	"thingies/internal/db"
+	"thingies/internal/models"
+)
+
+// TagCreateRequest is the request body for creating a tag
+type TagCreateRequest struct {
+	Title    string `json:"title"`
+	Parent   string `json:"parent,omitempty"`   // name, UUID, or prefix
+	Shortcut string `json:"shortcut,omitempty"` // single character
+}
+
+// TagUpdateRequest is the request body for updating a tag. Parent and
+// Shortcut are pointers so that "" can clear them.
+type TagUpdateRequest struct {
+	Title    string  `json:"title,omitempty"`
+	Parent   *string `json:"parent,omitempty"`   // name, UUID, or prefix; "" makes the tag top-level
+	Shortcut *string `json:"shortcut,omitempty"` // single character; "" removes it
+}
+
+// handleCreateTag handles POST /tags and returns the new tag. If it has not
+// reached the database within Config.CreateTimeout, it answers 202 with the
+// UUID AppleScript reported and the requested fields.
+func (s *Server) handleCreateTag(w http.ResponseWriter, r *http.Request) {
+	var req TagCreateRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if req.Title == "" {
+		writeError(w, http.StatusBadRequest, "title is required")
+		return
+	}
+	if msg := checkShortcut(req.Shortcut); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if status, msg := s.checkTagTitle(req.Title, ""); msg != "" {
+		writeError(w, status, msg)
+		return
+	}
+
+	var parent string
+	if req.Parent != "" {
+		var err error
+		if parent, err = s.db.ResolveTagID(req.Parent); err != nil {
+			writeError(w, statusFor(err), "parent: "+err.Error())
+			return
+		}
+	}
+
+	uuid, err := s.things.CreateTag(req.Title, parent)
+	if err != nil {
+		writeError(w, statusFor(err), "failed to create tag: "+err.Error())
+		return
+	}
+	if req.Shortcut != "" {
+		if err := s.things.SetTagShortcut(uuid, req.Shortcut); err != nil {
+			writeError(w, statusFor(err), "tag created but setting its shortcut failed: "+err.Error())
+			return
+		}
+	}
+
+	var tag *models.Tag
+	err = s.readBack(w, r, func() (err error) {
+		tag, err = s.db.GetTag(uuid)
+		return err
+	})
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		writeJSON(w, http.StatusAccepted, models.TagJSON{UUID: uuid, Title: req.Title, Shortcut: req.Shortcut, ParentUUID: parent})
+	case err != nil:
+		writeError(w, statusFor(err), err.Error())
+	default:
+		writeJSON(w, http.StatusOK, tag.ToJSON())
+	}
+}
+
+// handleUpdateTag handles PATCH /tags/{uuid} and returns the updated tag
+func (s *Server) handleUpdateTag(w http.ResponseWriter, r *http.Request) {
+	uuid, err := s.db.ResolveTagUUID(r.PathValue("uuid"))
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+
+	var req TagUpdateRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if req.Title == "" && req.Parent == nil && req.Shortcut == nil {
+		writeError(w, http.StatusBadRequest, "no fields to update")
+		return
+	}
+	if req.Shortcut != nil {
+		if msg := checkShortcut(*req.Shortcut); msg != "" {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
+	if req.Title != "" {
+		if status, msg := s.checkTagTitle(req.Title, uuid); msg != "" {
+			writeError(w, status, msg)
+			return
+		}
+	}
+
+	var parent string
+	if req.Parent != nil && *req.Parent != "" {
+		if parent, err = s.db.ResolveTagID(*req.Parent); err != nil {
+			writeError(w, statusFor(err), "parent: "+err.Error())
+			return
+		}
+		if msg := s.checkTagParent(uuid, parent); msg != "" {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
+
+	if req.Title != "" {
+		if err := s.things.UpdateTag(uuid, req.Title); err != nil {
+			writeError(w, statusFor(err), "failed to rename tag: "+err.Error())
+			return
+		}
+	}
+	if req.Parent != nil {
+		if err := s.things.SetTagParent(uuid, parent); err != nil {
+			writeError(w, statusFor(err), "failed to set parent tag: "+err.Error())
+			return
+		}
+	}
+	if req.Shortcut != nil {
+		if err := s.things.SetTagShortcut(uuid, *req.Shortcut); err != nil {
+			writeError(w, statusFor(err), "failed to set tag shortcut: "+err.Error())
+			return
+		}
+	}
+
+	s.writeTag(w, uuid)
+}
+
+// handleDeleteTag handles DELETE /tags/{uuid}
+func (s *Server) handleDeleteTag(w http.ResponseWriter, r *http.Request) {
+	uuid, err := s.db.ResolveTagUUID(r.PathValue("uuid"))
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+
+	if err := s.things.DeleteTag(uuid); err != nil {
+		writeError(w, statusFor(err), "failed to delete tag: "+err.Error())
+		return
+	}
+
+	writeSuccess(w, "tag deleted")
+}
+
+// writeTag reads a tag back from the database and writes it as TagJSON
+func (s *Server) writeTag(w http.ResponseWriter, uuid string) {
+	tag, err := s.db.GetTag(uuid)
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, tag.ToJSON())
+}
+
+// checkShortcut returns an error message unless shortcut is empty or a
+// single character, the only keyboard shortcuts Things accepts
+func checkShortcut(shortcut string) string {
+	if utf8.RuneCountInString(shortcut) > 1 {
+		return fmt.Sprintf("shortcut must be a single character, got %q", shortcut)
+	}
+	return ""
+}
+
+// checkTagTitle returns a status and error message if another tag than
+// self already has title, since Things tag names are unique
+func (s *Server) checkTagTitle(title, self string) (int, string) {
+	existing, err := s.db.GetTagUUIDByName(title)
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return 0, ""
+	case err != nil && !errors.Is(err, db.ErrAmbiguous):
+		return statusFor(err), err.Error()
+	case existing == self:
+		return 0, ""
+	default:
+		return http.StatusConflict, fmt.Sprintf("a tag named %q already exists", title)
+	}
+}
+
+// checkTagParent returns an error message if nesting uuid under parent would
+// make a tag its own ancestor
+func (s *Server) checkTagParent(uuid, parent string) string {
+	seen := make(map[string]bool)
+	for p := parent; p != "" && !seen[p]; {
+		if p == uuid {
+			return "a tag cannot be nested under itself or one of its children"
+		}
+		seen[p] = true
+		tag, err := s.db.GetTag(p)
+		if err != nil {
+			return ""
+		}
+		p = tag.ParentUUID.String
+	}
+	return ""
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/models"
+	"thingies/internal/sandbox"
+	"thingies/internal/things"
+)
+
+func TestListTagsTree(t *testing.T) {
+	b := dbtest.New(t)
+	work := b.Tag("work")
+	b.Tag("meetings").Parent(work)
+	b.Tag("home")
+	s := New(Config{}, b.Open(), things.NewRecordingBackend())
+
+	get := func(path string) []models.TagJSON {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected 200, got %d; body: %s", path, w.Code, w.Body.String())
+		}
+		var tags []models.TagJSON
+		if err := json.Unmarshal(w.Body.Bytes(), &tags); err != nil {
+			t.Fatal(err)
+		}
+		return tags
+	}
+
+	flat := get("/tags")
+	if len(flat) != 3 {
+		t.Fatalf("expected 3 flat tags, got %+v", flat)
+	}
+	for _, tag := range flat {
+		if tag.Title == "meetings" && tag.ParentUUID != work.UUID {
+			t.Errorf("meetings parent_uuid = %q, want %q", tag.ParentUUID, work.UUID)
+		}
+		if len(tag.Children) != 0 {
+			t.Errorf("flat listing should not nest, got children under %q", tag.Title)
+		}
+	}
+
+	tree := get("/tags?tree=true")
+	if len(tree) != 2 || tree[1].Title != "work" {
+		t.Fatalf("expected roots home and work, got %+v", tree)
+	}
+	if len(tree[1].Children) != 1 || tree[1].Children[0].Title != "meetings" {
+		t.Errorf("expected meetings under work, got %+v", tree[1].Children)
+	}
+}
+
+// TestTagCRUD creates, updates, and deletes tags against a sandbox database,
+// checking parent tags, shortcuts, and the validation in front of them
+func TestTagCRUD(t *testing.T) {
+	b := dbtest.New(t)
+	b.Tag("work")
+	backend, err := sandbox.Open(b.Path())
+	if err != nil {
+		t.Fatalf("sandbox.Open: %v", err)
+	}
+	defer backend.Close()
+	s := New(Config{}, b.Open(), backend)
+
+	do := func(method, path, body string, want int) models.TagJSON {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, req)
+		if w.Code != want {
+			t.Fatalf("%s %s %s: expected %d, got %d; body: %s", method, path, body, want, w.Code, w.Body.String())
+		}
+		var tag models.TagJSON
+		if want == http.StatusOK && method != http.MethodDelete {
+			if err := json.Unmarshal(w.Body.Bytes(), &tag); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return tag
+	}
+
+	office := do(http.MethodPost, "/tags", `{"title": "office"}`, http.StatusOK)
+	meetings := do(http.MethodPost, "/tags", `{"title": "meetings", "parent": "work", "shortcut": "m"}`, http.StatusOK)
+	if meetings.UUID == "" || meetings.ParentUUID == "" || meetings.Shortcut != "m" {
+		t.Fatalf("expected meetings under work with shortcut m, got %+v", meetings)
+	}
+
+	do(http.MethodPost, "/tags", `{"title": "work"}`, http.StatusConflict)
+	do(http.MethodPost, "/tags", `{"title": "x", "shortcut": "ab"}`, http.StatusBadRequest)
+	do(http.MethodPost, "/tags", `{"title": "x", "parent": "Nope"}`, http.StatusNotFound)
+
+	updated := do(http.MethodPatch, "/tags/"+meetings.UUID, `{"title": "standups", "parent": "office", "shortcut": ""}`, http.StatusOK)
+	if updated.Title != "standups" || updated.ParentUUID != office.UUID || updated.Shortcut != "" {
+		t.Errorf("expected standups under office without shortcut, got %+v", updated)
+	}
+	updated = do(http.MethodPatch, "/tags/"+meetings.UUID, `{"parent": ""}`, http.StatusOK)
+	if updated.ParentUUID != "" {
+		t.Errorf("expected standups at the top level, got parent %q", updated.ParentUUID)
+	}
+
+	do(http.MethodPatch, "/tags/"+office.UUID, `{"parent": "standups"}`, http.StatusOK)
+	do(http.MethodPatch, "/tags/"+meetings.UUID, `{"parent": "office"}`, http.StatusBadRequest)
+	do(http.MethodPatch, "/tags/"+meetings.UUID, `{"title": "office"}`, http.StatusConflict)
+	do(http.MethodPatch, "/tags/"+meetings.UUID, `{}`, http.StatusBadRequest)
+
+	do(http.MethodDelete, "/tags/"+meetings.UUID, "", http.StatusOK)
+	do(http.MethodDelete, "/tags/"+meetings.UUID, "", http.StatusNotFound)
+}
+
+// TestCreateTagNotReadBack checks that a tag AppleScript reports but the
+// database never shows is answered with 202 and the requested fields
+func TestCreateTagNotReadBack(t *testing.T) {
+	b := dbtest.New(t)
+	b.Tag("work").WithUUID("Work000000000000000000")
+	rec := things.NewRecordingBackend()
+	rec.Output = "Lost000000000000000000"
+	s := New(Config{CreateTimeout: 150 * time.Millisecond}, b.Open(), rec)
+
+	w := serve(s, http.MethodPost, "/tags", "", `{"title": "meetings", "parent": "work", "shortcut": "m"}`)
+	var tag models.TagJSON
+	if err := json.Unmarshal(w.Body.Bytes(), &tag); err != nil {
+		t.Fatal(err)
+	}
+	want := models.TagJSON{UUID: "Lost000000000000000000", Title: "meetings", Shortcut: "m", ParentUUID: "Work000000000000000000"}
+	if w.Code != http.StatusAccepted || !reflect.DeepEqual(tag, want) {
+		t.Errorf("expected 202 with %+v, got %d: %s", want, w.Code, w.Body.String())
+	}
+}
@@ -1,9 +1,13 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
+	"thingies/internal/db"
 	"thingies/internal/things"
 )
 
@@ -27,6 +31,15 @@ type TaskUpdateRequest struct {
 	Tags     string `json:"tags,omitempty"`
 }
 
+// TaskMoveRequest is the request body for moving a task. Exactly one of
+// Inbox, Area, or Project/Heading must be set.
+type TaskMoveRequest struct {
+	Project string `json:"project,omitempty"` // name, UUID, or prefix
+	Area    string `json:"area,omitempty"`    // name, UUID, or prefix
+	Heading string `json:"heading,omitempty"` // UUID or prefix
+	Inbox   bool   `json:"inbox,omitempty"`
+}
+
 // ProjectCreateRequest is the request body for creating a project
 type ProjectCreateRequest struct {
 	Title    string   `json:"title"`
@@ -86,13 +99,90 @@ func (s *Server) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 		Heading:  req.Heading,
 	}
 
-	url := things.BuildAddURL(params)
-	if err := things.OpenURL(url); err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to create task: "+err.Error())
+	if s.config.CreateTimeout == 0 {
+		if err := s.things.OpenURL(things.BuildAddURL(params)); err != nil {
+			writeError(w, statusFor(err), "failed to create task: "+err.Error())
+			return
+		}
+		writeSuccess(w, "task created")
 		return
 	}
 
-	writeSuccess(w, "task created")
+	match := db.CreatedMatch{Title: req.Title, Notes: req.Notes}
+	uuid, err := s.createAndWait(w, r, match, func(notes string) string {
+		params.Notes = notes
+		return things.BuildAddURL(params)
+	})
+	if err != nil {
+		writeError(w, statusFor(err), "failed to create task: "+err.Error())
+		return
+	}
+	task, err := s.db.GetTask(uuid)
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, task.ToJSON())
+}
+
+// createAndWait opens the things:/// create URL that build returns for the
+// given notes, waits up to Config.CreateTimeout for the new row and returns
+// its UUID. build receives the requested notes with a marker appended (see
+// db.PendingCreate), which is removed again once the row is found.
+func (s *Server) createAndWait(w http.ResponseWriter, r *http.Request, match db.CreatedMatch, build func(notes string) string) (string, error) {
+	pending, err := s.db.ExpectCreated(match)
+	if err != nil {
+		return "", err
+	}
+	if err := s.things.OpenURL(build(pending.MarkedNotes())); err != nil {
+		return "", err
+	}
+
+	extendWriteDeadline(w, s.config.CreateTimeout)
+	uuid, err := pending.Wait(r.Context(), s.config.CreateTimeout)
+	if err != nil {
+		return "", err
+	}
+	if err := pending.Strip(uuid, s.things.SetNotes); err != nil {
+		return "", err
+	}
+	return uuid, nil
+}
+
+// extendWriteDeadline gives a handler that is about to wait up to d the
+// server's usual write timeout on top of it, so a --create-timeout longer
+// than writeTimeout does not cut off the response
+func extendWriteDeadline(w http.ResponseWriter, d time.Duration) {
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(d + writeTimeout))
+}
+
+// readBackInterval is how often readBack retries a read
+const readBackInterval = 100 * time.Millisecond
+
+// readBack retries read while it returns db.ErrNotFound, up to
+// Config.CreateTimeout. AppleScript reports a new area's or tag's UUID
+// before the row is necessarily in the database, so a single read can miss
+// it. Returns read's last error.
+func (s *Server) readBack(w http.ResponseWriter, r *http.Request, read func() error) error {
+	extendWriteDeadline(w, s.config.CreateTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), s.config.CreateTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(readBackInterval)
+	defer ticker.Stop()
+
+	for {
+		err := read()
+		if !errors.Is(err, db.ErrNotFound) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-ticker.C:
+		}
+	}
 }
 
 // handleUpdateTask handles PATCH /tasks/{uuid}
@@ -105,7 +195,7 @@ func (s *Server) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 
 	resolved, err := s.db.ResolveTaskUUID(uuid)
 	if err != nil {
-		writeError(w, http.StatusNotFound, err.Error())
+		writeError(w, statusFor(err), err.Error())
 		return
 	}
 	uuid = resolved
@@ -125,18 +215,18 @@ func (s *Server) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 		TagNames: req.Tags,
 	}
 
-	// Specific dates need an auth token for the URL scheme
-	if things.IsSpecificDate(req.When) {
+	// Evening and specific dates need an auth token for the URL scheme
+	if things.NeedsAuthToken(req.When) {
 		token, err := s.db.GetAuthToken()
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, "failed to get auth token: "+err.Error())
+			writeError(w, statusFor(err), "failed to get auth token: "+err.Error())
 			return
 		}
 		params.AuthToken = token
 	}
 
-	if err := things.UpdateTask(params); err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to update task: "+err.Error())
+	if err := s.things.UpdateTask(params); err != nil {
+		writeError(w, statusFor(err), "failed to update task: "+err.Error())
 		return
 	}
 
@@ -153,13 +243,13 @@ func (s *Server) handleCompleteTask(w http.ResponseWriter, r *http.Request) {
 
 	resolved, err := s.db.ResolveTaskUUID(uuid)
 	if err != nil {
-		writeError(w, http.StatusNotFound, err.Error())
+		writeError(w, statusFor(err), err.Error())
 		return
 	}
 	uuid = resolved
 
-	if err := things.CompleteTask(uuid); err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to complete task: "+err.Error())
+	if err := s.things.CompleteTask(uuid); err != nil {
+		writeError(w, statusFor(err), "failed to complete task: "+err.Error())
 		return
 	}
 
@@ -176,13 +266,13 @@ func (s *Server) handleCancelTask(w http.ResponseWriter, r *http.Request) {
 
 	resolved, err := s.db.ResolveTaskUUID(uuid)
 	if err != nil {
-		writeError(w, http.StatusNotFound, err.Error())
+		writeError(w, statusFor(err), err.Error())
 		return
 	}
 	uuid = resolved
 
-	if err := things.CancelTask(uuid); err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to cancel task: "+err.Error())
+	if err := s.things.CancelTask(uuid); err != nil {
+		writeError(w, statusFor(err), "failed to cancel task: "+err.Error())
 		return
 	}
 
@@ -199,13 +289,13 @@ func (s *Server) handleDeleteTask(w http.ResponseWriter, r *http.Request) {
 
 	resolved, err := s.db.ResolveTaskUUID(uuid)
 	if err != nil {
-		writeError(w, http.StatusNotFound, err.Error())
+		writeError(w, statusFor(err), err.Error())
 		return
 	}
 	uuid = resolved
 
-	if err := things.DeleteTask(uuid); err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to delete task: "+err.Error())
+	if err := s.things.DeleteTask(uuid); err != nil {
+		writeError(w, statusFor(err), "failed to delete task: "+err.Error())
 		return
 	}
 
@@ -222,13 +312,13 @@ func (s *Server) handleMoveTaskToToday(w http.ResponseWriter, r *http.Request) {
 
 	resolved, err := s.db.ResolveTaskUUID(uuid)
 	if err != nil {
-		writeError(w, http.StatusNotFound, err.Error())
+		writeError(w, statusFor(err), err.Error())
 		return
 	}
 	uuid = resolved
 
-	if err := things.MoveTaskToToday(uuid); err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to move task to today: "+err.Error())
+	if err := s.things.MoveTaskToToday(uuid); err != nil {
+		writeError(w, statusFor(err), "failed to move task to today: "+err.Error())
 		return
 	}
 
@@ -245,7 +335,7 @@ func (s *Server) handleMoveTaskToSomeday(w http.ResponseWriter, r *http.Request)
 
 	resolved, err := s.db.ResolveTaskUUID(uuid)
 	if err != nil {
-		writeError(w, http.StatusNotFound, err.Error())
+		writeError(w, statusFor(err), err.Error())
 		return
 	}
 	uuid = resolved
@@ -256,14 +346,64 @@ func (s *Server) handleMoveTaskToSomeday(w http.ResponseWriter, r *http.Request)
 		When: "someday",
 	}
 
-	if err := things.UpdateTask(params); err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to move task to someday: "+err.Error())
+	if err := s.things.UpdateTask(params); err != nil {
+		writeError(w, statusFor(err), "failed to move task to someday: "+err.Error())
 		return
 	}
 
 	writeSuccess(w, "task moved to someday")
 }
 
+// handleMoveTask handles POST /tasks/{uuid}/move
+func (s *Server) handleMoveTask(w http.ResponseWriter, r *http.Request) {
+	uuid := r.PathValue("uuid")
+	if uuid == "" {
+		writeError(w, http.StatusBadRequest, "task UUID is required")
+		return
+	}
+
+	resolved, err := s.db.ResolveTaskUUID(uuid)
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+	uuid = resolved
+
+	var req TaskMoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	target, err := s.db.ResolveMoveTarget(req.Inbox, req.Project, req.Area, req.Heading)
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+
+	switch {
+	case target.Inbox:
+		err = s.things.MoveTaskToInbox(uuid)
+	case target.Heading != "":
+		token, tokenErr := s.db.GetAuthToken()
+		if tokenErr != nil {
+			writeError(w, statusFor(tokenErr), "failed to get auth token: "+tokenErr.Error())
+			return
+		}
+		err = s.things.MoveTaskToHeading(uuid, target.Project, target.Heading, token)
+	case target.Project != "":
+		err = s.things.MoveTaskToProject(uuid, target.Project)
+	default:
+		err = s.things.MoveTaskToArea(uuid, target.Area)
+	}
+	if err != nil {
+		writeError(w, statusFor(err), "failed to move task: "+err.Error())
+		return
+	}
+
+	writeSuccess(w, "task moved")
+}
+
 // handleCreateProject handles POST /projects
 func (s *Server) handleCreateProject(w http.ResponseWriter, r *http.Request) {
 	var req ProjectCreateRequest
@@ -287,11 +427,29 @@ func (s *Server) handleCreateProject(w http.ResponseWriter, r *http.Request) {
 		ToDos:    req.ToDos,
 	}
 
-	url := things.BuildAddProjectURL(params)
-	if err := things.OpenURL(url); err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to create project: "+err.Error())
+	if s.config.CreateTimeout == 0 {
+		if err := s.things.OpenURL(things.BuildAddProjectURL(params)); err != nil {
+			writeError(w, statusFor(err), "failed to create project: "+err.Error())
+			return
+		}
+		writeSuccess(w, "project created")
+		return
+	}
+
+	match := db.CreatedMatch{Project: true, Title: req.Title, Notes: req.Notes}
+	uuid, err := s.createAndWait(w, r, match, func(notes string) string {
+		params.Notes = notes
+		return things.BuildAddProjectURL(params)
+	})
+	if err != nil {
+		writeError(w, statusFor(err), "failed to create project: "+err.Error())
+		return
+	}
+	project, err := s.db.GetProject(uuid)
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
 		return
 	}
 
-	writeSuccess(w, "project created")
+	writeJSON(w, http.StatusOK, project.ToJSON())
 }
@@ -6,6 +6,12 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/models"
+	"thingies/internal/sandbox"
+	"thingies/internal/things"
 )
 
 // TestCreateTaskRejectsUnknownFields verifies that POST /tasks returns 400
@@ -63,8 +69,8 @@ func TestCreateTaskRejectsUnknownFields(t *testing.T) {
 
 // TestCreateTaskAcceptsValidFields verifies that valid JSON fields decode
 // without error when DisallowUnknownFields is enabled. This tests the decode
-// path directly to avoid side effects (the handler calls things.OpenURL which
-// launches external apps).
+// path directly; see backend_test.go for the full handler against a
+// recording backend.
 func TestCreateTaskAcceptsValidFields(t *testing.T) {
 	tests := []struct {
 		name string
@@ -95,3 +101,66 @@ func TestCreateTaskAcceptsValidFields(t *testing.T) {
 		})
 	}
 }
+
+// TestCreateReturnsCreatedItem runs POST /tasks and POST /projects against a
+// sandbox database and checks that the response is the new row, UUID included
+func TestCreateReturnsCreatedItem(t *testing.T) {
+	b := dbtest.New(t)
+	b.Project("Launch").InArea(b.Area("Work"))
+	path := b.Path()
+
+	backend, err := sandbox.Open(path)
+	if err != nil {
+		t.Fatalf("sandbox.Open: %v", err)
+	}
+	defer backend.Close()
+	s := New(Config{CreateTimeout: 2 * time.Second}, b.Open(), backend)
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title": "Write spec", "notes": "Draft", "list": "Launch"}`))
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST /tasks: expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	var task models.TaskJSON
+	if err := json.Unmarshal(w.Body.Bytes(), &task); err != nil {
+		t.Fatal(err)
+	}
+	if task.UUID == "" || task.Title != "Write spec" || task.Notes != "Draft" || task.ProjectName != "Launch" {
+		t.Errorf("unexpected task: %+v", task)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/projects", strings.NewReader(`{"title": "Q1 Review", "area": "Work"}`))
+	w = httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST /projects: expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	var project models.ProjectJSON
+	if err := json.Unmarshal(w.Body.Bytes(), &project); err != nil {
+		t.Fatal(err)
+	}
+	if project.UUID == "" || project.Title != "Q1 Review" || project.Notes != "" || project.AreaName != "Work" {
+		t.Errorf("unexpected project: %+v", project)
+	}
+}
+
+// TestCreateTimeout checks that a create Things never applies answers 504
+func TestCreateTimeout(t *testing.T) {
+	rec := things.NewRecordingBackend()
+	s := New(Config{CreateTimeout: 150 * time.Millisecond}, dbtest.New(t).Open(), rec)
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title": "Lost"}`))
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected 504, got %d; body: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "did not appear in the database") {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+	if len(rec.URLs()) != 1 {
+		t.Errorf("expected the add URL to be opened once, got %v", rec.URLs())
+	}
+}
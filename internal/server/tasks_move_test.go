@@ -0,0 +1,74 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/things"
+)
+
+func TestMoveTask(t *testing.T) {
+	b := dbtest.New(t).AuthToken("secret")
+	b.Area("Work").WithUUID("Work000000000000000000")
+	b.Area("Client Work").WithUUID("Client0000000000000000")
+	launch := b.Project("Launch").WithUUID("Launch0000000000000000")
+	b.Project("Q1 Launch").WithUUID("Q1Launch00000000000000")
+	other := b.Project("Other").WithUUID("Other00000000000000000")
+	b.Heading(launch, "Prep").WithUUID("Prep000000000000000000")
+	b.Heading(other, "Misc").WithUUID("Misc000000000000000000")
+	b.Task("Wanderer").WithUUID("Wanderer00000000000000")
+	rec := things.NewRecordingBackend()
+	s := New(Config{}, b.Open(), rec)
+
+	tests := []struct {
+		name   string
+		body   string
+		want   int
+		script string // expected in the last AppleScript
+		url    string // expected in the last URL
+	}{
+		{"project by name", `{"project": "Launch"}`, http.StatusOK, `set project of aToDo to project id "Launch0000000000000000"`, ""},
+		{"area by name", `{"area": "Work"}`, http.StatusOK, `set area of aToDo to area id "Work000000000000000000"`, ""},
+		{"project name with a space", `{"project": "Q1 Launch"}`, http.StatusOK, `set project of aToDo to project id "Q1Launch00000000000000"`, ""},
+		{"area name with a space", `{"area": "Client Work"}`, http.StatusOK, `set area of aToDo to area id "Client0000000000000000"`, ""},
+		{"inbox", `{"inbox": true}`, http.StatusOK, `move to do id "Wanderer00000000000000" to list "Inbox"`, ""},
+		{"heading", `{"heading": "Prep"}`, http.StatusOK, "", "heading-id=Prep000000000000000000"},
+		{"heading in project", `{"project": "Launch", "heading": "Prep"}`, http.StatusOK, "", "list-id=Launch0000000000000000"},
+		{"heading in other project", `{"project": "Launch", "heading": "Misc"}`, http.StatusBadRequest, "", ""},
+		{"no destination", `{}`, http.StatusBadRequest, "", ""},
+		{"two destinations", `{"inbox": true, "area": "Work"}`, http.StatusBadRequest, "", ""},
+		{"unknown project", `{"project": "Nowhere"}`, http.StatusNotFound, "", ""},
+		{"bad body", `{`, http.StatusBadRequest, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scripts, urls := len(rec.Scripts()), len(rec.URLs())
+			req := httptest.NewRequest(http.MethodPost, "/tasks/Wanderer/move", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d; body: %s", tt.want, w.Code, w.Body.String())
+			}
+			switch {
+			case tt.script != "":
+				got := rec.Scripts()
+				if len(got) != scripts+1 || !strings.Contains(got[len(got)-1], tt.script) {
+					t.Errorf("expected a script containing %q, got %v", tt.script, got[scripts:])
+				}
+			case tt.url != "":
+				got := rec.URLs()
+				if len(got) != urls+1 || !strings.Contains(got[len(got)-1], tt.url) {
+					t.Errorf("expected a URL containing %q, got %v", tt.url, got[urls:])
+				}
+			default:
+				if len(rec.Scripts()) != scripts || len(rec.URLs()) != urls {
+					t.Errorf("rejected move reached the backend")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"thingies/internal/models"
+	"thingies/internal/things"
+)
+
+// handleTrash handles GET /trash
+func (s *Server) handleTrash(w http.ResponseWriter, r *http.Request) {
+	items, err := s.db.ListTrash()
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := make([]models.TrashItemJSON, len(items))
+	for i, item := range items {
+		result[i] = item.ToJSON()
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleRestoreTrashItem handles POST /trash/{uuid}/restore
+func (s *Server) handleRestoreTrashItem(w http.ResponseWriter, r *http.Request) {
+	uuid := r.PathValue("uuid")
+	if uuid == "" {
+		writeError(w, http.StatusBadRequest, "item UUID is required")
+		return
+	}
+
+	resolved, err := s.db.ResolveTrashUUID(uuid)
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+	item, err := s.db.GetTrashItem(resolved)
+	if err != nil {
+		writeError(w, statusFor(err), err.Error())
+		return
+	}
+	if item.ProjectTrashed {
+		writeError(w, http.StatusConflict, fmt.Sprintf("project %s is in the trash too; restore it first", item.ProjectUUID.String))
+		return
+	}
+
+	kind := strings.ToLower(item.Type.String())
+	params := things.RestoreParams{
+		UUID:      item.UUID,
+		IsProject: item.Type == models.TypeProject,
+		IsHeading: item.Type == models.TypeHeading,
+		List:      item.List(),
+		Project:   item.ProjectUUID.String,
+		Area:      item.AreaUUID.String,
+	}
+	if err := s.things.RestoreItem(params); err != nil {
+		writeError(w, statusFor(err), "failed to restore "+kind+": "+err.Error())
+		return
+	}
+
+	writeSuccess(w, kind+" restored")
+}
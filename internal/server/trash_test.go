@@ -0,0 +1,81 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/models"
+	"thingies/internal/things"
+)
+
+func TestTrashRoutes(t *testing.T) {
+	b := dbtest.New(t)
+	work := b.Area("Work")
+	launch := b.Project("Launch").InArea(work)
+	retired := b.Project("Retired").InArea(work).WithUUID("Retired000000000000000").Trashed()
+	b.Task("Slides").InProject(launch).WithUUID("Slides0000000000000000").Trashed()
+	b.Task("Orphan").InProject(retired).WithUUID("Orphan0000000000000000").Trashed()
+	b.Task("Live").WithUUID("Live000000000000000000")
+	b.Heading(launch, "Drafts").WithUUID("Drafts0000000000000000").Trashed()
+	rec := things.NewRecordingBackend()
+	s := New(Config{}, b.Open(), rec)
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/trash")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /trash: expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	var items []models.TrashItemJSON
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 4 {
+		t.Fatalf("expected 4 trashed items, got %+v", items)
+	}
+	for _, item := range items {
+		if item.UUID == "Slides0000000000000000" && (item.ProjectName != "Launch" || item.AreaName != "Work") {
+			t.Errorf("expected Slides from Work > Launch, got %+v", item)
+		}
+		if item.UUID == "Orphan0000000000000000" && !item.ProjectTrashed {
+			t.Errorf("expected Orphan to report its trashed project, got %+v", item)
+		}
+	}
+
+	if w := do(http.MethodPost, "/trash/Slides/restore"); w.Code != http.StatusOK {
+		t.Fatalf("restore: expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	scripts := rec.Scripts()
+	if len(scripts) != 1 || !strings.Contains(scripts[0], `set theItem to to do id "Slides0000000000000000"`) ||
+		!strings.Contains(scripts[0], `set project of theItem to project id "`+launch.UUID+`"`) {
+		t.Errorf("unexpected restore script: %v", scripts)
+	}
+
+	if w := do(http.MethodPost, "/trash/Orphan/restore"); w.Code != http.StatusConflict {
+		t.Errorf("restore under a trashed project: expected 409, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/trash/Live/restore"); w.Code != http.StatusNotFound {
+		t.Errorf("restore of an untrashed task: expected 404, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/trash/Retired/restore"); w.Code != http.StatusOK {
+		t.Errorf("restore project: expected 200, got %d", w.Code)
+	}
+	if scripts := rec.Scripts(); len(scripts) != 2 || !strings.Contains(scripts[1], `set theItem to project id "Retired000000000000000"`) {
+		t.Errorf("unexpected project restore script: %v", scripts)
+	}
+	if w := do(http.MethodPost, "/trash/Drafts/restore"); w.Code != http.StatusNotImplemented {
+		t.Errorf("restore heading: expected 501, got %d; body: %s", w.Code, w.Body.String())
+	}
+	if scripts := rec.Scripts(); len(scripts) != 2 {
+		t.Errorf("heading restore should not run a script, got %v", scripts)
+	}
+}
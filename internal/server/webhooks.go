@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"thingies/internal/webhook"
+)
+
+// WebhookJSON describes a configured webhook; the secret is never shown
+type WebhookJSON struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Filter string   `json:"filter,omitempty"`
+}
+
+// startWebhooks starts delivering events to the configured webhooks, if
+// any. Deliveries stop with the change feed on Shutdown.
+func (s *Server) startWebhooks() error {
+	if s.config.Webhooks == nil {
+		return nil
+	}
+	hooks, err := webhook.NewDispatcher(s.config.Webhooks, s.db)
+	if err != nil {
+		return err
+	}
+	feed, err := s.events()
+	if err != nil {
+		return err
+	}
+	s.hooks = hooks
+	go hooks.Run(context.Background(), feed)
+	log.Printf("Delivering events to %d webhooks", len(hooks.Hooks()))
+	return nil
+}
+
+// handleListWebhooks handles GET /webhooks
+func (s *Server) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	if s.hooks == nil {
+		writeError(w, http.StatusNotFound, "no webhooks configured")
+		return
+	}
+	result := []WebhookJSON{}
+	for _, h := range s.hooks.Hooks() {
+		events := h.Events
+		if events == nil {
+			events = []string{}
+		}
+		result = append(result, WebhookJSON{Name: h.Name, URL: h.URL, Events: events, Filter: h.Filter})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleWebhookDeliveries handles GET /webhooks/deliveries, the most recent
+// delivery attempts, newest first (query: hook, limit, default 100)
+func (s *Server) handleWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if s.hooks == nil {
+		writeError(w, http.StatusNotFound, "no webhooks configured")
+		return
+	}
+	limit := 100 // default
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+	writeJSON(w, http.StatusOK, s.hooks.Deliveries(r.URL.Query().Get("hook"), limit))
+}
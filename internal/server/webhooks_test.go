@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"thingies/internal/db/dbtest"
+	"thingies/internal/sandbox"
+	"thingies/internal/webhook"
+)
+
+func TestWebhooks(t *testing.T) {
+	const secret = "hook-secret-0123456789"
+
+	// The receiver fails the first attempt so the delivery is retried
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	var mu sync.Mutex
+	attempts := 0
+	deliveries := make(chan received, 8)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		attempts++
+		first := attempts == 1
+		mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		deliveries <- received{r.Header, body}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+	config := fmt.Sprintf(`{"webhooks": [{"name": "deploys", "url": %q, "secret": %q,
+		"events": ["task.completed"], "filter": "tag:deploy"}], "backoff": "10ms"}`, receiver.URL, secret)
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	hooks, err := webhook.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	b := dbtest.New(t)
+	b.Task("Water plants").WithUUID("Plants0000000000000000").Anytime()
+	b.Task("Ship API").WithUUID("ShipAPI000000000000000").Anytime().Tags("deploy")
+	backend, err := sandbox.Open(b.Path())
+	if err != nil {
+		t.Fatalf("sandbox.Open: %v", err)
+	}
+	defer backend.Close()
+	s := New(Config{WatchInterval: 20 * time.Millisecond, Webhooks: hooks}, b.Open(), backend)
+	if err := s.startWebhooks(); err != nil {
+		t.Fatalf("startWebhooks: %v", err)
+	}
+	defer s.closeEvents()
+
+	// Only the task tagged deploy passes the filter
+	for _, uuid := range []string{"Plants", "ShipAPI"} {
+		if w := serve(s, http.MethodPost, "/tasks/"+uuid+"/complete", "", ""); w.Code != http.StatusOK {
+			t.Fatalf("complete %s: expected 200, got %d", uuid, w.Code)
+		}
+	}
+
+	var got received
+	select {
+	case got = <-deliveries:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a delivery")
+	}
+	var payload struct {
+		Webhook string `json:"webhook"`
+		ID      string `json:"id"`
+		Type    string `json:"type"`
+		UUID    string `json:"uuid"`
+	}
+	if err := json.Unmarshal(got.body, &payload); err != nil {
+		t.Fatalf("bad payload %q: %v", got.body, err)
+	}
+	if payload.Webhook != "deploys" || payload.Type != "task.completed" || payload.UUID != "ShipAPI000000000000000" {
+		t.Errorf("unexpected payload: %s", got.body)
+	}
+	timestamp, _ := strconv.ParseInt(got.header.Get("X-Thingies-Timestamp"), 10, 64)
+	if sig := got.header.Get("X-Thingies-Signature"); sig != webhook.Sign(secret, timestamp, got.body) {
+		t.Errorf("signature %q does not match the body", sig)
+	}
+	if got.header.Get("X-Thingies-Event") != "task.completed" || got.header.Get("X-Thingies-Delivery") != payload.ID {
+		t.Errorf("unexpected headers: %v", got.header)
+	}
+
+	select {
+	case extra := <-deliveries:
+		t.Errorf("expected one delivery, also got %s", extra.body)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// The log shows the failed attempt and the retry, newest first. The
+	// receiver answers before the attempt is logged, so allow a moment.
+	var entries []webhook.Delivery
+	var w *httptest.ResponseRecorder
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		w = serve(s, http.MethodGet, "/webhooks/deliveries?hook=deploys", "", "")
+		if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+			t.Fatalf("bad deliveries %q: %v", w.Body.String(), err)
+		}
+		if len(entries) == 2 {
+			break
+		}
+	}
+	if len(entries) != 2 ||
+		entries[0].Outcome != webhook.OutcomeDelivered || entries[0].Attempt != 2 || entries[0].Status != http.StatusNoContent ||
+		entries[1].Outcome != webhook.OutcomeRetrying || entries[1].Status != http.StatusBadGateway || entries[1].EventID != payload.ID {
+		t.Errorf("unexpected delivery log: %s", w.Body.String())
+	}
+
+	w = serve(s, http.MethodGet, "/webhooks", "", "")
+	if w.Code != http.StatusOK || !json.Valid(w.Body.Bytes()) || strings.Contains(w.Body.String(), secret) {
+		t.Errorf("expected the webhooks without secrets, got %d %s", w.Code, w.Body.String())
+	}
+}
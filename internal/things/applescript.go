@@ -2,59 +2,48 @@ package things
 
 import (
 	"fmt"
-	"os/exec"
 	"regexp"
 	"strings"
 )
 
-// runAppleScript executes AppleScript code
-func runAppleScript(script string) error {
-	cmd := exec.Command("osascript", "-e", script)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("applescript error: %s: %w", strings.TrimSpace(string(output)), err)
-	}
-	return nil
-}
-
 // DeleteTask deletes (trashes) a task by UUID
-func DeleteTask(uuid string) error {
+func (c *Client) DeleteTask(uuid string) error {
 	script := fmt.Sprintf(`tell application "Things3"
 	delete to do id "%s"
 end tell`, uuid)
-	return runAppleScript(script)
+	return c.runAppleScript(script)
 }
 
 // DeleteProject deletes (trashes) a project by UUID
-func DeleteProject(uuid string) error {
+func (c *Client) DeleteProject(uuid string) error {
 	script := fmt.Sprintf(`tell application "Things3"
 	delete project id "%s"
 end tell`, uuid)
-	return runAppleScript(script)
+	return c.runAppleScript(script)
 }
 
 // CompleteTask marks a task as complete by UUID
-func CompleteTask(uuid string) error {
+func (c *Client) CompleteTask(uuid string) error {
 	script := fmt.Sprintf(`tell application "Things3"
 	set status of to do id "%s" to completed
 end tell`, uuid)
-	return runAppleScript(script)
+	return c.runAppleScript(script)
 }
 
 // CancelTask marks a task as canceled by UUID
-func CancelTask(uuid string) error {
+func (c *Client) CancelTask(uuid string) error {
 	script := fmt.Sprintf(`tell application "Things3"
 	set status of to do id "%s" to canceled
 end tell`, uuid)
-	return runAppleScript(script)
+	return c.runAppleScript(script)
 }
 
 // MoveTaskToToday moves a task to the Today list
-func MoveTaskToToday(uuid string) error {
+func (c *Client) MoveTaskToToday(uuid string) error {
 	script := fmt.Sprintf(`tell application "Things3"
 	move to do id "%s" to list "Today"
 end tell`, uuid)
-	return runAppleScript(script)
+	return c.runAppleScript(script)
 }
 
 var datePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
@@ -64,6 +53,12 @@ func IsSpecificDate(when string) bool {
 	return datePattern.MatchString(when)
 }
 
+// NeedsAuthToken reports whether UpdateTask schedules when through the
+// things:///update URL scheme, which requires the auth token
+func NeedsAuthToken(when string) bool {
+	return when == "evening" || IsSpecificDate(when)
+}
+
 // TaskUpdateParams contains parameters for updating a task via AppleScript
 type TaskUpdateParams struct {
 	UUID      string
@@ -72,11 +67,11 @@ type TaskUpdateParams struct {
 	DueDate   string // YYYY-MM-DD format
 	When      string // "today", "tomorrow", "evening", "anytime", "someday", or YYYY-MM-DD
 	TagNames  string // comma-separated
-	AuthToken string // required for specific date scheduling via URL scheme
+	AuthToken string // required for evening and specific date scheduling via URL scheme
 }
 
 // UpdateTask updates a task's properties via AppleScript
-func UpdateTask(params TaskUpdateParams) error {
+func (c *Client) UpdateTask(params TaskUpdateParams) error {
 	var statements []string
 
 	if params.Name != "" {
@@ -90,9 +85,14 @@ func UpdateTask(params TaskUpdateParams) error {
 	}
 	if params.When != "" {
 		switch params.When {
-		case "today", "evening":
-			// Note: "evening" just moves to Today; Things 3 doesn't expose evening scheduling via AppleScript
+		case "today":
 			statements = append(statements, `move theTodo to list "Today"`)
+		case "evening":
+			// AppleScript has no This Evening list, but the URL scheme does
+			if params.AuthToken == "" {
+				return fmt.Errorf("auth token required for evening scheduling")
+			}
+			return c.updateViaURLScheme(params)
 		case "tomorrow":
 			statements = append(statements, `move theTodo to list "Tomorrow"`)
 		case "anytime":
@@ -101,13 +101,13 @@ func UpdateTask(params TaskUpdateParams) error {
 			statements = append(statements, `move theTodo to list "Someday"`)
 		default:
 			if !datePattern.MatchString(params.When) {
-				return fmt.Errorf("invalid when value '%s': use 'today', 'tomorrow', 'anytime', 'someday', or YYYY-MM-DD", params.When)
+				return fmt.Errorf("invalid when value '%s': use 'today', 'tomorrow', 'evening', 'anytime', 'someday', or YYYY-MM-DD", params.When)
 			}
 			// Specific dates require the URL scheme (AppleScript activation date is read-only)
 			if params.AuthToken == "" {
 				return fmt.Errorf("auth token required for specific date scheduling")
 			}
-			return updateViaURLScheme(params)
+			return c.updateViaURLScheme(params)
 		}
 	}
 	if params.TagNames != "" {
@@ -123,12 +123,13 @@ func UpdateTask(params TaskUpdateParams) error {
 	%s
 end tell`, params.UUID, strings.Join(statements, "\n\t"))
 
-	return runAppleScript(script)
+	return c.runAppleScript(script)
 }
 
 // updateViaURLScheme updates a task using the things:///update URL scheme.
-// Used for specific date scheduling since AppleScript's activation date is read-only.
-func updateViaURLScheme(params TaskUpdateParams) error {
+// Used for evening and specific date scheduling since AppleScript's
+// activation date is read-only.
+func (c *Client) updateViaURLScheme(params TaskUpdateParams) error {
 	updateParams := UpdateParams{
 		ID:        params.UUID,
 		AuthToken: params.AuthToken,
@@ -139,7 +140,7 @@ func updateViaURLScheme(params TaskUpdateParams) error {
 		Tags:      params.TagNames,
 	}
 	url := BuildUpdateURL(updateParams)
-	return OpenURL(url)
+	return c.OpenURL(url)
 }
 
 // ProjectUpdateParams contains parameters for updating a project via AppleScript
@@ -152,7 +153,7 @@ type ProjectUpdateParams struct {
 }
 
 // UpdateProject updates a project's properties via AppleScript
-func UpdateProject(params ProjectUpdateParams) error {
+func (c *Client) UpdateProject(params ProjectUpdateParams) error {
 	var statements []string
 
 	if params.Name != "" {
@@ -177,52 +178,74 @@ func UpdateProject(params ProjectUpdateParams) error {
 	%s
 end tell`, params.UUID, strings.Join(statements, "\n\t"))
 
-	return runAppleScript(script)
+	return c.runAppleScript(script)
 }
 
 // CompleteProject marks a project as complete
-func CompleteProject(uuid string) error {
+func (c *Client) CompleteProject(uuid string) error {
 	script := fmt.Sprintf(`tell application "Things3"
 	set status of project id "%s" to completed
 end tell`, uuid)
-	return runAppleScript(script)
+	return c.runAppleScript(script)
 }
 
 // CancelProject marks a project as canceled
-func CancelProject(uuid string) error {
+func (c *Client) CancelProject(uuid string) error {
 	script := fmt.Sprintf(`tell application "Things3"
 	set status of project id "%s" to canceled
 end tell`, uuid)
-	return runAppleScript(script)
+	return c.runAppleScript(script)
+}
+
+// MoveProjectToArea moves a project into an area by UUID
+func (c *Client) MoveProjectToArea(projectUUID, areaUUID string) error {
+	script := fmt.Sprintf(`tell application "Things3"
+	set theProject to project id "%s"
+	set area of theProject to area id "%s"
+end tell`, projectUUID, areaUUID)
+	return c.runAppleScript(script)
+}
+
+// SetNotes replaces the notes of a task or project, including with an empty
+// string, which UpdateTask and UpdateProject treat as "leave unchanged"
+func (c *Client) SetNotes(uuid string, isProject bool, notes string) error {
+	class := "to do"
+	if isProject {
+		class = "project"
+	}
+	script := fmt.Sprintf(`tell application "Things3"
+	set notes of %s id "%s" to %q
+end tell`, class, uuid, notes)
+	return c.runAppleScript(script)
 }
 
 // CreateArea creates a new area and returns its UUID
-func CreateArea(name string) (string, error) {
+func (c *Client) CreateArea(name string) (string, error) {
 	script := fmt.Sprintf(`tell application "Things3"
 	set newArea to make new area with properties {name:%q}
 	return id of newArea
 end tell`, name)
-	return runAppleScriptWithOutput(script)
+	return c.runAppleScriptWithOutput(script)
 }
 
 // UpdateArea updates an area's name
-func UpdateArea(uuid, name string) error {
+func (c *Client) UpdateArea(uuid, name string) error {
 	script := fmt.Sprintf(`tell application "Things3"
 	set name of area id "%s" to %q
 end tell`, uuid, name)
-	return runAppleScript(script)
+	return c.runAppleScript(script)
 }
 
 // DeleteArea deletes an area by UUID
-func DeleteArea(uuid string) error {
+func (c *Client) DeleteArea(uuid string) error {
 	script := fmt.Sprintf(`tell application "Things3"
 	delete area id "%s"
 end tell`, uuid)
-	return runAppleScript(script)
+	return c.runAppleScript(script)
 }
 
 // CreateTag creates a new tag and returns its UUID
-func CreateTag(name string, parentUUID string) (string, error) {
+func (c *Client) CreateTag(name string, parentUUID string) (string, error) {
 	var script string
 	if parentUUID != "" {
 		script = fmt.Sprintf(`tell application "Things3"
@@ -236,72 +259,116 @@ end tell`, parentUUID, name)
 	return id of newTag
 end tell`, name)
 	}
-	return runAppleScriptWithOutput(script)
+	return c.runAppleScriptWithOutput(script)
 }
 
 // UpdateTag updates a tag's name
-func UpdateTag(uuid, name string) error {
+func (c *Client) UpdateTag(uuid, name string) error {
 	script := fmt.Sprintf(`tell application "Things3"
 	set name of tag id "%s" to %q
 end tell`, uuid, name)
-	return runAppleScript(script)
+	return c.runAppleScript(script)
+}
+
+// SetTagShortcut sets a tag's keyboard shortcut; "" removes it
+func (c *Client) SetTagShortcut(uuid, shortcut string) error {
+	script := fmt.Sprintf(`tell application "Things3"
+	set keyboard shortcut of tag id "%s" to %q
+end tell`, uuid, shortcut)
+	return c.runAppleScript(script)
+}
+
+// SetTagParent nests a tag under another tag by UUID; "" makes it a
+// top-level tag
+func (c *Client) SetTagParent(uuid, parentUUID string) error {
+	parent := "missing value"
+	if parentUUID != "" {
+		parent = fmt.Sprintf(`tag id "%s"`, parentUUID)
+	}
+	script := fmt.Sprintf(`tell application "Things3"
+	set parent tag of tag id "%s" to %s
+end tell`, uuid, parent)
+	return c.runAppleScript(script)
 }
 
 // DeleteTag deletes a tag by UUID
-func DeleteTag(uuid string) error {
+func (c *Client) DeleteTag(uuid string) error {
 	script := fmt.Sprintf(`tell application "Things3"
 	delete tag id "%s"
 end tell`, uuid)
-	return runAppleScript(script)
+	return c.runAppleScript(script)
 }
 
 // DeleteHeading deletes a heading by UUID
 // Tasks under the heading move to project root
-func DeleteHeading(uuid string) error {
+func (c *Client) DeleteHeading(uuid string) error {
 	script := fmt.Sprintf(`tell application "Things3"
 	delete to do id "%s"
 end tell`, uuid)
-	return runAppleScript(script)
+	return c.runAppleScript(script)
 }
 
 // RenameHeading renames a heading by UUID
-func RenameHeading(uuid, newTitle string) error {
+func (c *Client) RenameHeading(uuid, newTitle string) error {
 	script := fmt.Sprintf(`tell application "Things3"
 	set name of to do id "%s" to %q
 end tell`, uuid, newTitle)
-	return runAppleScript(script)
+	return c.runAppleScript(script)
 }
 
-// runAppleScriptWithOutput executes AppleScript and returns the output
-func runAppleScriptWithOutput(script string) (string, error) {
-	cmd := exec.Command("osascript", "-e", script)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("applescript error: %s: %w", strings.TrimSpace(string(output)), err)
-	}
-	return strings.TrimSpace(string(output)), nil
+// ArchiveHeading archives a heading by UUID. Things archives a heading by
+// completing it; its tasks are left as they are.
+func (c *Client) ArchiveHeading(uuid string) error {
+	script := fmt.Sprintf(`tell application "Things3"
+	set status of to do id "%s" to completed
+end tell`, uuid)
+	return c.runAppleScript(script)
 }
 
 // MoveTaskToArea moves a task to an area by UUID
-func MoveTaskToArea(taskUUID, areaUUID string) error {
+func (c *Client) MoveTaskToArea(taskUUID, areaUUID string) error {
 	script := fmt.Sprintf(`tell application "Things3"
 	set aToDo to to do id "%s"
 	set area of aToDo to area id "%s"
 end tell`, taskUUID, areaUUID)
-	return runAppleScript(script)
+	return c.runAppleScript(script)
 }
 
 // MoveTaskToProject moves a task to a project by UUID
-func MoveTaskToProject(taskUUID, projectUUID string) error {
+func (c *Client) MoveTaskToProject(taskUUID, projectUUID string) error {
 	script := fmt.Sprintf(`tell application "Things3"
 	set aToDo to to do id "%s"
 	set project of aToDo to project id "%s"
 end tell`, taskUUID, projectUUID)
-	return runAppleScript(script)
+	return c.runAppleScript(script)
+}
+
+// MoveTaskToHeading moves a task under a heading of a project. AppleScript
+// reaches headings as to dos, but a to do has no heading property to set, so
+// this uses the things:///update URL scheme, which requires the auth token.
+func (c *Client) MoveTaskToHeading(taskUUID, projectUUID, headingUUID, authToken string) error {
+	if authToken == "" {
+		return fmt.Errorf("auth token required for moving a task under a heading")
+	}
+	url := BuildUpdateURL(UpdateParams{
+		ID:        taskUUID,
+		AuthToken: authToken,
+		ListID:    projectUUID,
+		HeadingID: headingUUID,
+	})
+	return c.OpenURL(url)
+}
+
+// MoveTaskToInbox moves a task out of its project or area into the Inbox
+func (c *Client) MoveTaskToInbox(uuid string) error {
+	script := fmt.Sprintf(`tell application "Things3"
+	move to do id "%s" to list "Inbox"
+end tell`, uuid)
+	return c.runAppleScript(script)
 }
 
 // DeleteAllOpenTasks deletes all open tasks (moves to trash) and returns the count
-func DeleteAllOpenTasks() (int, error) {
+func (c *Client) DeleteAllOpenTasks() (int, error) {
 	script := `tell application "Things3"
 	set openTodos to every to do whose status is open
 	set countDeleted to count of openTodos
@@ -310,7 +377,7 @@ func DeleteAllOpenTasks() (int, error) {
 	end repeat
 	return countDeleted
 end tell`
-	result, err := runAppleScriptWithOutput(script)
+	result, err := c.runAppleScriptWithOutput(script)
 	if err != nil {
 		return 0, err
 	}
@@ -321,3 +388,58 @@ end tell`
 	}
 	return count, nil
 }
+
+// RestoreParams describes where RestoreItem puts a trashed item back
+type RestoreParams struct {
+	UUID      string
+	IsProject bool
+	IsHeading bool
+	List      string // list the item was in: "Inbox", "Today", "Upcoming", "Anytime", or "Someday"
+	Project   string // original project UUID, for to-dos
+	Area      string // original area UUID, when not in a project
+}
+
+// ErrRestoreHeading is returned by RestoreItem for a heading
+var ErrRestoreHeading = fmt.Errorf("%w: a trashed heading cannot be restored; put it back in Things", ErrUnsupported)
+
+// RestoreItem takes a to-do or project out of the trash with AppleScript:
+// move to its built-in list, then set its project or area. A to do has no
+// heading property in AppleScript, so to-dos return to the project root
+// rather than their heading. Headings return ErrRestoreHeading, since there
+// is no known script that moves one back into its project.
+func (c *Client) RestoreItem(params RestoreParams) error {
+	if params.IsHeading {
+		return ErrRestoreHeading
+	}
+	class := "to do"
+	if params.IsProject {
+		class = "project"
+	}
+	// Upcoming can't be moved into without a date
+	list := params.List
+	if list == "" || list == "Upcoming" {
+		list = "Anytime"
+	}
+
+	statements := []string{fmt.Sprintf(`move theItem to list %q`, list)}
+	switch {
+	case params.Project != "" && !params.IsProject:
+		statements = append(statements, fmt.Sprintf(`set project of theItem to project id "%s"`, params.Project))
+	case params.Area != "":
+		statements = append(statements, fmt.Sprintf(`set area of theItem to area id "%s"`, params.Area))
+	}
+
+	script := fmt.Sprintf(`tell application "Things3"
+	set theItem to %s id "%s"
+	%s
+end tell`, class, params.UUID, strings.Join(statements, "\n\t"))
+	return c.runAppleScript(script)
+}
+
+// EmptyTrash permanently deletes everything in the trash
+func (c *Client) EmptyTrash() error {
+	script := `tell application "Things3"
+	empty trash
+end tell`
+	return c.runAppleScript(script)
+}
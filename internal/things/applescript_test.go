@@ -0,0 +1,207 @@
+package things
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"thingies/internal/models"
+)
+
+func TestClientRecordsScripts(t *testing.T) {
+	rec := NewRecordingBackend()
+	c := NewClient(rec)
+
+	if err := c.CompleteTask("6Cq1RzaLR7eFfjNL3Ymriw"); err != nil {
+		t.Fatalf("CompleteTask: %v", err)
+	}
+	if err := c.DeleteProject("7Xm2TpbMQ4gHhjOK4Znsjx"); err != nil {
+		t.Fatalf("DeleteProject: %v", err)
+	}
+
+	scripts := rec.Scripts()
+	if len(scripts) != 2 {
+		t.Fatalf("expected 2 scripts, got %d", len(scripts))
+	}
+	if !strings.Contains(scripts[0], `set status of to do id "6Cq1RzaLR7eFfjNL3Ymriw" to completed`) {
+		t.Errorf("unexpected complete script: %s", scripts[0])
+	}
+	if !strings.Contains(scripts[1], `delete project id "7Xm2TpbMQ4gHhjOK4Znsjx"`) {
+		t.Errorf("unexpected delete script: %s", scripts[1])
+	}
+	if len(rec.URLs()) != 0 {
+		t.Errorf("expected no URLs, got %v", rec.URLs())
+	}
+}
+
+func TestClientReturnsBackendOutput(t *testing.T) {
+	rec := &RecordingBackend{Output: "NewAreaUUID00000000000"}
+	c := NewClient(rec)
+
+	uuid, err := c.CreateArea("Work")
+	if err != nil {
+		t.Fatalf("CreateArea: %v", err)
+	}
+	if uuid != "NewAreaUUID00000000000" {
+		t.Errorf("expected backend output as UUID, got %q", uuid)
+	}
+	if !strings.Contains(rec.Scripts()[0], `make new area with properties {name:"Work"}`) {
+		t.Errorf("unexpected create script: %s", rec.Scripts()[0])
+	}
+}
+
+func TestUpdateTaskSpecificDateUsesURLScheme(t *testing.T) {
+	rec := NewRecordingBackend()
+	c := NewClient(rec)
+
+	err := c.UpdateTask(TaskUpdateParams{
+		UUID:      "6Cq1RzaLR7eFfjNL3Ymriw",
+		When:      "2026-03-15",
+		AuthToken: "secret",
+	})
+	if err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+
+	calls := rec.Calls()
+	if len(calls) != 1 || calls[0].Kind != CallURL {
+		t.Fatalf("expected a single URL call, got %+v", calls)
+	}
+	if !strings.HasPrefix(calls[0].Payload, "things:///update?") ||
+		!strings.Contains(calls[0].Payload, "when=2026-03-15") ||
+		!strings.Contains(calls[0].Payload, "auth-token=secret") {
+		t.Errorf("unexpected update URL: %s", calls[0].Payload)
+	}
+}
+
+func TestUpdateTaskEveningUsesURLScheme(t *testing.T) {
+	rec := NewRecordingBackend()
+	c := NewClient(rec)
+
+	if err := c.UpdateTask(TaskUpdateParams{UUID: "6Cq1RzaLR7eFfjNL3Ymriw", When: "evening"}); err == nil {
+		t.Error("expected an error without an auth token")
+	}
+
+	err := c.UpdateTask(TaskUpdateParams{
+		UUID:      "6Cq1RzaLR7eFfjNL3Ymriw",
+		When:      "evening",
+		AuthToken: "secret",
+	})
+	if err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+
+	calls := rec.Calls()
+	if len(calls) != 1 || calls[0].Kind != CallURL {
+		t.Fatalf("expected a single URL call, got %+v", calls)
+	}
+	if !strings.Contains(calls[0].Payload, "when=evening") ||
+		!strings.Contains(calls[0].Payload, "auth-token=secret") {
+		t.Errorf("unexpected update URL: %s", calls[0].Payload)
+	}
+}
+
+func TestClientPropagatesBackendError(t *testing.T) {
+	rec := &RecordingBackend{Err: errors.New("boom")}
+	c := NewClient(rec)
+
+	if err := c.CancelTask("6Cq1RzaLR7eFfjNL3Ymriw"); err == nil {
+		t.Fatal("expected backend error")
+	}
+	if len(rec.Scripts()) != 1 {
+		t.Errorf("failed call should still be recorded")
+	}
+}
+
+func TestRestoreItemScript(t *testing.T) {
+	rec := NewRecordingBackend()
+	c := NewClient(rec)
+
+	if err := c.RestoreItem(RestoreParams{UUID: "6Cq1RzaLR7eFfjNL3Ymriw", List: "Upcoming", Project: "7Xm2TpbMQ4gHhjOK4Znsjx"}); err != nil {
+		t.Fatalf("RestoreItem: %v", err)
+	}
+	if err := c.RestoreItem(RestoreParams{UUID: "7Xm2TpbMQ4gHhjOK4Znsjx", IsProject: true, List: "Someday", Area: "AreaUUID00000000000000"}); err != nil {
+		t.Fatalf("RestoreItem: %v", err)
+	}
+	if err := c.RestoreItem(RestoreParams{UUID: "HeadingUUID00000000000", IsHeading: true, Project: "7Xm2TpbMQ4gHhjOK4Znsjx"}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("heading restore: expected ErrUnsupported, got %v", err)
+	}
+
+	scripts := rec.Scripts()
+	if len(scripts) != 2 {
+		t.Fatalf("expected 2 scripts, got %d", len(scripts))
+	}
+	for _, want := range []string{
+		`set theItem to to do id "6Cq1RzaLR7eFfjNL3Ymriw"`,
+		`move theItem to list "Anytime"`,
+		`set project of theItem to project id "7Xm2TpbMQ4gHhjOK4Znsjx"`,
+	} {
+		if !strings.Contains(scripts[0], want) {
+			t.Errorf("task restore script missing %q:\n%s", want, scripts[0])
+		}
+	}
+	for _, want := range []string{
+		`set theItem to project id "7Xm2TpbMQ4gHhjOK4Znsjx"`,
+		`move theItem to list "Someday"`,
+		`set area of theItem to area id "AreaUUID00000000000000"`,
+	} {
+		if !strings.Contains(scripts[1], want) {
+			t.Errorf("project restore script missing %q:\n%s", want, scripts[1])
+		}
+	}
+}
+
+func TestChecklistURLs(t *testing.T) {
+	rec := NewRecordingBackend()
+	c := NewClient(rec)
+
+	if err := c.AddChecklistItems("6Cq1RzaLR7eFfjNL3Ymriw", []string{"Passport", "Charger"}, false, "tok"); err != nil {
+		t.Fatalf("AddChecklistItems: %v", err)
+	}
+	if err := c.AddChecklistItems("6Cq1RzaLR7eFfjNL3Ymriw", []string{"Tickets"}, true, "tok"); err != nil {
+		t.Fatalf("AddChecklistItems(prepend): %v", err)
+	}
+	if err := c.SetChecklist("6Cq1RzaLR7eFfjNL3Ymriw", []models.ChecklistItem{{Title: "Charger"}, {Title: "Passport"}}, "tok"); err != nil {
+		t.Fatalf("SetChecklist(open): %v", err)
+	}
+	if err := c.SetChecklist("6Cq1RzaLR7eFfjNL3Ymriw", []models.ChecklistItem{{Title: "Charger", Completed: true}, {Title: "Passport"}}, "tok"); err != nil {
+		t.Fatalf("SetChecklist(checked): %v", err)
+	}
+
+	urls := rec.URLs()
+	for i, want := range []string{
+		"append-checklist-items=Passport%0ACharger",
+		"prepend-checklist-items=Tickets",
+		"checklist-items=Charger%0APassport",
+		"things:///json?",
+	} {
+		if !strings.Contains(urls[i], want) {
+			t.Errorf("URL %d: expected %q in %s", i, want, urls[i])
+		}
+	}
+	data, _ := url.QueryUnescape(urls[3])
+	if !strings.Contains(data, `"operation":"update"`) || !strings.Contains(data, `{"title":"Charger","completed":true}`) {
+		t.Errorf("expected a JSON update keeping Charger completed, got %s", data)
+	}
+
+	if err := c.AddChecklistItems("6Cq1RzaLR7eFfjNL3Ymriw", []string{"two\nlines"}, false, "tok"); err == nil {
+		t.Error("expected an error for a title with a newline")
+	}
+	if err := c.SetChecklist("6Cq1RzaLR7eFfjNL3Ymriw", nil, ""); err == nil {
+		t.Error("expected an error without an auth token")
+	}
+}
+
+func TestHeadingWrites(t *testing.T) {
+	rec := NewRecordingBackend()
+	c := NewClient(rec)
+
+	if err := c.ArchiveHeading("Prep000000000000000000"); err != nil {
+		t.Fatalf("ArchiveHeading: %v", err)
+	}
+
+	if script := rec.Scripts()[0]; !strings.Contains(script, `set status of to do id "Prep000000000000000000" to completed`) {
+		t.Errorf("unexpected archive script %s", script)
+	}
+}
@@ -0,0 +1,83 @@
+package things
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Backend performs the side effects behind every write: running AppleScript
+// against the Things app and opening things:/// URLs.
+type Backend interface {
+	// RunAppleScript executes a script and returns its trimmed output
+	RunAppleScript(script string) (string, error)
+	// OpenURL hands a URL to the Things app
+	OpenURL(url string) error
+}
+
+// Client issues Things operations through a Backend
+type Client struct {
+	backend Backend
+
+	// now and wait are time.Now and waitContext; tests swap them to check
+	// the pacing of RunJSON without waiting
+	now  func() time.Time
+	wait func(context.Context, time.Duration) error
+
+	paced      bool       // RunJSON keeps to Things' rate limit; false for backends that do not reach Things
+	jsonMu     sync.Mutex // serializes RunJSON, which paces sends across calls
+	jsonWindow time.Time  // start of the current JSON rate window
+	jsonSent   int        // items sent since jsonWindow
+}
+
+// NewClient creates a Client that routes all writes through backend
+func NewClient(backend Backend) *Client {
+	if backend == nil {
+		backend = SystemBackend{}
+	}
+	c := &Client{backend: backend, now: time.Now, wait: waitContext, paced: true}
+	switch backend.(type) {
+	case *DryRunBackend, *RecordingBackend:
+		c.paced = false
+	}
+	return c
+}
+
+// waitContext waits for d to pass, returning early with ctx's error if ctx
+// ends first
+func waitContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Backend returns the backend the client writes through
+func (c *Client) Backend() Backend {
+	return c.backend
+}
+
+// OpenURL opens a Things URL through the backend
+func (c *Client) OpenURL(url string) error {
+	return c.backend.OpenURL(url)
+}
+
+// runAppleScript executes AppleScript code, discarding its output
+func (c *Client) runAppleScript(script string) error {
+	_, err := c.runAppleScriptWithOutput(script)
+	return err
+}
+
+// runAppleScriptWithOutput executes AppleScript and returns the output.
+// Failures are returned as a classified *ScriptError.
+func (c *Client) runAppleScriptWithOutput(script string) (string, error) {
+	output, err := c.backend.RunAppleScript(script)
+	if err != nil {
+		return "", classifyScriptError(err)
+	}
+	return output, nil
+}
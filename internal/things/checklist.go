@@ -0,0 +1,72 @@
+package things
+
+import (
+	"fmt"
+	"strings"
+
+	"thingies/internal/models"
+)
+
+// AddChecklistItems adds checklist items to the end of a to-do's checklist,
+// or to the top with prepend. AppleScript cannot address checklist items, so
+// this uses things:///update, which requires the auth token.
+func (c *Client) AddChecklistItems(taskUUID string, titles []string, prepend bool, authToken string) error {
+	if authToken == "" {
+		return fmt.Errorf("auth token required for checklist changes")
+	}
+	if err := checkChecklistTitles(titles); err != nil {
+		return err
+	}
+	if len(titles) == 0 {
+		return fmt.Errorf("no checklist items to add")
+	}
+
+	params := UpdateParams{ID: taskUUID, AuthToken: authToken}
+	if prepend {
+		params.PrependChecklistItems = titles
+	} else {
+		params.AppendChecklistItems = titles
+	}
+	return c.OpenURL(BuildUpdateURL(params))
+}
+
+// SetChecklist replaces a to-do's checklist with items, in order. When every
+// item is open this uses things:///update's checklist-items; otherwise it
+// uses things:///json, the only URL command that keeps an item's completion.
+// Either way Things gives every item a new UUID.
+func (c *Client) SetChecklist(taskUUID string, items []models.ChecklistItem, authToken string) error {
+	if authToken == "" {
+		return fmt.Errorf("auth token required for checklist changes")
+	}
+
+	titles := make([]string, len(items))
+	allOpen := len(items) > 0
+	for i, item := range items {
+		titles[i] = item.Title
+		if item.Completed || item.Canceled {
+			allOpen = false
+		}
+	}
+	if err := checkChecklistTitles(titles); err != nil {
+		return err
+	}
+
+	if allOpen {
+		return c.OpenURL(BuildUpdateURL(UpdateParams{ID: taskUUID, AuthToken: authToken, ChecklistItems: titles}))
+	}
+	return c.OpenURL(BuildChecklistJSONURL(taskUUID, authToken, items))
+}
+
+// checkChecklistTitles rejects titles the URL scheme would split or drop:
+// things:///update separates checklist items with newlines
+func checkChecklistTitles(titles []string) error {
+	for _, title := range titles {
+		if strings.TrimSpace(title) == "" {
+			return fmt.Errorf("checklist item title cannot be empty")
+		}
+		if strings.ContainsAny(title, "\r\n") {
+			return fmt.Errorf("checklist item title cannot contain a newline: %q", title)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package things
+
+import (
+	"fmt"
+	"io"
+)
+
+// DryRunBackend prints every script and URL instead of executing them,
+// so a command can be reviewed before it touches Things
+type DryRunBackend struct {
+	W io.Writer
+}
+
+// NewDryRunBackend creates a DryRunBackend that prints to w
+func NewDryRunBackend(w io.Writer) *DryRunBackend {
+	return &DryRunBackend{W: w}
+}
+
+// RunAppleScript prints the script and returns empty output
+func (d *DryRunBackend) RunAppleScript(script string) (string, error) {
+	_, err := fmt.Fprintf(d.W, "[dry-run] osascript:\n%s\n", script)
+	return "", err
+}
+
+// OpenURL prints the URL
+func (d *DryRunBackend) OpenURL(url string) error {
+	_, err := fmt.Fprintf(d.W, "[dry-run] open %s\n", url)
+	return err
+}
@@ -0,0 +1,81 @@
+package things
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Sentinel errors classifying why Things rejected an AppleScript write.
+// Test for them with errors.Is on any error returned by a Client method.
+var (
+	// ErrAppNotRunning means Things could not be reached or launched
+	ErrAppNotRunning = errors.New("Things is not running")
+	// ErrObjectMissing means the referenced to-do, project, area, or tag does not exist
+	ErrObjectMissing = errors.New("Things object not found")
+	// ErrPermissionDenied means macOS blocked automation of Things (System Settings >
+	// Privacy & Security > Automation)
+	ErrPermissionDenied = errors.New("not permitted to control Things")
+)
+
+// ScriptError is a failed AppleScript run, classified by the error number
+// osascript prints at the end of its message, e.g. "... (-1728)"
+type ScriptError struct {
+	Code int   // AppleScript error number, 0 if none was printed
+	Kind error // ErrAppNotRunning, ErrObjectMissing, ErrPermissionDenied, or nil
+	Err  error // the backend's error
+}
+
+func (e *ScriptError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap exposes both the classification and the backend's error to errors.Is
+func (e *ScriptError) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// errorCodePattern matches the trailing "(-1728)" osascript appends to errors
+var errorCodePattern = regexp.MustCompile(`\((-\d+)\)`)
+
+// AppleScript error numbers by classification
+var errorKinds = map[int]error{
+	-600:   ErrAppNotRunning,    // Application isn't running
+	-609:   ErrAppNotRunning,    // Connection is invalid
+	-10810: ErrAppNotRunning,    // Launch Services could not launch the app
+	-1728:  ErrObjectMissing,    // Can't get <object>
+	-1719:  ErrObjectMissing,    // Invalid index
+	-1743:  ErrPermissionDenied, // Not authorized to send Apple events
+	-10004: ErrPermissionDenied, // A privilege violation occurred
+}
+
+// classifyScriptError wraps a backend error in a ScriptError
+func classifyScriptError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var already *ScriptError
+	if errors.As(err, &already) {
+		return err
+	}
+
+	se := &ScriptError{Err: err}
+	msg := err.Error()
+	if m := errorCodePattern.FindAllStringSubmatch(msg, -1); m != nil {
+		se.Code, _ = strconv.Atoi(m[len(m)-1][1])
+		se.Kind = errorKinds[se.Code]
+	}
+	if se.Kind == nil {
+		switch {
+		case strings.Contains(msg, "isn't running"):
+			se.Kind = ErrAppNotRunning
+		case strings.Contains(msg, "Not authorized"):
+			se.Kind = ErrPermissionDenied
+		}
+	}
+	return se
+}
@@ -0,0 +1,48 @@
+package things
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestClassifyScriptError(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		wantKind error
+		wantCode int
+	}{
+		{"object missing", `Things3 got an error: Can't get to do id "abc". (-1728)`, ErrObjectMissing, -1728},
+		{"app not running", `Things3 got an error: Application isn't running. (-600)`, ErrAppNotRunning, -600},
+		{"not authorized", `Not authorized to send Apple events to Things3. (-1743)`, ErrPermissionDenied, -1743},
+		{"not running without code", `Things3 isn't running`, ErrAppNotRunning, 0},
+		{"unclassified", `syntax error: Expected end of line (-2741)`, nil, -2741},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &RecordingBackend{Err: fmt.Errorf("applescript error: %s: exit status 1", tt.output)}
+			err := NewClient(rec).CompleteTask("6Cq1RzaLR7eFfjNL3Ymriw")
+
+			var se *ScriptError
+			if !errors.As(err, &se) {
+				t.Fatalf("expected *ScriptError, got %T: %v", err, err)
+			}
+			if se.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d", se.Code, tt.wantCode)
+			}
+			for _, kind := range []error{ErrAppNotRunning, ErrObjectMissing, ErrPermissionDenied} {
+				if got, want := errors.Is(err, kind), kind == tt.wantKind; got != want {
+					t.Errorf("errors.Is(err, %q) = %v, want %v", kind, got, want)
+				}
+			}
+			if !errors.Is(err, rec.Err) {
+				t.Error("expected the backend error to stay in the chain")
+			}
+			if err.Error() != rec.Err.Error() {
+				t.Errorf("message changed: %q", err.Error())
+			}
+		})
+	}
+}
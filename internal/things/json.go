@@ -0,0 +1,291 @@
+package things
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Item types and operations of the things:///json command
+const (
+	JSONToDo          = "to-do"
+	JSONProject       = "project"
+	JSONHeading       = "heading"
+	JSONChecklistItem = "checklist-item"
+
+	JSONCreate = "create"
+	JSONUpdate = "update"
+)
+
+// Limits for sending a things:///json batch. Long URLs are cut off on their
+// way to Things, and Things accepts at most MaxJSONItems items per call and
+// per JSONRateWindow; RunJSON waits out the window before sending more.
+const (
+	maxJSONURLLength = 16 * 1024
+	MaxJSONItems     = 250
+	JSONRateWindow   = 10 * time.Second
+)
+
+// ErrInvalidJSON means a things:///json batch is malformed: an unknown item
+// type or operation, a missing title or id, or an item nested where Things
+// does not accept it
+var ErrInvalidJSON = errors.New("invalid things JSON")
+
+// ErrUnsupported means Things has no command for a write: neither AppleScript
+// nor the URL scheme can perform it
+var ErrUnsupported = errors.New("not supported by Things")
+
+// JSONItem is one object of a things:///json data array: a to-do or project
+// to create or update, or a heading or checklist item nested inside one
+type JSONItem struct {
+	Type       string         `json:"type"`                // to-do, project, heading, or checklist-item
+	Operation  string         `json:"operation,omitempty"` // create (the default) or update
+	ID         string         `json:"id,omitempty"`        // UUID of the item to update
+	Attributes JSONAttributes `json:"attributes"`
+}
+
+// JSONAttributes are the attributes of a JSONItem. Which ones apply depends
+// on the item type; Things ignores the rest.
+type JSONAttributes struct {
+	Title          string     `json:"title,omitempty"`
+	Notes          string     `json:"notes,omitempty"`
+	When           string     `json:"when,omitempty"`
+	Deadline       string     `json:"deadline,omitempty"`
+	Tags           []string   `json:"tags,omitempty"`
+	List           string     `json:"list,omitempty"`       // to-do: project or area title
+	ListID         string     `json:"list-id,omitempty"`    // to-do: project or area UUID
+	Heading        string     `json:"heading,omitempty"`    // to-do: heading title within the list
+	HeadingID      string     `json:"heading-id,omitempty"` // to-do: heading UUID within the list
+	Area           string     `json:"area,omitempty"`       // project: area title
+	AreaID         string     `json:"area-id,omitempty"`    // project: area UUID
+	Completed      bool       `json:"completed,omitempty"`
+	Canceled       bool       `json:"canceled,omitempty"`
+	Archived       bool       `json:"archived,omitempty"`        // heading
+	ChecklistItems []JSONItem `json:"checklist-items,omitempty"` // to-do: replaces the checklist on update
+	Items          []JSONItem `json:"items,omitempty"`           // project: to-dos and headings, in order
+}
+
+// MarshalJSON omits empty attributes like the struct tags say, except that
+// a non-nil empty ChecklistItems is kept: on an update it clears the checklist
+func (a JSONAttributes) MarshalJSON() ([]byte, error) {
+	type attributes JSONAttributes
+	data, err := json.Marshal(attributes(a))
+	if err != nil || a.ChecklistItems == nil || len(a.ChecklistItems) > 0 {
+		return data, err
+	}
+	if string(data) == "{}" {
+		return []byte(`{"checklist-items":[]}`), nil
+	}
+	return append([]byte(`{"checklist-items":[],`), data[1:]...), nil
+}
+
+// ValidateJSON checks a batch against the structure Things accepts: to-dos
+// and projects at the top level, to-dos and headings inside a new project's
+// items, checklist items inside a to-do's checklist-items, and only to-dos
+// and projects updated. Errors wrap ErrInvalidJSON.
+func ValidateJSON(items []JSONItem) error {
+	if len(items) == 0 {
+		return fmt.Errorf("%w: no items", ErrInvalidJSON)
+	}
+	for i, item := range items {
+		if err := validateJSONItem(item, fmt.Sprintf("item %d", i+1), true, JSONToDo, JSONProject); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateJSONItem checks one item, which must have one of the allowed types.
+// Only top-level items may be updates.
+func validateJSONItem(item JSONItem, path string, top bool, allowed ...string) error {
+	ok := false
+	for _, t := range allowed {
+		ok = ok || item.Type == t
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s: type %q is not allowed here (want %s)", ErrInvalidJSON, path, item.Type, strings.Join(allowed, " or "))
+	}
+
+	switch item.Operation {
+	case "", JSONCreate:
+		if item.ID != "" {
+			return fmt.Errorf("%w: %s: id is only used with operation update", ErrInvalidJSON, path)
+		}
+		if strings.TrimSpace(item.Attributes.Title) == "" {
+			return fmt.Errorf("%w: %s: %s needs a title", ErrInvalidJSON, path, item.Type)
+		}
+	case JSONUpdate:
+		if !top {
+			return fmt.Errorf("%w: %s: nested items cannot be updates", ErrInvalidJSON, path)
+		}
+		if item.ID == "" {
+			return fmt.Errorf("%w: %s: update needs an id", ErrInvalidJSON, path)
+		}
+		if len(item.Attributes.Items) > 0 {
+			return fmt.Errorf("%w: %s: items can only be given when creating a project; Things ignores them on update", ErrInvalidJSON, path)
+		}
+	default:
+		return fmt.Errorf("%w: %s: unknown operation %q (want create or update)", ErrInvalidJSON, path, item.Operation)
+	}
+
+	if len(item.Attributes.ChecklistItems) > 0 && item.Type != JSONToDo {
+		return fmt.Errorf("%w: %s: only to-dos have checklist-items", ErrInvalidJSON, path)
+	}
+	for i, c := range item.Attributes.ChecklistItems {
+		if err := validateJSONItem(c, fmt.Sprintf("%s checklist-items[%d]", path, i), false, JSONChecklistItem); err != nil {
+			return err
+		}
+	}
+	if len(item.Attributes.Items) > 0 && item.Type != JSONProject {
+		return fmt.Errorf("%w: %s: only projects have items", ErrInvalidJSON, path)
+	}
+	for i, child := range item.Attributes.Items {
+		if err := validateJSONItem(child, fmt.Sprintf("%s items[%d]", path, i), false, JSONToDo, JSONHeading); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JSONNeedsAuth reports whether a batch contains updates, which Things only
+// accepts together with the URL scheme auth token
+func JSONNeedsAuth(items []JSONItem) bool {
+	for _, item := range items {
+		if item.Operation == JSONUpdate {
+			return true
+		}
+	}
+	return false
+}
+
+// CountJSONItems returns the number of to-dos, projects, and headings in a
+// batch, nested ones included; checklist items are not counted
+func CountJSONItems(items []JSONItem) int {
+	n := 0
+	for _, item := range items {
+		if item.Type != JSONChecklistItem {
+			n++
+		}
+		n += CountJSONItems(item.Attributes.Items)
+	}
+	return n
+}
+
+// BuildJSONURL builds a single things:///json URL for items, however long.
+// authToken may be empty when no item is an update.
+func BuildJSONURL(authToken string, items ...JSONItem) string {
+	data, _ := json.Marshal(items)
+
+	u := url.URL{
+		Scheme: "things",
+		Host:   "",
+		Path:   "/json",
+	}
+	q := u.Query()
+	if authToken != "" {
+		q.Set("auth-token", authToken)
+	}
+	q.Set("data", string(data))
+
+	// Use %20 for spaces instead of + (Things doesn't decode + as space)
+	u.RawQuery = strings.ReplaceAll(q.Encode(), "+", "%20")
+	return u.String()
+}
+
+// jsonChunk is one things:///json URL of a batch and the number of items
+// in it, as counted by CountJSONItems
+type jsonChunk struct {
+	url   string
+	items int
+}
+
+// BuildJSONURLs splits items into as few things:///json URLs as the URL
+// length and item limits allow, keeping their order. A top-level item is
+// never split, since its nested items must arrive with it; one that does not
+// fit in a URL on its own is an error.
+func BuildJSONURLs(authToken string, items []JSONItem) ([]string, error) {
+	chunks, err := buildJSONChunks(authToken, items, maxJSONURLLength, MaxJSONItems)
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		urls[i] = chunk.url
+	}
+	return urls, nil
+}
+
+func buildJSONChunks(authToken string, items []JSONItem, maxLength, maxItems int) ([]jsonChunk, error) {
+	var chunks []jsonChunk
+	var chunk []JSONItem
+	var last string
+	count := 0
+	for i, item := range items {
+		n := CountJSONItems([]JSONItem{item})
+		candidate := BuildJSONURL(authToken, append(chunk, item)...)
+		if len(chunk) > 0 && (len(candidate) > maxLength || count+n > maxItems) {
+			chunks = append(chunks, jsonChunk{url: last, items: count})
+			chunk, count = nil, 0
+			candidate = BuildJSONURL(authToken, item)
+		}
+		if len(candidate) > maxLength {
+			return nil, fmt.Errorf("%w: item %d is %d bytes as a URL, over the %d byte limit; split it up", ErrInvalidJSON, i+1, len(candidate), maxLength)
+		}
+		if n > maxItems {
+			return nil, fmt.Errorf("%w: item %d holds %d items, over Things' limit of %d", ErrInvalidJSON, i+1, n, maxItems)
+		}
+		chunk = append(chunk, item)
+		count += n
+		last = candidate
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, jsonChunk{url: last, items: count})
+	}
+	return chunks, nil
+}
+
+// RunJSON validates items and sends them to Things with as many
+// things:///json URLs as needed, returning how many were opened. authToken
+// is required when any item is an update.
+//
+// Things takes at most MaxJSONItems items per 10 seconds, so before a URL
+// that would go over, RunJSON waits until the window has passed, or returns
+// ctx's error if ctx ends first. The count carries over between calls on the
+// same Client, not between processes. Clients writing to a DryRunBackend or
+// RecordingBackend do not wait, since nothing reaches Things.
+func (c *Client) RunJSON(ctx context.Context, items []JSONItem, authToken string) (int, error) {
+	if err := ValidateJSON(items); err != nil {
+		return 0, err
+	}
+	if authToken == "" && JSONNeedsAuth(items) {
+		return 0, fmt.Errorf("auth token required for things JSON updates")
+	}
+	chunks, err := buildJSONChunks(authToken, items, maxJSONURLLength, MaxJSONItems)
+	if err != nil {
+		return 0, err
+	}
+
+	c.jsonMu.Lock()
+	defer c.jsonMu.Unlock()
+	for i, chunk := range chunks {
+		now := c.now()
+		if now.Sub(c.jsonWindow) >= JSONRateWindow {
+			c.jsonWindow, c.jsonSent = now, 0
+		}
+		if c.paced && c.jsonSent+chunk.items > MaxJSONItems {
+			if err := c.wait(ctx, c.jsonWindow.Add(JSONRateWindow).Sub(now)); err != nil {
+				return i, err
+			}
+			c.jsonWindow, c.jsonSent = c.now(), 0
+		}
+		if err := c.OpenURL(chunk.url); err != nil {
+			return i, err
+		}
+		c.jsonSent += chunk.items
+	}
+	return len(chunks), nil
+}
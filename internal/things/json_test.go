@@ -0,0 +1,204 @@
+package things
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateJSON(t *testing.T) {
+	todo := func(title string) JSONItem {
+		return JSONItem{Type: JSONToDo, Attributes: JSONAttributes{Title: title}}
+	}
+	heading := JSONItem{Type: JSONHeading, Attributes: JSONAttributes{Title: "Prep"}}
+	check := JSONItem{Type: JSONChecklistItem, Attributes: JSONAttributes{Title: "Passport"}}
+
+	tests := []struct {
+		name    string
+		items   []JSONItem
+		wantErr string
+	}{
+		{name: "project structure", items: []JSONItem{{Type: JSONProject, Attributes: JSONAttributes{
+			Title: "Launch",
+			Items: []JSONItem{heading, {Type: JSONToDo, Attributes: JSONAttributes{Title: "Pack", ChecklistItems: []JSONItem{check}}}},
+		}}}},
+		{name: "update", items: []JSONItem{{Type: JSONToDo, Operation: JSONUpdate, ID: "abc", Attributes: JSONAttributes{When: "today"}}}},
+		{name: "empty", wantErr: "no items"},
+		{name: "top-level heading", items: []JSONItem{heading}, wantErr: `item 1: type "heading" is not allowed here`},
+		{name: "missing title", items: []JSONItem{todo(" ")}, wantErr: "item 1: to-do needs a title"},
+		{name: "items on an update", items: []JSONItem{{Type: JSONProject, Operation: JSONUpdate, ID: "abc", Attributes: JSONAttributes{Items: []JSONItem{heading}}}}, wantErr: "items can only be given when creating a project"},
+		{name: "update without id", items: []JSONItem{{Type: JSONProject, Operation: JSONUpdate}}, wantErr: "update needs an id"},
+		{name: "unknown operation", items: []JSONItem{{Type: JSONToDo, Operation: "delete"}}, wantErr: `unknown operation "delete"`},
+		{name: "nested update", items: []JSONItem{{Type: JSONProject, Attributes: JSONAttributes{
+			Title: "Launch",
+			Items: []JSONItem{{Type: JSONToDo, Operation: JSONUpdate, ID: "abc"}},
+		}}}, wantErr: "item 1 items[0]: nested items cannot be updates"},
+		{name: "items on a to-do", items: []JSONItem{{Type: JSONToDo, Attributes: JSONAttributes{Title: "Pack", Items: []JSONItem{todo("x")}}}}, wantErr: "only projects have items"},
+		{name: "checklist in a project", items: []JSONItem{{Type: JSONProject, Attributes: JSONAttributes{Title: "Launch", Items: []JSONItem{check}}}}, wantErr: `items[0]: type "checklist-item" is not allowed here`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateJSON(tt.items)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidJSON) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected ErrInvalidJSON containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestBuildJSONURLs(t *testing.T) {
+	var items []JSONItem
+	for _, title := range []string{"one", "two", "three", "four", "five"} {
+		items = append(items, JSONItem{Type: JSONToDo, Attributes: JSONAttributes{Title: title}})
+	}
+	single := len(BuildJSONURL("", items[0]))
+
+	chunks, err := buildJSONChunks("", items, 2*single+10, 250)
+	if err != nil {
+		t.Fatalf("buildJSONChunks: %v", err)
+	}
+	var titles []string
+	for _, chunk := range chunks {
+		if len(chunk.url) > 2*single+10 {
+			t.Errorf("URL over the limit: %d bytes", len(chunk.url))
+		}
+		data, _ := url.QueryUnescape(chunk.url)
+		for _, part := range strings.Split(data, `"title":"`)[1:] {
+			titles = append(titles, part[:strings.Index(part, `"`)])
+		}
+	}
+	if len(chunks) != 3 || strings.Join(titles, " ") != "one two three four five" {
+		t.Errorf("expected 3 URLs in order, got %d: %v", len(chunks), titles)
+	}
+
+	if chunks, _ := buildJSONChunks("", items, 1<<20, 2); len(chunks) != 3 || chunks[0].items != 2 || chunks[2].items != 1 {
+		t.Errorf("expected the item limit to give 3 URLs of 2, 2, and 1 items, got %+v", chunks)
+	}
+	if _, err := buildJSONChunks("", items, single-1, 250); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("expected an item too large for one URL to fail, got %v", err)
+	}
+
+	// An empty, non-nil checklist is sent, so an update can clear it
+	cleared := BuildJSONURL("tok", JSONItem{Type: JSONToDo, Operation: JSONUpdate, ID: "abc", Attributes: JSONAttributes{ChecklistItems: []JSONItem{}}})
+	if data, _ := url.QueryUnescape(cleared); !strings.Contains(data, `"attributes":{"checklist-items":[]}`) {
+		t.Errorf("expected an empty checklist-items, got %s", data)
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	rec := NewRecordingBackend()
+	c := NewClient(rec)
+
+	update := JSONItem{Type: JSONToDo, Operation: JSONUpdate, ID: "abc", Attributes: JSONAttributes{Title: "Renamed"}}
+	if _, err := c.RunJSON(context.Background(), []JSONItem{update}, ""); err == nil || !strings.Contains(err.Error(), "auth token") {
+		t.Errorf("expected an auth token error, got %v", err)
+	}
+	if _, err := c.RunJSON(context.Background(), []JSONItem{{Type: JSONHeading}}, ""); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("expected ErrInvalidJSON, got %v", err)
+	}
+	if len(rec.URLs()) != 0 {
+		t.Fatalf("expected nothing sent for invalid batches, got %v", rec.URLs())
+	}
+
+	n, err := c.RunJSON(context.Background(), []JSONItem{update}, "tok")
+	if err != nil || n != 1 {
+		t.Fatalf("RunJSON = %d, %v; want 1 URL", n, err)
+	}
+	if u := rec.URLs()[0]; !strings.HasPrefix(u, "things:///json?auth-token=tok&data=") {
+		t.Errorf("unexpected URL %s", u)
+	}
+}
+
+// pacedBackend hides a RecordingBackend's type from NewClient, so the
+// client paces its JSON sends as it would for Things
+type pacedBackend struct {
+	*RecordingBackend
+}
+
+func todos(n int) []JSONItem {
+	items := make([]JSONItem, n)
+	for i := range items {
+		items[i] = JSONItem{Type: JSONToDo, Attributes: JSONAttributes{Title: fmt.Sprintf("task %d", i+1)}}
+	}
+	return items
+}
+
+// TestRunJSONPacing checks that RunJSON waits out Things' 10-second window
+// before going over 250 items, also across calls
+func TestRunJSONPacing(t *testing.T) {
+	rec := NewRecordingBackend()
+	c := NewClient(pacedBackend{rec})
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	var waits []time.Duration
+	c.now = func() time.Time { return now }
+	c.wait = func(_ context.Context, d time.Duration) error {
+		if c.jsonSent > MaxJSONItems {
+			t.Errorf("sent %d items before waiting", c.jsonSent)
+		}
+		waits = append(waits, d)
+		now = now.Add(d)
+		return nil
+	}
+
+	n, err := c.RunJSON(context.Background(), todos(251), "")
+	if err != nil || n < 2 || len(rec.URLs()) != n {
+		t.Fatalf("RunJSON = %d, %v; want every URL sent", n, err)
+	}
+	if len(waits) != 1 || waits[0] != 10*time.Second {
+		t.Errorf("expected one 10s wait, got %v", waits)
+	}
+
+	// What fits in the rest of the window goes at once; one more item waits
+	// for the window to end
+	now = now.Add(3 * time.Second)
+	if _, err := c.RunJSON(context.Background(), todos(MaxJSONItems-c.jsonSent), ""); err != nil || len(waits) != 1 {
+		t.Errorf("expected the rest of the window to go without waiting, got %v, waits %v", err, waits)
+	}
+	if _, err := c.RunJSON(context.Background(), todos(1), ""); err != nil || len(waits) != 2 || waits[1] != 7*time.Second {
+		t.Errorf("expected a 7s wait for the rest of the window, got %v, waits %v", err, waits)
+	}
+}
+
+// TestRunJSONPacingCanceled checks that a canceled context ends the wait
+// and that recording and dry-run clients do not wait at all
+func TestRunJSONPacingCanceled(t *testing.T) {
+	rec := NewRecordingBackend()
+	c := NewClient(pacedBackend{rec})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	n, err := c.RunJSON(ctx, todos(251), "")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %d, %v", n, err)
+	}
+	if n != len(rec.URLs()) || n == 0 {
+		t.Errorf("expected the URLs before the wait to be sent and counted, got %d of %v", n, rec.URLs())
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("canceled RunJSON took %s", time.Since(start))
+	}
+
+	for _, backend := range []Backend{NewRecordingBackend(), NewDryRunBackend(io.Discard)} {
+		c := NewClient(backend)
+		c.wait = func(context.Context, time.Duration) error {
+			t.Errorf("%T client waited", backend)
+			return nil
+		}
+		if _, err := c.RunJSON(context.Background(), todos(2*MaxJSONItems), ""); err != nil {
+			t.Errorf("%T: %v", backend, err)
+		}
+	}
+}
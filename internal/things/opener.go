@@ -3,10 +3,24 @@ package things
 import (
 	"fmt"
 	"os/exec"
+	"strings"
 )
 
+// SystemBackend drives the real Things app via osascript and the macOS open command
+type SystemBackend struct{}
+
+// RunAppleScript executes AppleScript code via osascript
+func (SystemBackend) RunAppleScript(script string) (string, error) {
+	cmd := exec.Command("osascript", "-e", script)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("applescript error: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
 // OpenURL opens a URL using macOS open command
-func OpenURL(url string) error {
+func (SystemBackend) OpenURL(url string) error {
 	cmd := exec.Command("open", url)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
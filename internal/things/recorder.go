@@ -0,0 +1,91 @@
+package things
+
+import "sync"
+
+// CallKind identifies the kind of operation a backend was asked to perform
+type CallKind string
+
+const (
+	CallAppleScript CallKind = "applescript"
+	CallURL         CallKind = "url"
+)
+
+// Call is a single operation captured by RecordingBackend
+type Call struct {
+	Kind    CallKind `json:"kind"`
+	Payload string   `json:"payload"` // script source or URL
+}
+
+// RecordingBackend captures every script and URL instead of executing them.
+// It lets write paths be exercised on machines without Things installed.
+type RecordingBackend struct {
+	// Output is returned from every RunAppleScript call (e.g. the id of a created area)
+	Output string
+	// Err, when set, is returned from every call after it is recorded
+	Err error
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// NewRecordingBackend creates an empty RecordingBackend
+func NewRecordingBackend() *RecordingBackend {
+	return &RecordingBackend{}
+}
+
+// RunAppleScript records the script and returns the configured Output and Err
+func (r *RecordingBackend) RunAppleScript(script string) (string, error) {
+	r.record(CallAppleScript, script)
+	if r.Err != nil {
+		return "", r.Err
+	}
+	return r.Output, nil
+}
+
+// OpenURL records the URL and returns the configured Err
+func (r *RecordingBackend) OpenURL(url string) error {
+	r.record(CallURL, url)
+	return r.Err
+}
+
+func (r *RecordingBackend) record(kind CallKind, payload string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Kind: kind, Payload: payload})
+}
+
+// Calls returns every recorded call in the order it was issued
+func (r *RecordingBackend) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// Scripts returns the recorded AppleScript sources in order
+func (r *RecordingBackend) Scripts() []string {
+	return r.payloads(CallAppleScript)
+}
+
+// URLs returns the recorded URLs in order
+func (r *RecordingBackend) URLs() []string {
+	return r.payloads(CallURL)
+}
+
+// Reset discards all recorded calls
+func (r *RecordingBackend) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+func (r *RecordingBackend) payloads(kind CallKind) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []string
+	for _, c := range r.calls {
+		if c.Kind == kind {
+			out = append(out, c.Payload)
+		}
+	}
+	return out
+}
@@ -3,6 +3,8 @@ package things
 import (
 	"net/url"
 	"strings"
+
+	"thingies/internal/models"
 )
 
 // AddParams contains parameters for creating a task
@@ -129,8 +131,14 @@ type UpdateParams struct {
 	Deadline     string
 	Tags         string
 	AddTags      string
+	ListID       string // project or area UUID to move the to-do into
+	HeadingID    string // heading UUID within the ListID project
 	Completed    bool
 	Canceled     bool
+
+	ChecklistItems        []string // replaces the checklist; every item starts open
+	PrependChecklistItems []string
+	AppendChecklistItems  []string
 }
 
 // BuildUpdateURL builds a things:///update URL
@@ -169,14 +177,49 @@ func BuildUpdateURL(params UpdateParams) string {
 	if params.AddTags != "" {
 		q.Set("add-tags", params.AddTags)
 	}
+	if params.ListID != "" {
+		q.Set("list-id", params.ListID)
+	}
+	if params.HeadingID != "" {
+		q.Set("heading-id", params.HeadingID)
+	}
 	if params.Completed {
 		q.Set("completed", "true")
 	}
 	if params.Canceled {
 		q.Set("canceled", "true")
 	}
+	if len(params.ChecklistItems) > 0 {
+		q.Set("checklist-items", strings.Join(params.ChecklistItems, "\n"))
+	}
+	if len(params.PrependChecklistItems) > 0 {
+		q.Set("prepend-checklist-items", strings.Join(params.PrependChecklistItems, "\n"))
+	}
+	if len(params.AppendChecklistItems) > 0 {
+		q.Set("append-checklist-items", strings.Join(params.AppendChecklistItems, "\n"))
+	}
 
 	// Use %20 for spaces instead of + (Things doesn't decode + as space)
 	u.RawQuery = strings.ReplaceAll(q.Encode(), "+", "%20")
 	return u.String()
 }
+
+// BuildChecklistJSONURL builds a things:///json URL that replaces a to-do's
+// checklist. Unlike things:///update, the JSON command can set each item's
+// completion, so checking an item does not reopen the others.
+func BuildChecklistJSONURL(id, authToken string, items []models.ChecklistItem) string {
+	checklist := make([]JSONItem, len(items))
+	for i, item := range items {
+		checklist[i] = JSONItem{Type: JSONChecklistItem, Attributes: JSONAttributes{
+			Title:     item.Title,
+			Completed: item.Completed,
+			Canceled:  item.Canceled,
+		}}
+	}
+	return BuildJSONURL(authToken, JSONItem{
+		Type:       JSONToDo,
+		Operation:  JSONUpdate,
+		ID:         id,
+		Attributes: JSONAttributes{ChecklistItems: checklist},
+	})
+}
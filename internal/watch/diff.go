@@ -0,0 +1,156 @@
+package watch
+
+import (
+	"sort"
+
+	"thingies/internal/db"
+	"thingies/internal/models"
+)
+
+// Actions that make up an event type such as "task.completed"
+const (
+	ActionCreated   = "created"
+	ActionUpdated   = "updated"
+	ActionMoved     = "moved"
+	ActionCompleted = "completed"
+	ActionCanceled  = "canceled"
+	ActionReopened  = "reopened"
+	ActionArchived  = "archived" // headings
+	ActionRestored  = "restored" // taken out of the trash
+	ActionDeleted   = "deleted"  // trashed, or gone from the database
+)
+
+// Change is one difference between two States
+type Change struct {
+	Type    string // kind.action, e.g. "task.completed"
+	Kind    string // db.KindTask, db.KindProject, ...
+	Action  string // ActionCreated, ActionUpdated, ...
+	UUID    string
+	Title   string
+	Changed []string // fields that changed, for updated and moved
+	From    *db.ItemState
+	To      *db.ItemState
+
+	modified float64
+}
+
+// Diff returns the changes from prev to next, oldest modification first.
+//
+// An item may produce several changes at once, e.g. moved and updated. Only
+// the trashed item itself is flagged by Things, so the tasks of a deleted
+// project produce no events of their own. Items both created and trashed
+// between the two States produce nothing.
+func Diff(prev, next db.State) []Change {
+	var changes []Change
+	add := func(action string, from, to *db.ItemState, fields ...string) {
+		item := to
+		if item == nil {
+			item = from
+		}
+		changes = append(changes, Change{
+			Type:     item.Kind + "." + action,
+			Kind:     item.Kind,
+			Action:   action,
+			UUID:     item.UUID,
+			Title:    item.Title,
+			Changed:  fields,
+			From:     from,
+			To:       to,
+			modified: item.Modified,
+		})
+	}
+
+	for uuid, n := range next {
+		n := n
+		p, existed := prev[uuid]
+		switch {
+		case !existed:
+			if !n.Trashed {
+				add(ActionCreated, nil, &n)
+			}
+		case p.Trashed && !n.Trashed:
+			add(ActionRestored, &p, &n)
+		case !p.Trashed && n.Trashed:
+			add(ActionDeleted, &p, &n)
+		case !n.Trashed:
+			diffItem(p, n, add)
+		}
+	}
+	for uuid, p := range prev {
+		p := p
+		if _, exists := next[uuid]; !exists && !p.Trashed {
+			add(ActionDeleted, &p, nil)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.modified != b.modified {
+			return a.modified < b.modified
+		}
+		if a.UUID != b.UUID {
+			return a.UUID < b.UUID
+		}
+		return a.Action < b.Action
+	})
+	return changes
+}
+
+// diffItem adds the changes between two live versions of an item
+func diffItem(p, n db.ItemState, add func(action string, from, to *db.ItemState, fields ...string)) {
+	if p.Status != n.Status {
+		switch {
+		case n.Kind == db.KindHeading && n.Status != models.StatusIncomplete:
+			add(ActionArchived, &p, &n)
+		case n.Status == models.StatusCompleted:
+			add(ActionCompleted, &p, &n)
+		case n.Status == models.StatusCanceled:
+			add(ActionCanceled, &p, &n)
+		default:
+			add(ActionReopened, &p, &n)
+		}
+	}
+
+	var moved []string
+	if p.Area != n.Area {
+		moved = append(moved, "area")
+	}
+	if p.Project != n.Project {
+		moved = append(moved, "project")
+	}
+	if p.Heading != n.Heading {
+		moved = append(moved, "heading")
+	}
+	if p.Parent != n.Parent {
+		moved = append(moved, "parent")
+	}
+	if len(moved) > 0 {
+		add(ActionMoved, &p, &n, moved...)
+	}
+
+	var updated []string
+	if p.Title != n.Title {
+		updated = append(updated, "title")
+	}
+	if p.Notes != n.Notes {
+		updated = append(updated, "notes")
+	}
+	if p.Start != n.Start || p.StartDate != n.StartDate || p.Evening != n.Evening {
+		updated = append(updated, "when")
+	}
+	if p.Deadline != n.Deadline {
+		updated = append(updated, "deadline")
+	}
+	if p.Tags != n.Tags {
+		updated = append(updated, "tags")
+	}
+	if p.Checklist != n.Checklist {
+		updated = append(updated, "checklist")
+	}
+	if p.Shortcut != n.Shortcut {
+		updated = append(updated, "shortcut")
+	}
+	if len(updated) > 0 {
+		add(ActionUpdated, &p, &n, updated...)
+	}
+}
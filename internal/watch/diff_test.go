@@ -0,0 +1,81 @@
+package watch
+
+import (
+	"reflect"
+	"testing"
+
+	"thingies/internal/db"
+	"thingies/internal/models"
+)
+
+func TestDiff(t *testing.T) {
+	task := func(uuid, title string, modified float64) db.ItemState {
+		return db.ItemState{Kind: db.KindTask, UUID: uuid, Title: title, Modified: modified}
+	}
+	prev := db.State{
+		"a": task("a", "Write copy", 1),
+		"b": task("b", "Book venue", 1),
+		"c": task("c", "Pack", 1),
+		"d": task("d", "Old", 1),
+		"e": {Kind: db.KindTask, UUID: "e", Title: "Trashed", Trashed: true, Modified: 1},
+		"f": task("f", "Gone", 1),
+		"h": {Kind: db.KindHeading, UUID: "h", Title: "Prep", Project: "p", Modified: 1},
+		"t": {Kind: db.KindTag, UUID: "t", Title: "work"},
+	}
+	next := db.State{
+		"a": task("a", "Write copy", 2),
+		"b": task("b", "Book venue", 3),
+		"c": task("c", "Pack", 4),
+		"d": task("d", "Old", 1),
+		"e": task("e", "Trashed", 5),
+		"g": task("g", "New", 6),
+		"x": {Kind: db.KindTask, UUID: "x", Title: "Flash", Trashed: true, Modified: 6},
+		"h": {Kind: db.KindHeading, UUID: "h", Title: "Prep", Project: "p", Status: models.StatusCompleted, Modified: 7},
+		"t": {Kind: db.KindTag, UUID: "t", Title: "work", Shortcut: "w"},
+	}
+	a := next["a"]
+	a.Status = models.StatusCompleted
+	next["a"] = a
+	b := next["b"]
+	b.Project, b.Heading, b.Title = "p", "h", "Book the venue"
+	next["b"] = b
+	c := next["c"]
+	c.Trashed = true
+	next["c"] = c
+
+	var got []string
+	for _, change := range Diff(prev, next) {
+		got = append(got, change.UUID+" "+change.Type)
+		switch change.Type {
+		case "task.moved":
+			if !reflect.DeepEqual(change.Changed, []string{"project", "heading"}) {
+				t.Errorf("expected project and heading moved, got %v", change.Changed)
+			}
+		case "task.updated":
+			if !reflect.DeepEqual(change.Changed, []string{"title"}) || change.Title != "Book the venue" {
+				t.Errorf("expected the new title, got %v %q", change.Changed, change.Title)
+			}
+		case "tag.updated":
+			if !reflect.DeepEqual(change.Changed, []string{"shortcut"}) {
+				t.Errorf("expected the shortcut changed, got %v", change.Changed)
+			}
+		}
+	}
+
+	// Tags have no modification date, so they sort first; a vanished item
+	// sorts by its last known one
+	want := []string{
+		"t tag.updated",
+		"f task.deleted",
+		"a task.completed",
+		"b task.moved",
+		"b task.updated",
+		"c task.deleted",
+		"e task.restored",
+		"g task.created",
+		"h heading.archived",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected\n%v\ngot\n%v", want, got)
+	}
+}
@@ -0,0 +1,292 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"thingies/internal/db"
+)
+
+const (
+	// backlogSize is how many events a Feed keeps for resuming subscribers
+	backlogSize = 1000
+	// subscriberBuffer is how many events a subscriber may fall behind
+	// before it is dropped
+	subscriberBuffer = 256
+)
+
+// Event is a Change as delivered by a Feed: numbered, timestamped, and
+// carrying the item as it is now
+type Event struct {
+	ID      string            `json:"id"`
+	Type    string            `json:"type"`
+	Time    time.Time         `json:"time"`
+	UUID    string            `json:"uuid"`
+	Title   string            `json:"title,omitempty"`
+	Changed []string          `json:"changed,omitempty"`
+	From    map[string]string `json:"from,omitempty"` // moved: the previous container UUIDs
+	Item    interface{}       `json:"item,omitempty"` // TaskJSON, ProjectJSON, Heading, Area, or TagJSON; absent when deleted
+
+	// Change is the difference the event was built from
+	Change Change `json:"-"`
+}
+
+// Feed watches a Things database and publishes an Event for every change
+type Feed struct {
+	db       *db.ThingsDB
+	interval time.Duration
+	stream   string // distinguishes event IDs of this Feed from earlier ones
+
+	mu      sync.Mutex
+	seq     uint64
+	backlog []Event
+	subs    map[*Subscription]struct{}
+	stopped bool
+	done    chan struct{} // closed by stop
+}
+
+// NewFeed returns a Feed that checks the database files every interval
+func NewFeed(thingsDB *db.ThingsDB, interval time.Duration) *Feed {
+	return &Feed{
+		db:       thingsDB,
+		interval: interval,
+		stream:   strconv.FormatInt(time.Now().UnixNano(), 36),
+		subs:     make(map[*Subscription]struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start reads the current state as the baseline and watches for changes
+// until ctx is done, then closes every subscription. It returns once the
+// baseline is read, so changes made after Start returns are reported.
+func (f *Feed) Start(ctx context.Context) error {
+	prev, err := f.db.State()
+	if err != nil {
+		return err
+	}
+	changed := Files(ctx, f.interval, DatabaseFiles(f.db.Path())...)
+
+	go func() {
+		for range changed {
+			next, err := f.db.State()
+			if err != nil {
+				log.Printf("watch: %v", err)
+				continue
+			}
+			f.publish(Diff(prev, next))
+			prev = next
+		}
+		f.stop()
+	}()
+	return nil
+}
+
+// Done returns a channel that is closed when the feed stops. A subscription
+// closed before then was dropped for falling behind and may subscribe again.
+func (f *Feed) Done() <-chan struct{} {
+	return f.done
+}
+
+// Subscription receives the events of a Feed
+type Subscription struct {
+	// C delivers events as they happen. It is closed when the feed stops or
+	// the subscriber falls more than a few hundred events behind.
+	C <-chan Event
+	// Backlog holds the events after the ID passed to Subscribe
+	Backlog []Event
+	// Reset is set when events after that ID are no longer known, because
+	// the ID is from an earlier run or too old. The subscriber should
+	// re-read whatever state it keeps.
+	Reset bool
+	// LastID is the ID of the newest event published before Subscribe, ""
+	// if there is none
+	LastID string
+
+	feed *Feed
+	ch   chan Event
+}
+
+// Subscribe starts delivering events. lastID is the ID of the last event
+// the subscriber saw, or "" to receive only new ones.
+func (f *Feed) Subscribe(lastID string) *Subscription {
+	ch := make(chan Event, subscriberBuffer)
+	sub := &Subscription{C: ch, feed: f, ch: ch}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.seq > 0 {
+		sub.LastID = fmt.Sprintf("%s-%d", f.stream, f.seq)
+	}
+	if lastID != "" {
+		stream, seqText, _ := strings.Cut(lastID, "-")
+		seq, err := strconv.ParseUint(seqText, 10, 64)
+		switch {
+		case err != nil || stream != f.stream || seq > f.seq:
+			sub.Reset = true
+		case len(f.backlog) > 0 && seq+1 < f.seqOf(f.backlog[0]):
+			sub.Reset = true
+		default:
+			for _, event := range f.backlog {
+				if f.seqOf(event) > seq {
+					sub.Backlog = append(sub.Backlog, event)
+				}
+			}
+		}
+	}
+
+	if f.stopped {
+		close(ch)
+	} else {
+		f.subs[sub] = struct{}{}
+	}
+	return sub
+}
+
+// Close stops delivery to the subscription
+func (s *Subscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	if _, ok := s.feed.subs[s]; ok {
+		delete(s.feed.subs, s)
+		close(s.ch)
+	}
+}
+
+// seqOf returns the sequence number of an event of this feed
+func (f *Feed) seqOf(event Event) uint64 {
+	seq, _ := strconv.ParseUint(strings.TrimPrefix(event.ID, f.stream+"-"), 10, 64)
+	return seq
+}
+
+// publish numbers changes, looks up their items, and delivers them
+func (f *Feed) publish(changes []Change) {
+	if len(changes) == 0 {
+		return
+	}
+	events := make([]Event, len(changes))
+	now := time.Now()
+	for i, c := range changes {
+		events[i] = Event{Type: c.Type, Time: now, UUID: c.UUID, Title: c.Title, Changed: c.Changed, Change: c}
+		if c.Action == ActionMoved {
+			events[i].From = movedFrom(c)
+		}
+		if c.Action != ActionDeleted {
+			events[i].Item = f.item(c)
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range events {
+		f.seq++
+		events[i].ID = fmt.Sprintf("%s-%d", f.stream, f.seq)
+		f.backlog = append(f.backlog, events[i])
+		for sub := range f.subs {
+			select {
+			case sub.ch <- events[i]:
+			default:
+				// Too far behind: drop it so it reconnects and resumes
+				delete(f.subs, sub)
+				close(sub.ch)
+			}
+		}
+	}
+	if len(f.backlog) > backlogSize {
+		f.backlog = append([]Event(nil), f.backlog[len(f.backlog)-backlogSize:]...)
+	}
+}
+
+// stop closes every subscription
+func (f *Feed) stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopped = true
+	close(f.done)
+	for sub := range f.subs {
+		delete(f.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// movedFrom returns the previous containers of a moved item
+func movedFrom(c Change) map[string]string {
+	from := make(map[string]string)
+	for _, field := range c.Changed {
+		switch field {
+		case "area":
+			from[field] = c.From.Area
+		case "project":
+			from[field] = c.From.Project
+		case "heading":
+			from[field] = c.From.Heading
+		case "parent":
+			from[field] = c.From.Parent
+		}
+	}
+	return from
+}
+
+// item reads the changed item back from the database in its JSON form, or
+// returns nil if it cannot be read
+func (f *Feed) item(c Change) interface{} {
+	switch c.Kind {
+	case db.KindTask:
+		if task, err := f.db.GetTask(c.UUID); err == nil {
+			return task.ToJSON()
+		}
+	case db.KindProject:
+		if project, err := f.db.GetProject(c.UUID); err == nil {
+			return project.ToJSON()
+		}
+	case db.KindHeading:
+		if heading, err := f.db.GetHeading(c.UUID); err == nil {
+			return heading
+		}
+	case db.KindArea:
+		if area, err := f.db.GetArea(c.UUID); err == nil {
+			return area
+		}
+	case db.KindTag:
+		if tag, err := f.db.GetTag(c.UUID); err == nil {
+			return tag.ToJSON()
+		}
+	}
+	return nil
+}
+
+// TypeFilter holds the event types and kinds a subscriber is limited to,
+// e.g. "task.completed" or "project"; empty means all
+type TypeFilter map[string]bool
+
+// ParseTypes parses a comma-separated list of event types and kinds
+func ParseTypes(value string) TypeFilter {
+	return NewTypeFilter(strings.Split(value, ","))
+}
+
+// NewTypeFilter returns a filter for the given event types and kinds,
+// ignoring blank ones
+func NewTypeFilter(types []string) TypeFilter {
+	filter := make(TypeFilter)
+	for _, t := range types {
+		if t = strings.TrimSpace(t); t != "" {
+			filter[t] = true
+		}
+	}
+	return filter
+}
+
+// Match reports whether an event type such as "task.completed" passes the
+// filter, by its full type or its kind
+func (f TypeFilter) Match(eventType string) bool {
+	if len(f) == 0 || f[eventType] {
+		return true
+	}
+	kind, _, _ := strings.Cut(eventType, ".")
+	return f[kind]
+}
@@ -0,0 +1,75 @@
+// Package watch turns changes to the Things database into typed events.
+//
+// Files polls the database and its write-ahead log for writes, Diff compares
+// two db.State snapshots, and Feed ties them together: it re-reads the state
+// whenever the files change and fans the resulting events out to
+// subscribers, keeping a backlog so a subscriber can resume where it left off.
+package watch
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// DatabaseFiles returns the files SQLite writes for the database at path:
+// the database itself and its write-ahead log, where Things' writes land
+// until a checkpoint
+func DatabaseFiles(path string) []string {
+	return []string{path, path + "-wal"}
+}
+
+// fingerprint identifies the contents of a file well enough to notice a
+// write: its size and modification time, or zero values if it is missing
+type fingerprint struct {
+	size    int64
+	modTime time.Time
+}
+
+func fingerprints(paths []string) []fingerprint {
+	prints := make([]fingerprint, len(paths))
+	for i, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			prints[i] = fingerprint{size: info.Size(), modTime: info.ModTime()}
+		}
+	}
+	return prints
+}
+
+// Files polls paths every interval and sends on the returned channel when
+// any of them was written, created, or removed since the last poll. Changes
+// that arrive while a send is pending are merged into it. The channel is
+// closed when ctx is done.
+func Files(ctx context.Context, interval time.Duration, paths ...string) <-chan struct{} {
+	changed := make(chan struct{}, 1)
+	last := fingerprints(paths)
+
+	go func() {
+		defer close(changed)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			current := fingerprints(paths)
+			same := true
+			for i := range current {
+				same = same && current[i].size == last[i].size && current[i].modTime.Equal(last[i].modTime)
+			}
+			if same {
+				continue
+			}
+			last = current
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return changed
+}
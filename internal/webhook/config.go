@@ -0,0 +1,149 @@
+// Package webhook POSTs change events to HTTP endpoints.
+//
+// A Dispatcher subscribes to a watch.Feed and sends every event that passes
+// a hook's event types and task query to its URL, signed with the hook's
+// secret. Failed deliveries are retried with exponential backoff, and every
+// attempt is recorded in a delivery log.
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"thingies/internal/db"
+	"thingies/internal/watch"
+)
+
+// Defaults and limits for Config.Attempts and Config.Backoff
+const (
+	defaultAttempts = 6
+	maxAttempts     = 20
+	defaultBackoff  = 2 * time.Second
+	maxBackoff      = 5 * time.Minute
+)
+
+// Hook is one endpoint in the webhook config file
+type Hook struct {
+	Name   string   `json:"name"`             // shown in the delivery log and sent in the payload
+	URL    string   `json:"url"`              // http or https URL the events are POSTed to
+	Secret string   `json:"secret"`           // HMAC key for X-Thingies-Signature
+	Events []string `json:"events,omitempty"` // event types or kinds, e.g. "task.completed" or "project"; empty means all
+	Filter string   `json:"filter,omitempty"` // task query the item must match, e.g. "tag:deploy"; limits the hook to to-dos and projects
+
+	types watch.TypeFilter
+	query *db.TaskQuery
+}
+
+// Config is the file passed to `thingies serve --webhooks`:
+//
+//	{"webhooks": [
+//	  {"name": "deploys", "url": "https://ci.example.com/things", "secret": "...",
+//	   "events": ["task.completed"], "filter": "tag:deploy"}
+//	 ],
+//	 "log": "webhooks.jsonl", "attempts": 6, "backoff": "2s"}
+type Config struct {
+	Hooks    []Hook `json:"webhooks"`
+	Log      string `json:"log,omitempty"`      // JSON Lines file every delivery attempt is appended to
+	Attempts int    `json:"attempts,omitempty"` // tries per event, including the first (default 6)
+	Backoff  string `json:"backoff,omitempty"`  // wait before the first retry, doubled for each one after (default 2s)
+
+	backoff time.Duration
+}
+
+// LoadConfig reads and validates a webhook config file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook config: %w", err)
+	}
+
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid webhook config %s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid webhook config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// validate checks every hook, fills in defaults, and compiles the filters
+func (c *Config) validate() error {
+	if len(c.Hooks) == 0 {
+		return fmt.Errorf("no webhooks defined")
+	}
+
+	switch {
+	case c.Attempts == 0:
+		c.Attempts = defaultAttempts
+	case c.Attempts < 0 || c.Attempts > maxAttempts:
+		return fmt.Errorf("attempts must be between 1 and %d", maxAttempts)
+	}
+	c.backoff = defaultBackoff
+	if c.Backoff != "" {
+		backoff, err := time.ParseDuration(c.Backoff)
+		if err != nil || backoff <= 0 || backoff > maxBackoff {
+			return fmt.Errorf("backoff %q must be a duration between 0 and %s", c.Backoff, maxBackoff)
+		}
+		c.backoff = backoff
+	}
+
+	seen := make(map[string]bool)
+	for i := range c.Hooks {
+		h := &c.Hooks[i]
+		if h.Name == "" {
+			h.Name = fmt.Sprintf("webhook-%d", i+1)
+		}
+		if seen[h.Name] {
+			return fmt.Errorf("two webhooks are named %q", h.Name)
+		}
+		seen[h.Name] = true
+
+		u, err := url.Parse(h.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook %q: url must be an http or https URL", h.Name)
+		}
+		if len(h.Secret) < 16 {
+			return fmt.Errorf("webhook %q: secret must be at least 16 characters", h.Name)
+		}
+		for _, t := range h.Events {
+			if !validEventType(t) {
+				return fmt.Errorf("webhook %q: unknown event type %q (want a kind such as task, or kind.action such as task.completed)", h.Name, t)
+			}
+		}
+		h.types = watch.NewTypeFilter(h.Events)
+		if strings.TrimSpace(h.Filter) != "" {
+			if h.query, err = db.ParseQuery(h.Filter); err != nil {
+				return fmt.Errorf("webhook %q: filter: %w", h.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validEventType reports whether t is an event kind or kind.action
+func validEventType(t string) bool {
+	kind, action, hasAction := strings.Cut(t, ".")
+	switch kind {
+	case db.KindTask, db.KindProject, db.KindHeading, db.KindArea, db.KindTag:
+	default:
+		return false
+	}
+	if !hasAction {
+		return true
+	}
+	switch action {
+	case watch.ActionCreated, watch.ActionUpdated, watch.ActionMoved,
+		watch.ActionCompleted, watch.ActionCanceled, watch.ActionReopened,
+		watch.ActionArchived, watch.ActionRestored, watch.ActionDeleted:
+		return true
+	}
+	return false
+}
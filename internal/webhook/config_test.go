@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"valid", `{"webhooks": [{"url": "https://example.com/hook", "secret": "0123456789abcdef", "events": ["task.completed", "project"], "filter": "tag:deploy"}], "backoff": "500ms"}`, ""},
+		{"no webhooks", `{"webhooks": []}`, "no webhooks defined"},
+		{"bad url", `{"webhooks": [{"url": "ftp://example.com", "secret": "0123456789abcdef"}]}`, "http or https URL"},
+		{"short secret", `{"webhooks": [{"url": "https://example.com", "secret": "short"}]}`, "at least 16 characters"},
+		{"bad event", `{"webhooks": [{"url": "https://example.com", "secret": "0123456789abcdef", "events": ["task.complete"]}]}`, `unknown event type "task.complete"`},
+		{"bad filter", `{"webhooks": [{"url": "https://example.com", "secret": "0123456789abcdef", "filter": "colour:red"}]}`, "filter: invalid query"},
+		{"duplicate name", `{"webhooks": [{"name": "a", "url": "https://example.com", "secret": "0123456789abcdef"}, {"name": "a", "url": "https://example.org", "secret": "0123456789abcdef"}]}`, `two webhooks are named "a"`},
+		{"bad attempts", `{"webhooks": [{"url": "https://example.com", "secret": "0123456789abcdef"}], "attempts": 50}`, "attempts must be"},
+		{"bad backoff", `{"webhooks": [{"url": "https://example.com", "secret": "0123456789abcdef"}], "backoff": "soon"}`, "backoff"},
+		{"unknown field", `{"webhooks": [{"url": "https://example.com", "secret": "0123456789abcdef", "tags": ["deploy"]}]}`, "unknown field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "webhooks.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := LoadConfig(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				h := cfg.Hooks[0]
+				if h.Name != "webhook-1" || h.query == nil || !h.types.Match("project.created") || h.types.Match("task.created") {
+					t.Errorf("expected defaults and compiled filters, got %+v", h)
+				}
+				if cfg.Attempts != defaultAttempts || cfg.backoff != 500*time.Millisecond {
+					t.Errorf("expected default attempts and the given backoff, got %d %s", cfg.Attempts, cfg.backoff)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{"a":1}' | openssl dgst -sha256 -hmac 0123456789abcdef
+	got := Sign("0123456789abcdef", 1700000000, []byte(`{"a":1}`))
+	if want := "sha256=9eb18f493f8ec135d9eb2dad817c369bb4e9cbfa818657897a7437c1cd8c3a23"; got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+	if got == Sign("0123456789abcdef", 1700000001, []byte(`{"a":1}`)) {
+		t.Error("signature must cover the timestamp")
+	}
+}
@@ -0,0 +1,285 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"thingies/internal/db"
+	"thingies/internal/watch"
+)
+
+const (
+	// queueSize is how many events may wait for one hook, e.g. while its
+	// endpoint is down, before new ones are dropped
+	queueSize = 256
+	// requestTimeout bounds a single delivery attempt
+	requestTimeout = 10 * time.Second
+)
+
+// Payload is the JSON body POSTed to a hook: the event as sent on GET
+// /events, plus the name of the hook
+type Payload struct {
+	Webhook string `json:"webhook"`
+	watch.Event
+}
+
+// Dispatcher delivers the events of a watch.Feed to the hooks of a Config
+type Dispatcher struct {
+	config *Config
+	db     *db.ThingsDB
+	client *http.Client
+	log    *deliveryLog
+
+	// queries holds each hook's filter as of the day it was last used, see
+	// matches; only Run's goroutine touches it. now is time.Now, swapped by
+	// tests.
+	queries []*db.TaskQuery
+	now     func() time.Time
+}
+
+// NewDispatcher returns a Dispatcher for a config from LoadConfig. It opens
+// the config's log file, if any, which Run closes when it returns.
+func NewDispatcher(cfg *Config, thingsDB *db.ThingsDB) (*Dispatcher, error) {
+	deliveries, err := newDeliveryLog(cfg.Log)
+	if err != nil {
+		return nil, err
+	}
+	queries := make([]*db.TaskQuery, len(cfg.Hooks))
+	for i, hook := range cfg.Hooks {
+		queries[i] = hook.query
+	}
+	return &Dispatcher{
+		config:  cfg,
+		db:      thingsDB,
+		client:  &http.Client{Timeout: requestTimeout},
+		log:     deliveries,
+		queries: queries,
+		now:     time.Now,
+	}, nil
+}
+
+// Hooks returns the configured hooks
+func (d *Dispatcher) Hooks() []Hook {
+	return d.config.Hooks
+}
+
+// Deliveries returns up to limit of the most recent delivery attempts,
+// newest first, optionally only those of the named hook
+func (d *Dispatcher) Deliveries(hook string, limit int) []Delivery {
+	return d.log.recent(hook, limit)
+}
+
+// Run delivers events from feed until ctx is done or the feed stops. Each
+// hook has its own queue, so a slow or failing endpoint delays only itself.
+func (d *Dispatcher) Run(ctx context.Context, feed *watch.Feed) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer d.log.close()
+
+	var wg sync.WaitGroup
+	queues := make([]chan watch.Event, len(d.config.Hooks))
+	for i := range d.config.Hooks {
+		queues[i] = make(chan watch.Event, queueSize)
+		wg.Add(1)
+		go func(hook *Hook, queue <-chan watch.Event) {
+			defer wg.Done()
+			for event := range queue {
+				d.deliver(ctx, hook, event)
+			}
+		}(&d.config.Hooks[i], queues[i])
+	}
+	defer func() {
+		cancel() // abandon pending retries
+		for _, queue := range queues {
+			close(queue)
+		}
+		wg.Wait()
+	}()
+
+	// Resubscribe from the last event seen if the feed drops the
+	// subscription for falling behind
+	lastID := ""
+	for {
+		sub := feed.Subscribe(lastID)
+		if sub.Reset {
+			log.Printf("webhook: events after %s are no longer available and will not be delivered", lastID)
+		}
+		for _, event := range sub.Backlog {
+			d.dispatch(event, queues)
+			lastID = event.ID
+		}
+	receive:
+		for {
+			select {
+			case <-ctx.Done():
+				sub.Close()
+				return
+			case event, ok := <-sub.C:
+				if !ok {
+					break receive
+				}
+				d.dispatch(event, queues)
+				lastID = event.ID
+			}
+		}
+		select {
+		case <-feed.Done():
+			return
+		default:
+		}
+	}
+}
+
+// dispatch queues an event for every hook it matches
+func (d *Dispatcher) dispatch(event watch.Event, queues []chan watch.Event) {
+	for i := range d.config.Hooks {
+		hook := &d.config.Hooks[i]
+		if !d.matches(i, event) {
+			continue
+		}
+		select {
+		case queues[i] <- event:
+		default:
+			d.log.add(Delivery{
+				Time: time.Now(), Hook: hook.Name, EventID: event.ID, Type: event.Type, UUID: event.UUID,
+				Outcome: OutcomeDropped, Error: "too many undelivered events",
+			})
+		}
+	}
+}
+
+// matches reports whether an event passes the event types and filter of
+// hook i. The filter is tested against the item as it is now, so
+// "tag:deploy" with task.completed fires when a task tagged deploy is
+// completed, and its relative dates against today, not the day the config
+// was loaded.
+func (d *Dispatcher) matches(i int, event watch.Event) bool {
+	hook := &d.config.Hooks[i]
+	if !hook.types.Match(event.Type) {
+		return false
+	}
+	if d.queries[i] == nil {
+		return true
+	}
+	if event.Change.Kind != db.KindTask && event.Change.Kind != db.KindProject {
+		return false
+	}
+	d.queries[i] = d.queries[i].ForDay(d.now())
+	ok, err := d.db.MatchesQuery(event.UUID, d.queries[i])
+	if err != nil {
+		log.Printf("webhook %s: %v", hook.Name, err)
+		return false
+	}
+	return ok
+}
+
+// deliver POSTs an event to a hook, retrying with exponential backoff until
+// it is accepted, the attempts run out, or the endpoint rejects it for good
+func (d *Dispatcher) deliver(ctx context.Context, hook *Hook, event watch.Event) {
+	body, err := json.Marshal(Payload{Webhook: hook.Name, Event: event})
+	if err != nil {
+		log.Printf("webhook %s: %v", hook.Name, err)
+		return
+	}
+
+	wait := d.config.backoff
+	for attempt := 1; ; attempt++ {
+		if ctx.Err() != nil {
+			return
+		}
+		start := time.Now()
+		status, err := d.post(ctx, hook, event, body)
+		delivery := Delivery{
+			Time: start, Hook: hook.Name, EventID: event.ID, Type: event.Type, UUID: event.UUID,
+			Attempt: attempt, Status: status, Duration: time.Since(start).Milliseconds(),
+		}
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+
+		switch {
+		case err == nil && status >= 200 && status < 300:
+			delivery.Outcome = OutcomeDelivered
+		case attempt < d.config.Attempts && retryable(status, err) && ctx.Err() == nil:
+			delivery.Outcome = OutcomeRetrying
+			delivery.NextAttempt = time.Now().Add(wait)
+		default:
+			delivery.Outcome = OutcomeFailed
+		}
+		d.log.add(delivery)
+		if delivery.Outcome != OutcomeRetrying {
+			if delivery.Outcome == OutcomeFailed {
+				log.Printf("webhook %s: giving up on %s after %d attempts", hook.Name, event.ID, attempt)
+			}
+			return
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		wait = min(wait*2, maxBackoff)
+	}
+}
+
+// post makes one delivery attempt and returns the response status
+func (d *Dispatcher) post(ctx context.Context, hook *Hook, event watch.Event, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "thingies-webhook")
+	req.Header.Set("X-Thingies-Event", event.Type)
+	req.Header.Set("X-Thingies-Delivery", event.ID)
+	req.Header.Set("X-Thingies-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Thingies-Signature", Sign(hook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryable reports whether a failed attempt may succeed later: network
+// errors, timeouts, rate limits, and server errors are retried, other 4xx
+// answers are not
+func retryable(status int, err error) bool {
+	if status == 0 {
+		return err != nil && !errors.Is(err, context.Canceled)
+	}
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+}
+
+// Sign returns the X-Thingies-Signature header value for a body sent at
+// timestamp (unix seconds): "sha256=" and the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the secret. Receivers should recompute
+// it, compare in constant time, and reject old timestamps to stop replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,38 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"thingies/internal/db"
+	"thingies/internal/db/dbtest"
+	"thingies/internal/watch"
+)
+
+// TestFilterFollowsClock checks that a filter's relative dates are resolved
+// against the day an event arrives, not the day the config was loaded
+func TestFilterFollowsClock(t *testing.T) {
+	b := dbtest.New(t)
+	b.Task("Ship").WithUUID("Ship000000000000000000").Deadline(dbtest.DaysFromToday(1))
+	thingsDB := b.Open()
+
+	cfg := &Config{Hooks: []Hook{{URL: "https://example.com/hook", Secret: "0123456789abcdef", Filter: "due:today"}}}
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewDispatcher(cfg, thingsDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := dbtest.DaysFromToday(1).Add(-time.Minute)
+	d.now = func() time.Time { return now }
+
+	event := watch.Event{Type: "task.updated", UUID: "Ship000000000000000000", Change: watch.Change{Kind: db.KindTask}}
+	if d.matches(0, event) {
+		t.Errorf("due:today matched a task due tomorrow")
+	}
+	now = now.Add(2 * time.Minute)
+	if !d.matches(0, event) {
+		t.Errorf("due:today did not match once the clock passed midnight")
+	}
+}
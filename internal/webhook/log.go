@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Outcomes of a delivery attempt
+const (
+	OutcomeDelivered = "delivered" // the endpoint answered 2xx
+	OutcomeRetrying  = "retrying"  // failed, another attempt follows
+	OutcomeFailed    = "failed"    // failed for good: attempts used up or a 4xx answer
+	OutcomeDropped   = "dropped"   // never attempted, the hook's queue was full
+)
+
+// logSize is how many delivery attempts the in-memory log keeps
+const logSize = 500
+
+// Delivery is one attempt to deliver an event to a hook
+type Delivery struct {
+	Time        time.Time `json:"time"`
+	Hook        string    `json:"hook"`
+	EventID     string    `json:"event_id"`
+	Type        string    `json:"type"`
+	UUID        string    `json:"uuid"`
+	Attempt     int       `json:"attempt,omitempty"`
+	Outcome     string    `json:"outcome"`
+	Status      int       `json:"status,omitempty"` // HTTP status, absent if there was no response
+	Error       string    `json:"error,omitempty"`
+	Duration    int64     `json:"duration_ms"`
+	NextAttempt time.Time `json:"next_attempt,omitzero"` // retrying only
+}
+
+// deliveryLog keeps the most recent delivery attempts in memory and
+// appends every attempt to a JSON Lines file if one is configured
+type deliveryLog struct {
+	mu      sync.Mutex
+	entries []Delivery
+	file    *os.File
+}
+
+func newDeliveryLog(path string) (*deliveryLog, error) {
+	l := &deliveryLog{}
+	if path != "" {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open webhook log: %w", err)
+		}
+		l.file = file
+	}
+	return l, nil
+}
+
+func (l *deliveryLog) add(d Delivery) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, d)
+	if len(l.entries) > logSize {
+		l.entries = append([]Delivery(nil), l.entries[len(l.entries)-logSize:]...)
+	}
+	if l.file != nil {
+		line, _ := json.Marshal(d)
+		if _, err := l.file.Write(append(line, '\n')); err != nil {
+			log.Printf("webhook: failed to write delivery log: %v", err)
+		}
+	}
+}
+
+// recent returns up to limit entries, newest first, of the named hook or
+// of every hook if hook is ""; limit <= 0 means all that are kept
+func (l *deliveryLog) recent(hook string, limit int) []Delivery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	deliveries := []Delivery{}
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		if limit > 0 && len(deliveries) == limit {
+			break
+		}
+		if hook == "" || l.entries[i].Hook == hook {
+			deliveries = append(deliveries, l.entries[i])
+		}
+	}
+	return deliveries
+}
+
+func (l *deliveryLog) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+}